@@ -0,0 +1,77 @@
+package heos
+
+import (
+	"context"
+	"errors"
+)
+
+// A Batch is a sequence of commands which are executed in order by
+// Client.Exec, such as the commands needed to activate a scene:
+//
+//	var b heos.Batch
+//	b.Add("group/set_group?pid=1", nil)
+//	b.Add("browse/play_input?pid=1&input=inputs/aux_in_1", nil)
+//	b.Add("player/set_volume?pid=1&level=20", nil)
+//	b.Add("player/set_play_state?pid=1&state=play", nil)
+//	res, err := c.Exec(ctx, &b)
+type Batch struct {
+	// ContinueOnError, if true, causes Exec to execute all commands even if
+	// some fail. Otherwise, Exec stops at the first failure.
+	ContinueOnError bool
+
+	queries []batchQuery
+}
+
+// A batchQuery is a single command in a Batch.
+type batchQuery struct {
+	query string
+	out   interface{}
+}
+
+// Add adds a command to the Batch. query and out have the same meaning as in
+// Client.Query.
+func (b *Batch) Add(query string, out interface{}) {
+	b.queries = append(b.queries, batchQuery{query: query, out: out})
+}
+
+// Len returns the number of commands in the Batch.
+func (b *Batch) Len() int { return len(b.queries) }
+
+// A BatchResult is the result of a single command executed by Client.Exec.
+type BatchResult struct {
+	// Query is the command which was executed.
+	Query string
+
+	// Command and Err are the values returned by Client.Query for the
+	// command.
+	Command *Command
+	Err     error
+}
+
+// Exec executes the commands in b in order and returns a result for each
+// command which was executed. The returned error reports any failures: if
+// b.ContinueOnError is false, it is the error from the first failed command,
+// and no further commands are executed.
+func (c *Client) Exec(ctx context.Context, b *Batch) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(b.queries))
+
+	var errs []error
+	for _, q := range b.queries {
+		cmd, err := c.Query(ctx, q.query, q.out)
+		results = append(results, BatchResult{
+			Query:   q.query,
+			Command: cmd,
+			Err:     err,
+		})
+		if err == nil {
+			continue
+		}
+
+		if !b.ContinueOnError || ctx.Err() != nil {
+			return results, err
+		}
+		errs = append(errs, err)
+	}
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientExec(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://player/set_volume?pid=2&level=20\r\n":
+			return json.RawMessage(`{"heos": {"command": "player/set_volume", "result": "fail", "message": "eid=2&text=ID Not Valid"}}`)
+		case "heos://player/get_players\r\n":
+			return response("player/get_players", "", []heos.PlayerInfo{{Name: "Kitchen", PID: 1}})
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	newBatch := func(continueOnError bool) (*heos.Batch, *[]heos.PlayerInfo) {
+		var ps []heos.PlayerInfo
+		b := &heos.Batch{ContinueOnError: continueOnError}
+		b.Add("group/set_group?pid=1", nil)
+		b.Add("player/set_volume?pid=2&level=20", nil)
+		b.Add("player/get_players", &ps)
+		return b, &ps
+	}
+
+	// Stop on the first error.
+	b, _ := newBatch(false)
+	res, err := c.Exec(ctx, b)
+	if err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+	if diff := cmp.Diff(2, len(res)); diff != "" {
+		t.Fatalf("unexpected number of results (-want +got):\n%s", diff)
+	}
+	if res[0].Err != nil || res[1].Err == nil {
+		t.Fatalf("unexpected results: %+v", res)
+	}
+
+	// Continue past errors.
+	b, ps := newBatch(true)
+	res, err = c.Exec(ctx, b)
+	if err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+	if diff := cmp.Diff(3, len(res)); diff != "" {
+		t.Fatalf("unexpected number of results (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]heos.PlayerInfo{{Name: "Kitchen", PID: 1}}, *ps, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected players (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,202 @@
+package heos
+
+import (
+	"context"
+	"sync"
+)
+
+// An OverflowPolicy determines what a Broadcaster does when a Subscriber's
+// buffer is full.
+type OverflowPolicy int
+
+// Possible OverflowPolicy values.
+const (
+	// OverflowBlock waits for the Subscriber to receive an event, which
+	// delays delivery to every other Subscriber.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest discards the oldest event in the Subscriber's
+	// buffer to make room for the new event.
+	OverflowDropOldest
+)
+
+// SubscriberConfig contains options for a Subscriber. The zero value or a nil
+// SubscriberConfig are valid and enable the default behaviors.
+type SubscriberConfig struct {
+	// Buffer is the number of events buffered for the Subscriber. If zero,
+	// 16 events are buffered.
+	Buffer int
+
+	// Overflow determines what happens when the Subscriber's buffer is full.
+	// The default is OverflowBlock.
+	Overflow OverflowPolicy
+
+	// Filter, if not nil, selects which events are delivered to the
+	// Subscriber.
+	Filter EventFilter
+}
+
+// A Broadcaster delivers each event from a single source, such as an
+// EventStream, to any number of independent Subscribers, so that several
+// parts of a program can consume events from one connection. A Broadcaster
+// is safe for concurrent use.
+//
+//	b := heos.NewBroadcaster()
+//	go b.Run(ctx, es.Events())
+//
+//	ui := b.Subscribe(nil)
+//	metrics := b.Subscribe(&heos.SubscriberConfig{Overflow: heos.OverflowDropOldest})
+type Broadcaster struct {
+	mu     sync.Mutex
+	subs   map[*Subscriber]struct{}
+	closed bool
+}
+
+// NewBroadcaster creates a Broadcaster. Use Run to begin delivering events.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subs: make(map[*Subscriber]struct{})}
+}
+
+// Run delivers each event from events, which typically come from an
+// EventStream, to every Subscriber. Run consumes all events from events and
+// blocks until the context is canceled or events is closed, and then closes
+// the channels of all Subscribers. Run must be called at most once.
+func (b *Broadcaster) Run(ctx context.Context, events <-chan Event) {
+	defer func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.closed = true
+		for s := range b.subs {
+			delete(b.subs, s)
+			close(s.c)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			b.mu.Lock()
+			for s := range b.subs {
+				s.deliver(ctx, e)
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// Subscribe adds a Subscriber which receives the events delivered after it
+// is added. If cfg is nil, a default configuration is used. If Run has
+// returned, the Subscriber's channel is already closed.
+func (b *Broadcaster) Subscribe(cfg *SubscriberConfig) *Subscriber {
+	if cfg == nil {
+		cfg = &SubscriberConfig{}
+	}
+
+	n := cfg.Buffer
+	if n <= 0 {
+		n = 16
+	}
+
+	s := &Subscriber{
+		b:        b,
+		c:        make(chan Event, n),
+		done:     make(chan struct{}),
+		overflow: cfg.Overflow,
+		filter:   cfg.Filter,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(s.c)
+		return s
+	}
+	b.subs[s] = struct{}{}
+
+	return s
+}
+
+// A Subscriber receives events from a Broadcaster.
+type Subscriber struct {
+	b        *Broadcaster
+	c        chan Event
+	done     chan struct{}
+	overflow OverflowPolicy
+	filter   EventFilter
+
+	// dropped is guarded by b.mu.
+	dropped int
+
+	closeOnce sync.Once
+}
+
+// Events returns a channel which delivers events. The channel is closed when
+// the Subscriber is closed or the Broadcaster stops running.
+func (s *Subscriber) Events() <-chan Event { return s.c }
+
+// Dropped returns the number of events discarded by OverflowDropOldest
+// because the Subscriber's buffer was full.
+func (s *Subscriber) Dropped() int {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	return s.dropped
+}
+
+// Close removes the Subscriber from its Broadcaster and closes its channel.
+// Close is safe to call more than once.
+func (s *Subscriber) Close() {
+	s.closeOnce.Do(func() {
+		// Unblock any pending delivery before waiting for it to complete.
+		close(s.done)
+
+		s.b.mu.Lock()
+		defer s.b.mu.Unlock()
+
+		if _, ok := s.b.subs[s]; ok {
+			delete(s.b.subs, s)
+			close(s.c)
+		}
+	})
+}
+
+// deliver delivers e to the Subscriber according to its OverflowPolicy. The
+// caller must hold s.b.mu.
+func (s *Subscriber) deliver(ctx context.Context, e Event) {
+	if s.filter != nil && !s.filter(e) {
+		return
+	}
+
+	if s.overflow == OverflowBlock {
+		select {
+		case s.c <- e:
+		case <-s.done:
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	for {
+		select {
+		case s.c <- e:
+			return
+		default:
+		}
+
+		// The buffer is full, so make room. The consumer may empty the
+		// buffer concurrently, in which case nothing is discarded.
+		select {
+		case <-s.c:
+			s.dropped++
+		default:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package heos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestBroadcaster(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b := heos.NewBroadcaster()
+
+	all := b.Subscribe(nil)
+	volume := b.Subscribe(&heos.SubscriberConfig{
+		Filter: heos.EventTypes(&heos.PlayerVolumeChanged{}),
+	})
+	latest := b.Subscribe(&heos.SubscriberConfig{
+		Buffer:   2,
+		Overflow: heos.OverflowDropOldest,
+	})
+	// A blocked Subscriber delays the others until it is closed.
+	stuck := b.Subscribe(&heos.SubscriberConfig{Buffer: 1})
+
+	events := make(chan heos.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Run(ctx, events)
+	}()
+
+	in := []heos.Event{
+		&heos.PlayerVolumeChanged{PID: 1, Level: 10},
+		&heos.PlayerStateChanged{PID: 1, State: heos.StatePlay},
+		&heos.PlayerVolumeChanged{PID: 1, Level: 20},
+		&heos.PlayersChanged{},
+	}
+
+	events <- in[0]
+	events <- in[1]
+	time.AfterFunc(50*time.Millisecond, stuck.Close)
+	for _, e := range in[2:] {
+		events <- e
+	}
+	close(events)
+	<-done
+
+	recv := func(s *heos.Subscriber) []heos.Event {
+		var es []heos.Event
+		for e := range s.Events() {
+			es = append(es, e)
+		}
+		return es
+	}
+
+	if diff := cmp.Diff(in, recv(all)); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]heos.Event{in[0], in[2]}, recv(volume)); diff != "" {
+		t.Fatalf("unexpected volume events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(in[2:], recv(latest)); diff != "" {
+		t.Fatalf("unexpected latest events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(2, latest.Dropped()); diff != "" {
+		t.Fatalf("unexpected number of dropped events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]heos.Event{in[0]}, recv(stuck)); diff != "" {
+		t.Fatalf("unexpected stuck events (-want +got):\n%s", diff)
+	}
+
+	// Subscribers added after Run returns receive nothing.
+	if _, ok := <-b.Subscribe(nil).Events(); ok {
+		t.Fatal("expected closed channel for late subscriber")
+	}
+}
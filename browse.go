@@ -0,0 +1,319 @@
+package heos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mdlayher/heos/wire"
+)
+
+// Source IDs for the music sources built into HEOS devices.
+const (
+	SourceLocalMedia = 1024
+	SourcePlaylists  = 1025
+	SourceHistory    = 1026
+	SourceAUXInputs  = 1027
+	SourceFavorites  = 1028
+)
+
+// Names of common physical inputs on HEOS devices, for use with
+// Browse.PlayInput.
+const (
+	InputAUXIn1     = "inputs/aux_in_1"
+	InputAUXIn2     = "inputs/aux_in_2"
+	InputAUXSingle  = "inputs/aux_single"
+	InputLineIn1    = "inputs/line_in_1"
+	InputOpticalIn1 = "inputs/optical_in_1"
+	InputOpticalIn2 = "inputs/optical_in_2"
+	InputCoaxIn1    = "inputs/coax_in_1"
+	InputHDMIARC1   = "inputs/hdmi_arc_1"
+	InputTVAudio    = "inputs/tvaudio"
+	InputPhono      = "inputs/phono"
+	InputUSBDAC     = "inputs/usbdac"
+)
+
+// Browse wraps HEOS Browse commands.
+type Browse struct {
+	c *Client
+}
+
+// A MusicSource is a source of media available to a HEOS device.
+type MusicSource struct {
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+
+	// Type is the type of source, such as "music_service" or "heos_server".
+	Type string   `json:"type"`
+	SID  SourceID `json:"sid"`
+
+	// Available reports whether the source can be used, such as a music
+	// service which is linked to the signed in HEOS account. Devices which do
+	// not report availability report all sources as available.
+	Available bool `json:"available"`
+
+	// ServiceUsername is the username of the account linked to a music
+	// service, if any.
+	ServiceUsername string `json:"service_username,omitempty"`
+
+	// Raw is the JSON object from which the MusicSource was decoded, which may
+	// contain fields not otherwise supported by this package.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ms *MusicSource) UnmarshalJSON(b []byte) error {
+	// Devices report availability as the string "true" or "false".
+	type musicSource MusicSource
+	var v struct {
+		musicSource
+		Available json.RawMessage `json:"available"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*ms = MusicSource(v.musicSource)
+	switch string(v.Available) {
+	case "", "null", "true", `"true"`:
+		ms.Available = true
+	case "false", `"false"`:
+		ms.Available = false
+	default:
+		return fmt.Errorf("heos: invalid music source availability: %s", v.Available)
+	}
+
+	return nil
+}
+
+// A MediaItem is a container or media item returned when browsing a music
+// source.
+type MediaItem struct {
+	// Container reports whether the item contains other items, and Playable
+	// reports whether the item can be played.
+	Container bool
+	Playable  bool
+
+	// Type is the type of item, such as "artist", "song", or "station".
+	Type     string
+	Name     string
+	ImageURL string
+	Artist   string
+	Album    string
+
+	// CID is set for containers, and MID is set for media.
+	CID string
+	MID string
+
+	// SID is set for items which are themselves music sources, such as the
+	// servers within the local media source. These items are browsed by
+	// source ID rather than container ID.
+	SID SourceID
+
+	// Raw is the JSON object from which the MediaItem was decoded, which may
+	// contain fields not otherwise supported by this package.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (mi *MediaItem) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Container string   `json:"container"`
+		Playable  string   `json:"playable"`
+		Type      string   `json:"type"`
+		Name      string   `json:"name"`
+		ImageURL  string   `json:"image_url"`
+		Artist    string   `json:"artist"`
+		Album     string   `json:"album"`
+		CID       string   `json:"cid"`
+		MID       string   `json:"mid"`
+		SID       SourceID `json:"sid"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*mi = MediaItem{
+		Container: v.Container == "yes",
+		Playable:  v.Playable == "yes",
+		Type:      v.Type,
+		Name:      v.Name,
+		ImageURL:  v.ImageURL,
+		Artist:    v.Artist,
+		Album:     v.Album,
+		CID:       v.CID,
+		MID:       v.MID,
+		SID:       v.SID,
+	}
+
+	// Nested sources carry no container attribute, but must be browsed to
+	// reach their contents.
+	if mi.SID != 0 {
+		mi.Container = true
+	}
+
+	return nil
+}
+
+// GetMusicSources returns the music sources available to a device.
+func (b *Browse) GetMusicSources(ctx context.Context) ([]MusicSource, error) {
+	var mss []MusicSource
+	if _, err := b.c.Query(ctx, "browse/get_music_sources", &mss); err != nil {
+		return nil, err
+	}
+
+	return mss, nil
+}
+
+// Browse returns the items in the music source specified by sid. If cid is not
+// empty, the items in the container specified by cid are returned instead.
+func (b *Browse) Browse(ctx context.Context, sid SourceID, cid string) ([]MediaItem, error) {
+	var mis []MediaItem
+	if _, err := b.c.Query(ctx, browseQuery(sid, cid), &mis); err != nil {
+		return nil, err
+	}
+
+	return mis, nil
+}
+
+// BrowseRange is like Browse, but returns only the items selected by r, and
+// Counts which report whether more items remain. Use BrowseAll to retrieve all
+// of the items in a large container.
+func (b *Browse) BrowseRange(ctx context.Context, sid SourceID, cid string, r Range) ([]MediaItem, Counts, error) {
+	if err := r.check(); err != nil {
+		return nil, Counts{}, err
+	}
+
+	var mis []MediaItem
+	cmd, err := b.c.Query(ctx, browseQuery(sid, cid)+"&range="+r.String(), &mis)
+	if err != nil {
+		return nil, Counts{}, err
+	}
+
+	return mis, counts(cmd, len(mis)), nil
+}
+
+// BrowseAll is like Browse, but returns all of the items in a container,
+// issuing as many requests as necessary.
+func (b *Browse) BrowseAll(ctx context.Context, sid SourceID, cid string) ([]MediaItem, error) {
+	var all []MediaItem
+	err := EachPage(maxRange, func(r Range) (Counts, error) {
+		mis, c, err := b.BrowseRange(ctx, sid, cid, r)
+		all = append(all, mis...)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// BrowseEach is like Browse, but invokes fn for each item as it is decoded
+// from the device's response rather than returning a slice, which reduces
+// memory use for large containers. If fn returns an error, no further items
+// are passed to fn and BrowseEach returns the error. If Config.Retry causes
+// the command to be retried, fn may be invoked again for the same items.
+func (b *Browse) BrowseEach(ctx context.Context, sid SourceID, cid string, fn func(mi MediaItem) error) error {
+	return queryEach(ctx, b.c, browseQuery(sid, cid), fn)
+}
+
+// browseQuery returns the browse/browse query for sid and cid.
+func browseQuery(sid SourceID, cid string) string {
+	q := fmt.Sprintf("browse/browse?sid=%d", sid)
+	if cid != "" {
+		q += "&cid=" + wire.Escape(cid)
+	}
+
+	return q
+}
+
+// GetFavorites returns the HEOS favorites, in the order used by
+// PlayFavorite.
+func (b *Browse) GetFavorites(ctx context.Context) ([]MediaItem, error) {
+	return b.Browse(ctx, SourceFavorites, "")
+}
+
+// PlayPreset plays the HEOS favorite specified by the one-based index preset
+// on the player specified by pid.
+func (b *Browse) PlayPreset(ctx context.Context, pid PlayerID, preset int) error {
+	if preset < 1 {
+		return fmt.Errorf("heos: invalid preset %d", preset)
+	}
+
+	_, err := b.c.Query(ctx, fmt.Sprintf("browse/play_preset?pid=%d&preset=%d", pid, preset), nil)
+	return err
+}
+
+// PlayFavorite plays the HEOS favorite at the zero-based index i, as returned
+// by GetFavorites, on the player specified by pid.
+func (b *Browse) PlayFavorite(ctx context.Context, pid PlayerID, i int) error {
+	return b.PlayPreset(ctx, pid, i+1)
+}
+
+// PlayFavoriteByName plays the HEOS favorite whose name matches name, ignoring
+// case, on the player specified by pid.
+func (b *Browse) PlayFavoriteByName(ctx context.Context, pid PlayerID, name string) error {
+	favs, err := b.GetFavorites(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, f := range favs {
+		if strings.EqualFold(f.Name, name) {
+			return b.PlayFavorite(ctx, pid, i)
+		}
+	}
+
+	return fmt.Errorf("heos: no favorite named %q", name)
+}
+
+// PlayInput plays the physical input specified by input, such as InputAUXIn1,
+// on the player specified by pid. To play an input on a group, specify the
+// group leader's player ID.
+func (b *Browse) PlayInput(ctx context.Context, pid PlayerID, input string) error {
+	_, err := b.c.Query(ctx, fmt.Sprintf("browse/play_input?pid=%d&input=%s", pid, wire.Escape(input)), nil)
+	return err
+}
+
+// PlayInputFrom plays the physical input specified by input on the source
+// player specified by spid, using the destination player specified by pid.
+// This allows, for example, audio from one device's AUX input to be played on
+// a different player or group.
+func (b *Browse) PlayInputFrom(ctx context.Context, pid, spid PlayerID, input string) error {
+	_, err := b.c.Query(ctx, fmt.Sprintf("browse/play_input?pid=%d&spid=%d&input=%s", pid, spid, wire.Escape(input)), nil)
+	return err
+}
+
+// PlayStream plays the station specified by mid from the music source
+// specified by sid on the player specified by pid. cid is the container of the
+// station, and may be empty.
+func (b *Browse) PlayStream(ctx context.Context, pid PlayerID, sid SourceID, cid, mid string) error {
+	q := fmt.Sprintf("browse/play_stream?pid=%d&sid=%d", pid, sid)
+	if cid != "" {
+		q += "&cid=" + wire.Escape(cid)
+	}
+
+	_, err := b.c.Query(ctx, q+"&mid="+wire.Escape(mid), nil)
+	return err
+}
+
+// PlayURL plays the media at the absolute URL specified by rawURL, such as an
+// internet radio stream or a file served over HTTP, on the player specified by
+// pid.
+func (b *Browse) PlayURL(ctx context.Context, pid PlayerID, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() {
+		return fmt.Errorf("heos: stream URL %q must be absolute", rawURL)
+	}
+
+	// The URL's own query parameters must be escaped so that they are not
+	// treated as attributes of the HEOS command.
+	_, err = b.c.Query(ctx, fmt.Sprintf("browse/play_stream?pid=%d&url=%s", pid, wire.Escape(rawURL)), nil)
+	return err
+}
@@ -0,0 +1,205 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestBrowseFavorites(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://browse/browse?sid=1028\r\n":
+			return frames{
+				// Devices may send an interim response before the result.
+				response("browse/browse", "command under process&sid=1028", nil),
+				response("browse/browse", "sid=1028&returned=2&count=2", json.RawMessage(`[
+					{"container": "no", "mid": "s1", "type": "station", "playable": "yes", "name": "Jazz FM", "image_url": ""},
+					{"container": "no", "mid": "s2", "type": "station", "playable": "yes", "name": "Classic Rock", "image_url": ""}
+				]`)),
+			}
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	favs, err := c.Browse.GetFavorites(ctx)
+	if err != nil {
+		t.Fatalf("failed to get favorites: %v", err)
+	}
+
+	want := []heos.MediaItem{
+		{Playable: true, Type: "station", Name: "Jazz FM", MID: "s1"},
+		{Playable: true, Type: "station", Name: "Classic Rock", MID: "s2"},
+	}
+	if diff := cmp.Diff(want, favs, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected favorites (-want +got):\n%s", diff)
+	}
+
+	if err := c.Browse.PlayFavorite(ctx, 1, 0); err != nil {
+		t.Fatalf("failed to play favorite: %v", err)
+	}
+	if err := c.Browse.PlayFavoriteByName(ctx, 1, "classic rock"); err != nil {
+		t.Fatalf("failed to play favorite by name: %v", err)
+	}
+	if err := c.Browse.PlayFavoriteByName(ctx, 1, "Polka"); err == nil {
+		t.Fatal("expected an error for unknown favorite, but none occurred")
+	}
+
+	wantReqs := []string{
+		"heos://browse/browse?sid=1028\r\n",
+		"heos://browse/play_preset?pid=1&preset=1\r\n",
+		"heos://browse/browse?sid=1028\r\n",
+		"heos://browse/play_preset?pid=1&preset=2\r\n",
+		"heos://browse/browse?sid=1028\r\n",
+	}
+	if diff := cmp.Diff(wantReqs, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestBrowsePlayInput(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+		return ack(req)
+	})
+	defer done()
+
+	if err := c.Browse.PlayInput(ctx, 1, heos.InputAUXIn1); err != nil {
+		t.Fatalf("failed to play input: %v", err)
+	}
+	if err := c.Browse.PlayInputFrom(ctx, 1, 2, heos.InputOpticalIn1); err != nil {
+		t.Fatalf("failed to play input from another player: %v", err)
+	}
+
+	want := []string{
+		"heos://browse/play_input?pid=1&input=inputs/aux_in_1\r\n",
+		"heos://browse/play_input?pid=1&spid=2&input=inputs/optical_in_1\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestBrowsePlayURL(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		const want = "heos://browse/play_stream?pid=1&url=http://radio.example.com/stream.mp3?station%3Djazz%26bitrate%3D128\r\n"
+		if diff := cmp.Diff(want, req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		return response("browse/play_stream", "", nil)
+	})
+	defer done()
+
+	if err := c.Browse.PlayURL(ctx, 1, "http://radio.example.com/stream.mp3?station=jazz&bitrate=128"); err != nil {
+		t.Fatalf("failed to play URL: %v", err)
+	}
+
+	if err := c.Browse.PlayURL(ctx, 1, "stream.mp3"); err == nil {
+		t.Fatal("expected an error for relative URL, but none occurred")
+	}
+}
+
+func TestBrowseBrowseEach(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://browse/browse?sid=1&cid=c1\r\n":
+			return frames{
+				response("browse/browse", "command under process&sid=1&cid=c1", nil),
+				// A late response to another command must not be streamed.
+				response("player/get_players", "", []heos.PlayerInfo{{PID: 1}}),
+				response("browse/browse", "sid=1&cid=c1&returned=3&count=3", json.RawMessage(`[
+					{"container": "no", "mid": "t1", "type": "song", "playable": "yes", "name": "One"},
+					{"container": "no", "mid": "t2", "type": "song", "playable": "yes", "name": "Two"},
+					{"container": "no", "mid": "t3", "type": "song", "playable": "yes", "name": "Three"}
+				]`)),
+			}
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	var names []string
+	err := c.Browse.BrowseEach(ctx, 1, "c1", func(mi heos.MediaItem) error {
+		names = append(names, mi.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to browse: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"One", "Two", "Three"}, names); diff != "" {
+		t.Fatalf("unexpected names (-want +got):\n%s", diff)
+	}
+
+	// Stop after the first item. The rest of the response must be consumed so
+	// that the connection remains usable.
+	errStop := errors.New("stop")
+	names = nil
+	err = c.Browse.BrowseEach(ctx, 1, "c1", func(mi heos.MediaItem) error {
+		names = append(names, mi.Name)
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected stop error, but got: %v", err)
+	}
+	if diff := cmp.Diff([]string{"One"}, names); diff != "" {
+		t.Fatalf("unexpected names (-want +got):\n%s", diff)
+	}
+
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+}
+
+func TestBrowseBrowseAll(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, strings.TrimSpace(req))
+
+		// A container with 200 items. The device reports the count, so no
+		// request is made for a third, empty page.
+		switch req {
+		case "heos://browse/browse?sid=1&cid=c1&range=0,99\r\n",
+			"heos://browse/browse?sid=1&cid=c1&range=100,199\r\n":
+		default:
+			panicf("unexpected client request: %q", req)
+		}
+
+		items := strings.TrimSuffix(strings.Repeat(`{"name": "x"},`, 100), ",")
+		return response("browse/browse", "sid=1&cid=c1&returned=100&count=200", json.RawMessage("["+items+"]"))
+	})
+	defer done()
+
+	mis, err := c.Browse.BrowseAll(ctx, 1, "c1")
+	if err != nil {
+		t.Fatalf("failed to browse: %v", err)
+	}
+	if diff := cmp.Diff(200, len(mis)); diff != "" {
+		t.Fatalf("unexpected number of items (-want +got):\n%s", diff)
+	}
+
+	want := []string{
+		"heos://browse/browse?sid=1&cid=c1&range=0,99",
+		"heos://browse/browse?sid=1&cid=c1&range=100,199",
+	}
+
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := c.Browse.BrowseRange(ctx, 1, "c1", heos.Range{Start: 10, End: 5}); err == nil {
+		t.Fatal("expected an error for an invalid range, but none occurred")
+	}
+}
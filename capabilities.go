@@ -0,0 +1,340 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Version is a HEOS firmware version, such as "1.520.200", as reported in
+// PlayerInfo.Version.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses a firmware version of the form "major.minor.patch".
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("heos: invalid firmware version %q", s)
+	}
+
+	var v [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("heos: invalid firmware version %q", s)
+		}
+		v[i] = n
+	}
+
+	return Version{Major: v[0], Minor: v[1], Patch: v[2]}, nil
+}
+
+// String returns the string form of a Version.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0, or 1 if v is older than, the same as, or newer than
+// w, respectively.
+func (v Version) Compare(w Version) int {
+	for _, d := range [...]int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Capabilities describes the commands supported by a player's firmware.
+//
+// A Client checks each command before it is sent. Commands which were added
+// by later firmware, such as paging with player/get_queue, are checked
+// against a table of minimum firmware versions. In addition, the Client
+// learns which commands each firmware version does not support: when a
+// player reports that it does not recognize a command, the command is
+// recorded as unsupported for the player's firmware version. Commands which
+// are known to be unsupported by their target fail immediately with an
+// *UnsupportedError.
+//
+// Commands target the player specified by their pid attribute, or the
+// leader of the group specified by their gid attribute, whose player ID is
+// the group ID. A player's firmware version is known once it is reported by
+// Players.GetPlayers, Players.GetPlayerInfo, or Players.Capabilities.
+type Capabilities struct {
+	// PID is the ID of the player.
+	PID PlayerID
+
+	// Version is the player's firmware version.
+	Version Version
+
+	unsupported map[string]bool
+}
+
+// Supports reports whether the player is believed to support command, such as
+// "player/get_quickselects". Commands which are not known to require newer
+// firmware are assumed to be supported until the player reports otherwise.
+func (c *Capabilities) Supports(command string) bool {
+	if min, ok := minVersion(command, nil); ok && c.Version.Compare(min) < 0 {
+		return false
+	}
+
+	return !c.unsupported[command]
+}
+
+// Unsupported returns the commands the player is known not to support.
+func (c *Capabilities) Unsupported() []string {
+	cmds := make([]string, 0, len(c.unsupported))
+	for cmd := range c.unsupported {
+		cmds = append(cmds, cmd)
+	}
+	for _, r := range requirements {
+		if r.attr == "" && c.Version.Compare(r.min) < 0 && !c.unsupported[r.command] {
+			cmds = append(cmds, r.command)
+		}
+	}
+
+	sort.Strings(cmds)
+	return cmds
+}
+
+// Capabilities returns the Capabilities of the player specified by pid,
+// querying its firmware version.
+func (p *Players) Capabilities(ctx context.Context, pid PlayerID) (*Capabilities, error) {
+	pi, err := p.GetPlayerInfo(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := ParseVersion(pi.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.c.caps.capabilities(pid, v), nil
+}
+
+// An UnsupportedError is returned when a command is not sent because the
+// target player's firmware is known not to support it. UnsupportedErrors
+// match ErrUnsupported using errors.Is.
+type UnsupportedError struct {
+	// Command is the command which was not sent.
+	Command string
+
+	// PID and Version identify the player and its firmware version. PID is
+	// zero for commands which do not target a player, in which case Version
+	// is the newest firmware version known to the Client.
+	PID     PlayerID
+	Version Version
+
+	// Requires is the minimum firmware version for the command, or the zero
+	// Version if the player reported that it does not support the command.
+	Requires Version
+}
+
+// Error implements error.
+func (e *UnsupportedError) Error() string {
+	var s string
+	if e.PID != 0 {
+		s = fmt.Sprintf("heos: %s: unsupported by player %d firmware %s", e.Command, e.PID, e.Version)
+	} else {
+		s = fmt.Sprintf("heos: %s: unsupported by firmware %s", e.Command, e.Version)
+	}
+	if e.Requires != (Version{}) {
+		s += fmt.Sprintf(", requires %s", e.Requires)
+	}
+
+	return s
+}
+
+// Is implements errors.Is.
+func (e *UnsupportedError) Is(target error) bool { return target == ErrUnsupported }
+
+// A requirement is the minimum firmware version for a command, or for a
+// command when it is sent with an attribute.
+type requirement struct {
+	command, attr string
+	min           Version
+}
+
+// requirements are the minimum firmware versions for commands and options
+// which were added after the initial HEOS CLI release. Newer device
+// generations, such as AVRs, use higher major versions and satisfy every
+// requirement. Commands which are not listed are assumed to be supported.
+var requirements = []requirement{
+	{command: "player/get_queue", attr: "range", min: Version{Major: 1, Minor: 385}},
+	{command: "browse/get_search_criteria", min: Version{Major: 1, Minor: 385}},
+}
+
+// minVersion returns the minimum firmware version for command when sent with
+// the attributes q, and whether the command has a minimum version. If q is
+// nil, only requirements for the command itself are considered.
+func minVersion(command string, q url.Values) (Version, bool) {
+	var (
+		min   Version
+		found bool
+	)
+	for _, r := range requirements {
+		if r.command != command || (r.attr != "" && !q.Has(r.attr)) {
+			continue
+		}
+		if !found || r.min.Compare(min) > 0 {
+			min, found = r.min, true
+		}
+	}
+
+	return min, found
+}
+
+// required reports whether command has any minimum firmware version.
+func required(command string) bool {
+	for _, r := range requirements {
+		if r.command == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// capabilities tracks player firmware versions and the commands they do not
+// support. The zero value is ready to use.
+type capabilities struct {
+	mu          sync.Mutex
+	versions    map[PlayerID]Version
+	unsupported map[Version]map[string]bool
+}
+
+// learn records the firmware version reported for the player specified by
+// pid. Versions which cannot be parsed are ignored.
+func (cs *capabilities) learn(pid PlayerID, version string) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.versions == nil {
+		cs.versions = make(map[PlayerID]Version)
+	}
+	cs.versions[pid] = v
+}
+
+// capabilities returns a snapshot of the Capabilities of the player specified
+// by pid, which runs firmware version v.
+func (cs *capabilities) capabilities(pid PlayerID, v Version) *Capabilities {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	unsupported := make(map[string]bool, len(cs.unsupported[v]))
+	for cmd := range cs.unsupported[v] {
+		unsupported[cmd] = true
+	}
+
+	return &Capabilities{PID: pid, Version: v, unsupported: unsupported}
+}
+
+// target returns the player ID targeted by the attributes q, which is the
+// group's leader for commands which target a group, and the player's
+// firmware version, if known.
+func (cs *capabilities) target(q url.Values) (PlayerID, Version, bool) {
+	raw := q.Get("pid")
+	if raw == "" {
+		// A group's ID is the player ID of its leader.
+		raw = q.Get("gid")
+	}
+	if raw == "" {
+		return 0, Version{}, false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, Version{}, false
+	}
+
+	pid := PlayerID(n)
+	v, ok := cs.versions[pid]
+	return pid, v, ok
+}
+
+// newest returns the newest firmware version known to cs.
+func (cs *capabilities) newest() (Version, bool) {
+	var (
+		newest Version
+		found  bool
+	)
+	for _, v := range cs.versions {
+		if !found || v.Compare(newest) > 0 {
+			newest, found = v, true
+		}
+	}
+
+	return newest, found
+}
+
+// check returns an *UnsupportedError if the command in u is known to be
+// unsupported by its target player.
+func (cs *capabilities) check(u *url.URL) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if len(cs.versions) == 0 || (len(cs.unsupported) == 0 && !required(u.Path)) {
+		// There is nothing to check, so avoid parsing u.
+		return nil
+	}
+
+	q := u.Query()
+	pid, v, ok := cs.target(q)
+	min, hasMin := minVersion(u.Path, q)
+
+	switch {
+	case ok && hasMin && v.Compare(min) < 0:
+		return &UnsupportedError{Command: u.Path, PID: pid, Version: v, Requires: min}
+	case ok && cs.unsupported[v][u.Path]:
+		return &UnsupportedError{Command: u.Path, PID: pid, Version: v}
+	case !ok && pid == 0 && hasMin:
+		// The command does not target a player, so it is only known to be
+		// unsupported if every known player's firmware is too old.
+		if newest, ok := cs.newest(); ok && newest.Compare(min) < 0 {
+			return &UnsupportedError{Command: u.Path, Version: newest, Requires: min}
+		}
+	}
+
+	return nil
+}
+
+// observe records the command in u as unsupported by its target player's
+// firmware version if err indicates that the player did not recognize it.
+func (cs *capabilities) observe(u *url.URL, err error) {
+	var herr *Error
+	if !errors.As(err, &herr) || herr.EID != eidUnrecognizedCommand {
+		return
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	_, v, ok := cs.target(u.Query())
+	if !ok {
+		return
+	}
+
+	if cs.unsupported == nil {
+		cs.unsupported = make(map[Version]map[string]bool)
+	}
+	if cs.unsupported[v] == nil {
+		cs.unsupported[v] = make(map[string]bool)
+	}
+	cs.unsupported[v][u.Path] = true
+}
@@ -0,0 +1,198 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := heos.ParseVersion("1.520.200")
+	if err != nil {
+		t.Fatalf("failed to parse version: %v", err)
+	}
+
+	if diff := cmp.Diff(heos.Version{Major: 1, Minor: 520, Patch: 200}, v); diff != "" {
+		t.Fatalf("unexpected version (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("1.520.200", v.String()); diff != "" {
+		t.Fatalf("unexpected version string (-want +got):\n%s", diff)
+	}
+
+	older := heos.Version{Major: 1, Minor: 505, Patch: 400}
+	if older.Compare(v) != -1 || v.Compare(older) != 1 || v.Compare(v) != 0 {
+		t.Fatal("unexpected version ordering")
+	}
+
+	for _, s := range []string{"", "1.520", "1.520.x", "1.-1.0"} {
+		if _, err := heos.ParseVersion(s); err == nil {
+			t.Fatalf("expected an error for %q, but none occurred", s)
+		}
+	}
+}
+
+func TestPlayersCapabilities(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://player/get_player_info?pid=1\r\n":
+			return response("player/get_player_info", "pid=1", heos.PlayerInfo{PID: 1, Version: "1.430.160"})
+		case "heos://player/get_player_info?pid=2\r\n":
+			return response("player/get_player_info", "pid=2", heos.PlayerInfo{PID: 2, Version: "1.520.200"})
+		case "heos://player/get_quickselects?pid=1\r\n":
+			return json.RawMessage(`{"heos": {"command": "player/get_quickselects", "result": "fail", "message": "eid=1&text=Unrecognized Command"}}`)
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	caps, err := c.Players.Capabilities(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get capabilities: %v", err)
+	}
+	if !caps.Supports("player/get_quickselects") {
+		t.Fatal("expected command to be supported before the player reports otherwise")
+	}
+
+	// The device rejects the command, after which the Client does not send
+	// it to a player running that firmware again.
+	const query = "player/get_quickselects?pid=1"
+	for i := 0; i < 2; i++ {
+		if _, err := c.Query(ctx, query, nil); !errors.Is(err, heos.ErrUnsupported) {
+			t.Fatalf("expected unsupported error, but got: %v", err)
+		}
+	}
+
+	_, err = c.Query(ctx, query, nil)
+	var uerr *heos.UnsupportedError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected *heos.UnsupportedError, but got: %v", err)
+	}
+
+	wantErr := &heos.UnsupportedError{
+		Command: "player/get_quickselects",
+		PID:     1,
+		Version: heos.Version{Major: 1, Minor: 430, Patch: 160},
+	}
+	if diff := cmp.Diff(wantErr, uerr); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+
+	caps, err = c.Players.Capabilities(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get capabilities: %v", err)
+	}
+	if diff := cmp.Diff([]string{"player/get_quickselects"}, caps.Unsupported()); diff != "" {
+		t.Fatalf("unexpected unsupported commands (-want +got):\n%s", diff)
+	}
+
+	// Players running other firmware are unaffected.
+	caps, err = c.Players.Capabilities(ctx, 2)
+	if err != nil {
+		t.Fatalf("failed to get capabilities: %v", err)
+	}
+	if !caps.Supports("player/get_quickselects") {
+		t.Fatal("expected command to be supported by newer firmware")
+	}
+	if _, err := c.Query(ctx, "player/get_quickselects?pid=2", nil); err != nil {
+		t.Fatalf("failed to query newer firmware: %v", err)
+	}
+
+	want := []string{
+		"heos://player/get_player_info?pid=1\r\n",
+		"heos://player/get_quickselects?pid=1\r\n",
+		"heos://player/get_player_info?pid=1\r\n",
+		"heos://player/get_player_info?pid=2\r\n",
+		"heos://player/get_quickselects?pid=2\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersCapabilitiesMinimumVersion(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://player/get_players\r\n":
+			return response("player/get_players", "", []heos.PlayerInfo{
+				{PID: 1, Version: "1.300.100"},
+				{PID: 2, Version: "1.520.200"},
+			})
+		case "heos://player/get_player_info?pid=1\r\n":
+			return response("player/get_player_info", "pid=1", heos.PlayerInfo{PID: 1, Version: "1.300.100"})
+		case "heos://group/get_volume?gid=1\r\n":
+			return json.RawMessage(`{"heos": {"command": "group/get_volume", "result": "fail", "message": "eid=1&text=Unrecognized Command"}}`)
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	if _, err := c.Players.GetPlayers(ctx); err != nil {
+		t.Fatalf("failed to get players: %v", err)
+	}
+
+	// Paging the queue requires newer firmware than player 1 runs, so the
+	// command is not sent.
+	_, _, err := c.Players.GetQueue(ctx, 1, heos.Page(0, 10))
+	var uerr *heos.UnsupportedError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected *heos.UnsupportedError, but got: %v", err)
+	}
+
+	wantErr := &heos.UnsupportedError{
+		Command:  "player/get_queue",
+		PID:      1,
+		Version:  heos.Version{Major: 1, Minor: 300, Patch: 100},
+		Requires: heos.Version{Major: 1, Minor: 385},
+	}
+	if diff := cmp.Diff(wantErr, uerr); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := c.Players.GetQueue(ctx, 2, heos.Page(0, 10)); err != nil {
+		t.Fatalf("failed to get queue: %v", err)
+	}
+
+	// Commands which do not target a player are sent when any player's
+	// firmware is new enough.
+	if _, err := c.Browse.GetSearchCriteria(ctx, 1); err != nil {
+		t.Fatalf("failed to get search criteria: %v", err)
+	}
+
+	// Commands which target a group are checked against its leader.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Groups.GetVolume(ctx, 1); !errors.Is(err, heos.ErrUnsupported) {
+			t.Fatalf("expected unsupported error, but got: %v", err)
+		}
+	}
+
+	caps, err := c.Players.Capabilities(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get capabilities: %v", err)
+	}
+	wantCmds := []string{"browse/get_search_criteria", "group/get_volume"}
+	if diff := cmp.Diff(wantCmds, caps.Unsupported()); diff != "" {
+		t.Fatalf("unexpected unsupported commands (-want +got):\n%s", diff)
+	}
+
+	want := []string{
+		"heos://player/get_players\r\n",
+		"heos://player/get_queue?pid=2&range=0,9\r\n",
+		"heos://browse/get_search_criteria?sid=1\r\n",
+		"heos://group/get_volume?gid=1\r\n",
+		"heos://player/get_player_info?pid=1\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
@@ -1,110 +1,976 @@
 package heos
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/url"
-	"os"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/mdlayher/heos/netctx"
+	"github.com/mdlayher/heos/wire"
 )
 
-// deadlineNow is a time far in the past which can trigger immediate connection
-// cancelation.
-var deadlineNow = time.Unix(1, 0)
+// A Result is the result of a command reported by a device.
+type Result string
+
+// Possible Result values.
+const (
+	ResultSuccess Result = "success"
+	ResultFail    Result = "fail"
+)
 
 // A Command contains command acknowledgement data returned as a response to
 // Client requests.
 type Command struct {
-	HEOS struct {
-		Command string `json:"command"`
-		Result  string `json:"result"`
-		Message string `json:"message"`
-	} `json:"heos"`
+	HEOS CommandHeader `json:"heos"`
+
+	// Payload is the raw payload of the response, if any, so that callers can
+	// access fields not otherwise supported by this package. Payload is not
+	// set when the payload is streamed, such as by Browse.BrowseEach.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// A CommandHeader is the header of a device's response to a command.
+type CommandHeader struct {
+	// Command is the command which was processed, such as
+	// "player/get_volume".
+	Command string `json:"command"`
+
+	// Result reports whether the command succeeded.
+	Result Result `json:"result"`
+
+	// Message contains the response's message attributes, such as
+	// "pid=1&level=20".
+	Message string `json:"message"`
+}
+
+// Ok reports whether the device processed the command successfully.
+func (c *Command) Ok() bool {
+	return c.HEOS.Result == ResultSuccess
+}
+
+// Attributes parses the message attributes of the Command, such as the
+// "level" attribute returned by "player/get_volume".
+func (c *Command) Attributes() wire.Attributes {
+	return wire.ParseAttributes(c.HEOS.Message)
+}
+
+// An Error is an error reported by a HEOS device in response to a command.
+type Error struct {
+	// Command is the command which failed, such as "system/heart_beat".
+	Command string
+
+	// EID is the HEOS error ID reported by the device.
+	EID int
+
+	// Text is the human-readable error text reported by the device.
+	Text string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("heos: %s: error %d: %s", e.Command, e.EID, e.Text)
+}
+
+// Config contains options for a Client. The zero value or a nil Config are
+// valid and enable the default behaviors.
+type Config struct {
+	// Metrics, if not nil, receives instrumentation data from the Client.
+	Metrics Metrics
+
+	// Logger, if not nil, is used to log outgoing commands, device responses,
+	// and decoding failures.
+	Logger *slog.Logger
+
+	// LogLevel is the level used when logging routine commands and responses.
+	// Failures are always logged at slog.LevelWarn. If nil, slog.LevelDebug is
+	// used.
+	LogLevel slog.Leveler
+
+	// Timeout, if non-zero, is applied to each query issued by the Client
+	// when the caller's context has no deadline, so that an unresponsive
+	// device cannot block a caller indefinitely. When retries are enabled,
+	// Timeout applies to each attempt.
+	Timeout time.Duration
+
+	// SlowTimeout, if non-zero, is applied instead of Timeout to commands
+	// which a device may take several seconds to process, such as
+	// "browse/browse", "browse/search", "system/sign_in", and
+	// "player/get_queue", which often contact a music service. This permits
+	// a short Timeout for quick commands such as heartbeats and volume
+	// changes without failing legitimate slow browses.
+	SlowTimeout time.Duration
+
+	// Retry, if not nil, enables automatic retries of commands which fail due
+	// to transient conditions.
+	Retry *RetryPolicy
+
+	// Reconnect, if not nil, causes an EventStream created by DialEvents to
+	// redial its device and register for change events again when its
+	// connection fails, rather than stopping.
+	Reconnect *ReconnectPolicy
+
+	// Credentials, if not nil, provides HEOS account credentials which are
+	// used to sign in automatically when a device reports that a command
+	// requires a signed in user.
+	Credentials CredentialProvider
+
+	// Dialer, if not nil, is used by Dial to dial connections, such as
+	// through a SOCKS5 proxy or an SSH tunnel to reach devices on a remote
+	// network. If nil, a net.Dialer is used.
+	Dialer ContextDialer
+
+	// RateLimit, if non-zero, is the minimum interval between commands sent
+	// by the Client. Commands issued more rapidly wait for their turn, which
+	// prevents flooding a device with commands, such as from a volume slider.
+	RateLimit time.Duration
+
+	// Coalesce, if true, coalesces volume, mute, and play state commands
+	// which set state on the same player or group, such as
+	// "player/set_volume", while they wait due to RateLimit. Only the most
+	// recent command is sent, in the slot reserved by the first, and
+	// superseded commands return ErrSuperseded. Coalesce has no effect unless
+	// RateLimit is set.
+	Coalesce bool
+
+	// EventFilter, if not nil, selects which change events are delivered by
+	// an EventStream using the Client's connection. Events for which
+	// EventFilter returns false are discarded, such as high frequency
+	// PlayerNowPlayingProgress events which a program does not need. Use
+	// EventTypes to filter by event type.
+	EventFilter EventFilter
+
+	// EventHistory, if non-zero, is the number of recent events retained by
+	// an EventStream using the Client's connection, which can be retrieved
+	// using EventStream.Recent. Events discarded by EventFilter are not
+	// retained.
+	EventHistory int
+
+	// VolumeLimit, if not nil, enforces maximum volume levels for players
+	// when their volume or their group's volume is changed by the Client. Use Players.EnforceVolumeLimit
+	// to also enforce the limits when volume is changed by other means.
+	VolumeLimit *VolumeLimit
+
+	// Strict, if true, causes payloads containing fields which are not known
+	// to this package to be rejected with an error, so that protocol changes
+	// between firmware versions are detected during development rather than
+	// silently ignored. Payloads decoded by custom methods, such as MediaItem,
+	// are not checked. Strict should not be used in production.
+	Strict bool
+
+	// MaxMessageSize, if non-zero, is the maximum size in bytes of a
+	// response or event read from a device, including streamed payloads such
+	// as those of Browse.BrowseEach. Larger messages fail with
+	// ErrMessageTooLarge rather than being buffered without bound, which
+	// protects against misbehaving devices or other services listening on
+	// the HEOS port. Because the remainder of an oversized message cannot be
+	// skipped reliably, the Client's connection is closed.
+	MaxMessageSize int
+
+	// Tracer, if not nil, creates a span for each command sent by the Client
+	// and for each reconnect performed by an EventStream.
+	Tracer Tracer
+
+	// Interceptors, if not empty, wrap every query issued by the Client,
+	// including those issued by the System, Players, Groups, and Browse
+	// methods. The first Interceptor is the outermost, and observes each
+	// query first. The initial handshake is not intercepted.
+	Interceptors []Interceptor
+
+	// SkipHandshake, if true, causes Dial and New to return without
+	// performing the initial heartbeat handshake with the device. The
+	// handshake is instead performed when the Client is first used to send a
+	// command, so that creating a Client over a slow link does not block, and
+	// so that no heartbeat is sent on connections where one is not desired.
+	// Errors which Dial or New would report are reported by the first
+	// command instead.
+	SkipHandshake bool
+
+	// Tap, if not nil, receives copies of all raw data sent and received on
+	// the Client's connection, including the initial handshake, so that
+	// protocol traces can be captured for bug reports. Use Record to capture
+	// a transcript which can be served back to a Client using Replay.
+	Tap Tap
+}
+
+// A ContextDialer dials network connections. *net.Dialer and the dialers
+// returned by golang.org/x/net/proxy implement ContextDialer.
+type ContextDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// A CredentialProvider provides HEOS account credentials to a Client on
+// demand, so that long-running services can sign in again automatically.
+type CredentialProvider interface {
+	// Credentials returns the username and password for a HEOS account.
+	Credentials(ctx context.Context) (username, password string, err error)
+}
+
+// StaticCredentials is a CredentialProvider which always returns the same
+// credentials.
+type StaticCredentials struct {
+	Username, Password string
+}
+
+// Credentials implements CredentialProvider.
+func (sc StaticCredentials) Credentials(_ context.Context) (string, string, error) {
+	return sc.Username, sc.Password, nil
+}
+
+// Metrics is an interface which can be implemented to collect instrumentation
+// data from a Client, such as by exporting it to Prometheus.
+type Metrics interface {
+	// ObserveQuery is called when a query completes with the query's command
+	// (such as "system/heart_beat"), its round-trip duration, and any error
+	// which occurred. Errors reported by a device are of type *Error and can
+	// be inspected using errors.As to count failures by EID.
+	ObserveQuery(command string, d time.Duration, err error)
+
+	// ObserveEvent is called when an EventStream receives a change event
+	// with the event's command, such as "event/player_state_changed", so
+	// that event throughput can be measured.
+	ObserveEvent(command string)
+
+	// ObserveReconnect is called after each attempt by an EventStream to
+	// re-establish its failed connection with the attempt's number, starting
+	// at 1, and any error which occurred. A nil error indicates that the
+	// EventStream reconnected.
+	ObserveReconnect(attempt int, err error)
 }
 
 // A Client is a Denon HEOS protocol client.
 type Client struct {
-	System System
+	System  System
+	Players Players
+	Groups  Groups
+	Browse  Browse
+
+	mu  sync.Mutex
+	b   []byte
+	c   Transport
+	dec *wire.Decoder
+
+	// stale holds timed out commands whose responses may still arrive.
+	stale []*url.URL
+
+	metrics  Metrics
+	logger   *slog.Logger
+	logLevel slog.Leveler
+	timeout  time.Duration
+	slow     time.Duration
+	creds    CredentialProvider
+	limiter  *limiter
+	retry    *RetryPolicy
+	filter   EventFilter
+	history  int
 
-	mu sync.Mutex
-	b  []byte
-	c  net.Conn
+	volumeLimit *VolumeLimit
+	strict      bool
+	maxSize     int
+	caps        capabilities
+
+	querier Querier
+	tracer  Tracer
+
+	state clientState
+
+	handshakeMu      sync.Mutex
+	handshakePending bool
 }
 
 // Dial dials a connection to the device specified by addr. The context is used
-// for cancelation and to set timeouts.
-func Dial(ctx context.Context, addr string) (*Client, error) {
-	var d net.Dialer
+// for cancelation and to set timeouts. If cfg is nil, a default configuration
+// is used.
+func Dial(ctx context.Context, addr string, cfg *Config) (*Client, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	var d ContextDialer = &net.Dialer{}
+	if cfg.Dialer != nil {
+		d = cfg.Dialer
+	}
+
 	conn, err := d.DialContext(ctx, "tcp", addr)
 	if err != nil {
 		return nil, err
 	}
 
+	c, err := New(ctx, conn, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// New creates a Client using an existing connection to a HEOS device, such as a
+// net.Conn or one returned by Record or Replay. The context is used for cancelation and to
+// set timeouts during the initial handshake. If cfg is nil, a default
+// configuration is used.
+func New(ctx context.Context, conn Transport, cfg *Config) (*Client, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.Tap != nil {
+		conn = &tapTransport{Transport: conn, tap: cfg.Tap}
+	}
+
 	c := &Client{
-		// TODO(mdlayher): is this enough to read large responses?
-		b: make([]byte, os.Getpagesize()),
-		c: conn,
+		c:       conn,
+		maxSize: cfg.MaxMessageSize,
+
+		metrics:  cfg.Metrics,
+		logger:   cfg.Logger,
+		logLevel: cfg.LogLevel,
+		timeout:  cfg.Timeout,
+		slow:     cfg.SlowTimeout,
+		creds:    cfg.Credentials,
+		retry:    cfg.Retry,
+	}
+	if c.logLevel == nil {
+		c.logLevel = slog.LevelDebug
+	}
+	c.dec = c.newDecoder(conn)
+	c.tracer = cfg.Tracer
+	c.querier = chain(QuerierFunc(c.issue), cfg.Interceptors)
+	c.filter = cfg.EventFilter
+	c.history = cfg.EventHistory
+	c.volumeLimit = cfg.VolumeLimit
+	c.strict = cfg.Strict
+	if cfg.RateLimit > 0 {
+		c.limiter = newLimiter(cfg.RateLimit, cfg.Coalesce)
 	}
 	c.System = System{c: c}
+	c.Players = Players{c: c}
+	c.Groups = Groups{c: c}
+	c.Browse = Browse{c: c}
+
+	if cfg.SkipHandshake {
+		// Defer the handshake until the Client is first used.
+		c.handshakePending = true
+		return c, nil
+	}
 
-	// Perform an initial handshake to verify that the device recognizes the
-	// HEOS protocol.
-	if err := c.System.Heartbeat(ctx); err != nil {
+	if err := c.handshake(ctx); err != nil {
 		return nil, err
 	}
 
 	return c, nil
 }
 
+// handshake performs the initial handshake with a device.
+func (c *Client) handshake(ctx context.Context) error {
+	// Verify that the device recognizes the HEOS protocol.
+	if _, err := c.issue(ctx, "system/heart_beat", nil); err != nil {
+		return err
+	}
+
+	// Some devices ship with prettified JSON responses enabled, so turn that
+	// off to keep responses compact. Devices which reject the command are not
+	// treated as fatal, since the decoder handles either form.
+	if _, err := c.issue(ctx, "system/prettify_json_response?enable=off", nil); err != nil {
+		var herr *Error
+		if !errors.As(err, &herr) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lazyHandshake performs the initial handshake with a device if it was
+// deferred by Config.SkipHandshake and has not yet succeeded.
+func (c *Client) lazyHandshake(ctx context.Context) error {
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+
+	if !c.handshakePending {
+		return nil
+	}
+
+	// A failed handshake is attempted again on the next use of the Client.
+	if err := c.handshake(ctx); err != nil {
+		return err
+	}
+
+	c.handshakePending = false
+	return nil
+}
+
 // Close closes the Client's connection.
+//
+// Commands in progress are interrupted and fail with ErrClientClosed. Use
+// Shutdown to wait for them to complete instead.
 func (c *Client) Close() error {
+	c.state.mu.Lock()
+	c.state.closing = true
+	c.state.closed = true
+	c.state.mu.Unlock()
+
 	return c.c.Close()
 }
 
 // Query issues a raw query to a device. The query string should be a HEOS
 // request of the form "system/heart_beat" or similar. out is a structure used
 // to unmarshal the response JSON data from a query's results.
-func (c *Client) Query(ctx context.Context, query string, out interface{}) (*Command, error) {
+//
+// If the device reports a failure, the returned error is of type *Error.
+func (c *Client) Query(ctx context.Context, query string, out interface{}) (_ *Command, err error) {
+	if err := c.state.begin(); err != nil {
+		return nil, err
+	}
+	defer func() { err = c.state.end(err) }()
+
+	if err := c.lazyHandshake(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.querier.Query(ctx, query, out)
+}
+
+// issue performs the work for Query once any Interceptors have been applied.
+func (c *Client) issue(ctx context.Context, query string, out interface{}) (*Command, error) {
 	u, err := url.Parse(query)
 	if err != nil {
 		return nil, err
 	}
 	u.Scheme = "heos"
 
-	// Embed a Command along with the payload to unmarshal the result, so the
-	// caller does not have to add Command to their own structures.
-	v := struct {
-		Command
-		Payload interface{} `json:"payload"`
-	}{
-		Payload: out,
+	if err := c.caps.check(u); err != nil {
+		return nil, err
+	}
+
+	attempts := c.retry.attempts()
+	for i := 0; ; i++ {
+		cmd, err := c.attempt(ctx, u, out)
+		if err == nil || i == attempts-1 || !transient(ctx, u.Path, err) {
+			c.caps.observe(u, err)
+			return cmd, err
+		}
+
+		d := c.retry.backoff(i)
+		c.log(ctx, c.logLevel.Level(), "retrying command",
+			slog.String("command", u.Path),
+			slog.Int("attempt", i+2),
+			slog.Duration("backoff", d),
+			slog.Any("error", err),
+		)
+
+		if err := sleep(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// withTimeout applies the Client's timeout for command to ctx if ctx has no
+// deadline.
+func (c *Client) withTimeout(ctx context.Context, command string) (context.Context, context.CancelFunc) {
+	timeout := c.timeout
+	if c.slow > 0 && slowCommand(command) {
+		timeout = c.slow
+	}
+
+	if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return ctx, func() {}
+}
+
+// slowCommand reports whether command is subject to Config.SlowTimeout.
+func slowCommand(command string) bool {
+	switch command {
+	case "browse/browse", "browse/search", "system/sign_in", "player/get_queue":
+		return true
+	}
+
+	return false
+}
+
+// attempt makes a single attempt to issue a query.
+func (c *Client) attempt(ctx context.Context, u *url.URL, out interface{}) (*Command, error) {
+	ctx, cancel := c.withTimeout(ctx, u.Path)
+	defer cancel()
+
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd, err := c.roundTrip(ctx, u, out)
+
+	var herr *Error
+	if c.creds != nil && u.Path != "system/sign_in" && errors.As(err, &herr) && herr.EID == eidUserNotLoggedIn {
+		// The command requires a signed in user, so sign in and try again.
+		if _, err := c.signIn(ctx); err != nil {
+			return nil, err
+		}
+
+		return c.roundTrip(ctx, u, out)
 	}
 
+	return cmd, err
+}
+
+// roundTrip issues a single query with logging and instrumentation.
+func (c *Client) roundTrip(ctx context.Context, u *url.URL, out interface{}) (*Command, error) {
+	if c.logger != nil {
+		// Avoid formatting the query unless it will be logged.
+		c.log(ctx, c.logLevel.Level(), "sending command", slog.String("query", redact(u)))
+	}
+
+	ctx, span := c.startCommandSpan(ctx, u)
+	start := time.Now()
+	cmd, err := c.query(ctx, u, out)
+	took := time.Since(start)
+	endCommandSpan(span, cmd, err)
+	if c.metrics != nil {
+		c.metrics.ObserveQuery(u.Path, took, err)
+	}
+
+	switch {
+	case err != nil:
+		c.log(ctx, slog.LevelWarn, "command failed",
+			slog.String("command", u.Path),
+			slog.Duration("took", took),
+			slog.Any("error", err),
+		)
+	default:
+		c.log(ctx, c.logLevel.Level(), "received response",
+			slog.String("command", cmd.HEOS.Command),
+			slog.String("result", string(cmd.HEOS.Result)),
+			slog.String("message", cmd.HEOS.Message),
+			slog.Duration("took", took),
+		)
+	}
+
+	return cmd, err
+}
+
+// signIn signs in using the Client's CredentialProvider, returning the
+// username used.
+func (c *Client) signIn(ctx context.Context) (string, error) {
+	un, pw, err := c.creds.Credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return un, c.System.SignIn(ctx, un, pw)
+}
+
+// redact returns the string form of u with any password removed, for logging.
+func redact(u *url.URL) string {
+	if u.Path != "system/sign_in" {
+		return u.String()
+	}
+
+	attrs := wire.ParseAttributes(u.RawQuery)
+	if _, ok := attrs["pw"]; ok {
+		attrs["pw"] = "REDACTED"
+	}
+
+	return "heos://" + u.Path + "?" + attrs.Encode()
+}
+
+// redactCommand returns a raw command with any password removed, for logging.
+// Commands which cannot be parsed are reduced to their path.
+func redactCommand(command string) string {
+	command = strings.TrimPrefix(command, "heos://")
+	u, err := url.Parse(command)
+	if err != nil {
+		path, _, _ := strings.Cut(command, "?")
+		return "heos://" + path
+	}
+	u.Scheme = "heos"
+
+	return redact(u)
+}
+
+// A payloadFunc may be passed as the out argument of Query to decode a
+// response payload directly from the connection, rather than buffering it.
+// It must consume exactly one JSON value from dec.
+type payloadFunc func(dec *json.Decoder) error
+
+// queryEach issues query and invokes fn for each item of the response's array
+// payload as it is decoded. If fn returns an error, no further items are
+// passed to fn, and the error is returned once the response is consumed.
+func queryEach[T any](ctx context.Context, c *Client, query string, fn func(v T) error) error {
+	_, keepRaw := interface{}(new(T)).(rawSetter)
+
+	var ferr error
+	each := payloadFunc(func(dec *json.Decoder) error {
+		return wire.ForEach(dec, func() error {
+			var v T
+			if c.strict || keepRaw {
+				// Buffer each item so that it can be checked for unknown
+				// fields or retained without affecting the rest of the
+				// stream.
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					return err
+				}
+				if err := c.unmarshal(raw, &v); err != nil {
+					// The stream is still intact, so report the error once
+					// the response is consumed.
+					if ferr == nil {
+						ferr = err
+					}
+					return nil
+				}
+			} else if err := dec.Decode(&v); err != nil {
+				return err
+			}
+
+			if ferr == nil {
+				ferr = fn(v)
+			}
+			return nil
+		})
+	})
+
+	if _, err := c.Query(ctx, query, each); err != nil {
+		return err
+	}
+
+	return ferr
+}
+
+// query performs the work for Query.
+func (c *Client) query(ctx context.Context, u *url.URL, out interface{}) (*Command, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	err = do(ctx, c.c, func(conn net.Conn) error {
-		// Commands must have \r\n terminators.
-		if _, err := io.WriteString(conn, u.String()+"\r\n"); err != nil {
+	// Stream the payload of the matching response if requested, and discard
+	// the payloads of any other frames.
+	var fn func(h wire.Header, dec *json.Decoder) error
+	if each, ok := out.(payloadFunc); ok {
+		fn = func(h wire.Header, dec *json.Decoder) error {
+			if responds(h, u) && c.staleIndex(h) == -1 && Result(h.Result) != ResultFail {
+				return each(dec)
+			}
+
+			var skip json.RawMessage
+			return dec.Decode(&skip)
+		}
+	}
+
+	var (
+		f       *wire.Frame
+		written bool
+	)
+	err := netctx.Do(ctx, c.c, func() error {
+		if err := c.write(c.c, u.String()); err != nil {
 			return err
 		}
+		written = true
+
+		// Skip any events delivered to a connection which is registered for
+		// change events while waiting for the command response, any interim
+		// responses sent by devices for long-running commands, and any late
+		// responses to earlier commands which timed out.
+		for {
+			var err error
+			f, err = c.read(ctx, c.c, u.Path, fn)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case f.IsEvent(), strings.HasPrefix(f.HEOS.Message, "command under process"):
+			case c.discardStale(f.HEOS):
+				c.log(ctx, c.logLevel.Level(), "discarding late response to a timed out command",
+					slog.String("command", u.Path),
+					slog.String("response", f.HEOS.Command),
+				)
+			case f.HEOS.Command != "" && !responds(f.HEOS, u):
+				c.log(ctx, c.logLevel.Level(), "discarding response for another command",
+					slog.String("command", u.Path),
+					slog.String("response", f.HEOS.Command),
+				)
+			default:
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		if written && ctx.Err() != nil {
+			// The device may still respond to the command, so its response
+			// must not be mistaken for the response to a later command.
+			c.stale = append(c.stale, u)
+		}
+		return nil, err
+	}
+
+	cmd := newCommand(f.HEOS)
+	if err := checkError(&cmd); err != nil {
+		return nil, err
+	}
+
+	if fn == nil && len(f.Payload) > 0 {
+		cmd.Payload = append(json.RawMessage(nil), f.Payload...)
+	}
+
+	if out != nil && fn == nil && len(f.Payload) > 0 {
+		if err := c.unmarshal(f.Payload, out); err != nil {
+			c.log(ctx, slog.LevelWarn, "failed to decode payload",
+				slog.String("command", u.Path),
+				slog.String("payload", string(f.Payload)),
+				slog.Any("error", err),
+			)
+			return nil, err
+		}
+	}
 
-		n, err := conn.Read(c.b)
-		if err != nil {
+	return &cmd, nil
+}
+
+// idAttributes are the attributes which identify the target of a command,
+// and which devices echo in the message of the command's response.
+var idAttributes = []string{"pid", "gid", "sid", "cid", "mid", "qid", "aid", "scid"}
+
+// responds reports whether h may be the response to the command u: the
+// commands must match, as must the values of any identifying attributes of u
+// which are echoed by h.
+func responds(h wire.Header, u *url.URL) bool {
+	if h.Command != u.Path {
+		return false
+	}
+
+	var (
+		want = wire.ParseAttributes(u.RawQuery)
+		got  = h.Attributes()
+	)
+	for _, k := range idAttributes {
+		w, ok := want[k]
+		if !ok {
+			continue
+		}
+		if g, ok := got[k]; ok && g != w {
+			return false
+		}
+	}
+
+	return true
+}
+
+// staleIndex returns the index of the earliest timed out command awaiting a
+// response to which h may respond, or -1 if there is none. The caller must
+// hold c.mu.
+func (c *Client) staleIndex(h wire.Header) int {
+	for i, u := range c.stale {
+		if responds(h, u) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// discardStale reports whether h is the late response to a timed out
+// command, and if so, stops awaiting that command's response. Devices respond
+// to commands in order, so the earliest matching command is assumed. The
+// caller must hold c.mu.
+func (c *Client) discardStale(h wire.Header) bool {
+	i := c.staleIndex(h)
+	if i == -1 {
+		return false
+	}
+
+	c.stale = append(c.stale[:i], c.stale[i+1:]...)
+	return true
+}
+
+// unmarshal unmarshals the payload b into out, rejecting unknown fields if
+// the Client is in strict mode.
+func (c *Client) unmarshal(b []byte, out interface{}) error {
+	if !c.strict {
+		if err := json.Unmarshal(b, out); err != nil {
 			return err
 		}
 
-		return json.Unmarshal(c.b[:n], &v)
+		return setRaw(b, out)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("heos: strict decoding failed: %w", err)
+	}
+
+	return setRaw(b, out)
+}
+
+// Send writes a raw command such as "player/get_volume?pid=1" to the device
+// without waiting for a response. Use Receive to read the next message sent by
+// the device. The context is used for cancelation and to set timeouts.
+//
+// Send and Receive are intended for experimenting with commands which are not
+// otherwise supported by the Client. They must not be used concurrently with
+// other Client methods, or a response may be delivered to the wrong caller.
+func (c *Client) Send(ctx context.Context, command string) (err error) {
+	if err := c.state.begin(); err != nil {
+		return err
+	}
+	defer func() { err = c.state.end(err) }()
+
+	if err := c.lazyHandshake(ctx); err != nil {
+		return err
+	}
+
+	c.log(ctx, c.logLevel.Level(), "sending raw command", slog.String("command", redactCommand(command)))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return netctx.Do(ctx, c.c, func() error {
+		return c.write(c.c, command)
+	})
+}
+
+// A Frame is a raw message read from a device by Client.Receive.
+type Frame struct {
+	// Command is the parsed header of the message.
+	Command Command
+
+	// Data is the raw JSON data of the entire message.
+	Data []byte
+}
+
+// Receive reads the next raw message sent by the device, such as a response
+// to a command issued by Send. The context is used for cancelation and to set
+// timeouts.
+func (c *Client) Receive(ctx context.Context) (_ *Frame, err error) {
+	if err := c.state.begin(); err != nil {
+		return nil, err
+	}
+	defer func() { err = c.state.end(err) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var f *wire.Frame
+	err = netctx.Do(ctx, c.c, func() error {
+		var err error
+		f, err = c.read(ctx, c.c, "", nil)
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO(mdlayher): inspect Command for errors returned by the device.
-	return &v.Command, nil
+	return &Frame{
+		Command: newCommand(f.HEOS),
+		Data:    f.Raw,
+	}, nil
+}
+
+// write writes command to conn. The caller must hold c.mu.
+func (c *Client) write(conn Transport, command string) error {
+	b, err := wire.AppendRequest(c.b[:0], command)
+	if err != nil {
+		return err
+	}
+	c.b = b
+
+	_, err = conn.Write(b)
+	return err
+}
+
+// read reads the next frame from conn, streaming its payload to fn if fn is
+// not nil. The caller must hold c.mu.
+func (c *Client) read(ctx context.Context, conn Transport, command string, fn func(h wire.Header, dec *json.Decoder) error) (*wire.Frame, error) {
+	var (
+		f   *wire.Frame
+		err error
+	)
+	if fn != nil {
+		f, err = c.dec.DecodeFunc(fn)
+	} else {
+		f, err = c.dec.Decode()
+	}
+	if err != nil {
+		// The stream state is unknown after a failed read, so start over with
+		// a fresh Decoder for the next read.
+		c.dec = c.newDecoder(conn)
+		if errors.Is(err, wire.ErrTooLarge) {
+			// The rest of the message remains unread and the next message
+			// cannot be located, so the connection is no longer usable.
+			c.log(ctx, slog.LevelWarn, "message exceeds maximum size",
+				slog.String("command", command),
+				slog.Int("max", c.maxSize),
+			)
+			_ = conn.Close()
+			return nil, ErrMessageTooLarge
+		}
+		if _, ok := err.(net.Error); !ok && err != io.EOF {
+			c.log(ctx, slog.LevelWarn, "failed to decode response",
+				slog.String("command", command),
+				slog.Any("error", err),
+			)
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// newDecoder creates a wire.Decoder for conn which enforces the Client's
+// maximum message size.
+func (c *Client) newDecoder(conn Transport) *wire.Decoder {
+	dec := wire.NewDecoder(conn)
+	dec.SetMaxSize(int64(c.maxSize))
+	return dec
+}
+
+// newCommand creates a Command from a wire.Header.
+func newCommand(h wire.Header) Command {
+	var cmd Command
+	cmd.HEOS.Command = h.Command
+	cmd.HEOS.Result = Result(h.Result)
+	cmd.HEOS.Message = h.Message
+	return cmd
+}
+
+// log logs a message using the Client's logger, if one is configured.
+func (c *Client) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// checkError returns an *Error if cmd indicates that a device failed to
+// process a command.
+func checkError(cmd *Command) error {
+	if cmd.HEOS.Result != ResultFail {
+		return nil
+	}
+
+	// Failure messages are of the form: "eid=2&text=ID Not Valid&pid=1". Any
+	// parsing errors are ignored so that partial information is still
+	// returned to the caller.
+	attrs := cmd.Attributes()
+	eid, _ := attrs.Int("eid")
+
+	return &Error{
+		Command: cmd.HEOS.Command,
+		EID:     eid,
+		Text:    attrs["text"],
+	}
 }
 
 // System wraps HEOS System commands.
@@ -118,33 +984,230 @@ func (s *System) Heartbeat(ctx context.Context) error {
 	return err
 }
 
-// TODO(mdlayher): break this out into netctx package?
+// Ping issues a heartbeat request to a device and returns the measured round
+// trip time. Ping bypasses Config.RateLimit and Config.Retry so that neither
+// inflates the measurement.
+func (s *System) Ping(ctx context.Context) (_ time.Duration, err error) {
+	if err := s.c.state.begin(); err != nil {
+		return 0, err
+	}
+	defer func() { err = s.c.state.end(err) }()
 
-// do accepts an input context and net.Conn and invokes fn with the context's
-// cancelation and deadline attached to the net.Conn's lifecycle.
-func do(ctx context.Context, c net.Conn, fn func(c net.Conn) error) error {
-	// Enable immediate connection cancelation via context by using the context's
-	// deadline and also setting a deadline in the past if/when the context is
-	// canceled. This pattern courtesy of @acln from #networking on Gophers Slack.
-	dl, _ := ctx.Deadline()
-	if err := c.SetDeadline(dl); err != nil {
-		return err
+	if err := s.c.lazyHandshake(ctx); err != nil {
+		return 0, err
 	}
 
-	errC := make(chan error)
-	go func() { errC <- fn(c) }()
+	ctx, cancel := s.c.withTimeout(ctx, "system/heart_beat")
+	defer cancel()
+
+	u := &url.URL{Scheme: "heos", Path: "system/heart_beat"}
+	start := time.Now()
+	if _, err := s.c.roundTrip(ctx, u, nil); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
 
-	select {
-	case <-ctx.Done():
-		if ctx.Err() == context.Canceled {
-			if err := c.SetDeadline(deadlineNow); err != nil {
+// SampleLatency issues a Ping every interval and invokes fn with each result,
+// which is useful for diagnosing network problems with a device. SampleLatency
+// blocks until the context is canceled, and returns an error only if interval
+// is not positive. Ping errors are passed to fn and do not stop sampling.
+func (s *System) SampleLatency(ctx context.Context, interval time.Duration, fn func(rtt time.Duration, err error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("heos: invalid latency sampling interval %v", interval)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+
+		rtt, err := s.Ping(ctx)
+		if ctx.Err() != nil {
+			// Don't report errors caused by cancelation.
+			return nil
+		}
+
+		fn(rtt, err)
+	}
+}
+
+// CheckAccount reports whether a user is signed in to a HEOS account on a
+// device, and if so, returns the username.
+func (s *System) CheckAccount(ctx context.Context) (string, bool, error) {
+	cmd, err := s.c.Query(ctx, "system/check_account", nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	attrs := cmd.Attributes()
+	if _, ok := attrs["signed_in"]; !ok {
+		return "", false, nil
+	}
+
+	return attrs["un"], true, nil
+}
+
+// SignIn signs in to a HEOS account using the specified credentials.
+func (s *System) SignIn(ctx context.Context, username, password string) error {
+	_, err := s.c.Query(ctx, fmt.Sprintf("system/sign_in?un=%s&pw=%s", wire.Escape(username), wire.Escape(password)), nil)
+	return err
+}
+
+// KeepSignedIn signs in using the Client's Config.Credentials each time a
+// UserChanged event received from events reports that the user has signed
+// out, so that long-running services remain signed in. KeepSignedIn consumes
+// all events from events, which typically come from an EventStream, and
+// blocks until the context is canceled or events is closed. If signing in
+// fails, KeepSignedIn returns the error. The Client must not be the Client
+// used by an EventStream.
+func (s *System) KeepSignedIn(ctx context.Context, events <-chan Event) error {
+	if s.c.creds == nil {
+		return errors.New("heos: KeepSignedIn requires Config.Credentials")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if e, ok := e.(*UserChanged); !ok || e.SignedIn {
+				continue
+			}
+
+			s.c.log(ctx, s.c.logLevel.Level(), "user signed out, signing in again")
+			if _, err := s.c.signIn(ctx); err != nil {
 				return err
 			}
 		}
+	}
+}
+
+// KeepSignedInEvents is like KeepSignedIn, but rather than consuming events,
+// it returns a channel which delivers each event received from events, so
+// that it can be chained with other consumers such as Dispatch. Each
+// UserChanged event which reports that the user has signed out is followed by
+// an AutoSignIn event which reports the result of signing in again, so that
+// applications can notify users or resume playback. Failures to sign in are
+// reported by AutoSignIn rather than stopping KeepSignedInEvents.
+//
+// The returned channel is closed when the context is canceled or events is
+// closed. The Client must not be the Client used by an EventStream.
+func (s *System) KeepSignedInEvents(ctx context.Context, events <-chan Event) (<-chan Event, error) {
+	if s.c.creds == nil {
+		return nil, errors.New("heos: KeepSignedInEvents requires Config.Credentials")
+	}
+
+	out := make(chan Event, 16)
+	go func() {
+		defer close(out)
+
+		send := func(e Event) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- e:
+				return true
+			}
+		}
+
+		for {
+			var e Event
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				e = ev
+			}
+
+			if !send(e) {
+				return
+			}
+			if e, ok := e.(*UserChanged); !ok || e.SignedIn {
+				continue
+			}
+
+			s.c.log(ctx, s.c.logLevel.Level(), "user signed out, signing in again")
+			un, err := s.c.signIn(ctx)
+			if err != nil {
+				s.c.log(ctx, slog.LevelWarn, "failed to sign in again", slog.Any("error", err))
+			}
+
+			if !send(&AutoSignIn{Username: un, Err: err}) {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
 
-		<-errC
-		return ctx.Err()
-	case err := <-errC:
+// SignOut signs out of the HEOS account on a device.
+func (s *System) SignOut(ctx context.Context) error {
+	_, err := s.c.Query(ctx, "system/sign_out", nil)
+	return err
+}
+
+// Reboot reboots the device. The device drops its connections immediately
+// after acknowledging the command, so Reboot closes the Client, which must not
+// be used afterward. If the connection drops before the acknowledgement is
+// received, Reboot assumes the device is rebooting and reports success.
+func (s *System) Reboot(ctx context.Context) error {
+	_, err := s.c.Query(ctx, "system/reboot", nil)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, syscall.ECONNRESET) {
 		return err
 	}
+
+	_ = s.c.Close()
+	return nil
+}
+
+// RegisterForChangeEvents enables or disables change events on the Client's
+// connection. Most callers should use DialEvents or NewEventStream to receive
+// events on a dedicated connection instead.
+func (s *System) RegisterForChangeEvents(ctx context.Context, enable bool) error {
+	_, err := s.c.Query(ctx, "system/register_for_change_events?enable="+onOff(enable), nil)
+	return err
+}
+
+// PrettifyJSONResponse enables or disables prettified (multi-line, indented)
+// JSON responses from a device. Dial disables prettified responses when a
+// Client is created.
+func (s *System) PrettifyJSONResponse(ctx context.Context, enable bool) error {
+	_, err := s.c.Query(ctx, "system/prettify_json_response?enable="+onOff(enable), nil)
+	return err
+}
+
+// onOff converts a boolean to the "on" and "off" values used in HEOS commands.
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
+
+// parseOnOff parses the "on" and "off" values used in HEOS commands.
+func parseOnOff(s string) (bool, error) {
+	switch s {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("heos: invalid on/off value: %q", s)
+	}
 }
@@ -1,12 +1,18 @@
 package heos_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -42,6 +48,254 @@ func TestClientContextDeadlineExceeded(t *testing.T) {
 	}
 }
 
+func TestClientConfigTimeout(t *testing.T) {
+	cfg := &heos.Config{Timeout: 50 * time.Millisecond}
+	c, _, done := testClientConfig(t, cfg, func(_ string) interface{} {
+		// Respond too slowly for the configured timeout.
+		time.Sleep(250 * time.Millisecond)
+		return nil
+	})
+	defer done()
+
+	// No deadline is set on the caller's context, so the configured timeout
+	// must apply.
+	err := c.System.Heartbeat(context.Background())
+	if diff := cmp.Diff(context.DeadlineExceeded.Error(), err.Error()); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientLateResponse(t *testing.T) {
+	c, _, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://player/get_volume?pid=1\r\n":
+			// Respond after the request times out.
+			time.Sleep(100 * time.Millisecond)
+			return response("player/get_volume", "pid=1&level=10", nil)
+		case "heos://player/get_volume?pid=2\r\n":
+			return response("player/get_volume", "pid=2&level=20", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Players.GetVolume(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+
+	// The late response for player 1 uses the same command, but must not be
+	// mistaken for the response for player 2, or for any later command.
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		level, err := c.Players.GetVolume(ctx, 2)
+		if err != nil {
+			t.Fatalf("failed to get volume: %v", err)
+		}
+		if diff := cmp.Diff(20, level); diff != "" {
+			t.Fatalf("unexpected volume (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestClientConfigSlowTimeout(t *testing.T) {
+	cfg := &heos.Config{
+		Timeout:     50 * time.Millisecond,
+		SlowTimeout: 5 * time.Second,
+	}
+	c, _, done := testClientConfig(t, cfg, func(req string) interface{} {
+		// Respond too slowly for the quick command timeout.
+		time.Sleep(250 * time.Millisecond)
+
+		switch req {
+		case "heos://browse/browse?sid=1\r\n":
+			return response("browse/browse", "sid=1", []heos.MediaItem{})
+		default:
+			return nil
+		}
+	})
+	defer done()
+
+	// Browsing may legitimately take longer than a quick command.
+	if _, err := c.Browse.Browse(context.Background(), 1, ""); err != nil {
+		t.Fatalf("failed to browse: %v", err)
+	}
+
+	err := c.System.Heartbeat(context.Background())
+	if diff := cmp.Diff(context.DeadlineExceeded.Error(), err.Error()); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientConfigMaxMessageSize(t *testing.T) {
+	cfg := &heos.Config{MaxMessageSize: 1024}
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		// Respond with a payload which exceeds the configured limit.
+		return response("player/get_players", "", []heos.PlayerInfo{{
+			Name: strings.Repeat("x", 4096),
+		}})
+	})
+	defer done()
+
+	_, err := c.Players.GetPlayers(ctx)
+	if !errors.Is(err, heos.ErrMessageTooLarge) {
+		t.Fatalf("expected heos.ErrMessageTooLarge, but got: %v", err)
+	}
+
+	// The connection cannot be used after an oversized message.
+	if err := c.System.Heartbeat(ctx); err == nil {
+		t.Fatal("expected an error after an oversized message, but none occurred")
+	}
+}
+
+func TestClientConfigDialer(t *testing.T) {
+	d := &testDialer{}
+	_, _, done := testClientConfig(t, &heos.Config{Dialer: d}, nil)
+	defer done()
+
+	if diff := cmp.Diff(1, d.n); diff != "" {
+		t.Fatalf("unexpected number of dials (-want +got):\n%s", diff)
+	}
+}
+
+var _ heos.ContextDialer = &testDialer{}
+
+// testDialer is a heos.ContextDialer which counts dials.
+type testDialer struct {
+	n int
+}
+
+func (d *testDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.n++
+
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, address)
+}
+
+func TestClientTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		enc := json.NewEncoder(server)
+		r := bufio.NewReader(server)
+		for {
+			req, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if req == "heos://player/get_volume?pid=1\r\n" {
+				// Never respond, so the caller's deadline must interrupt the
+				// read.
+				continue
+			}
+
+			if err := enc.Encode(ack(req)); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Hide all of the net.Conn methods which are not part of heos.Transport.
+	c, err := heos.New(ctx, &testTransport{c: client}, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+
+	tctx, tcancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer tcancel()
+
+	if _, err := c.Players.GetVolume(tctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+}
+
+var _ heos.Transport = &testTransport{}
+
+// A testTransport is a heos.Transport which is not a net.Conn.
+type testTransport struct {
+	c net.Conn
+}
+
+func (t *testTransport) Read(b []byte) (int, error)    { return t.c.Read(b) }
+func (t *testTransport) Write(b []byte) (int, error)   { return t.c.Write(b) }
+func (t *testTransport) Close() error                  { return t.c.Close() }
+func (t *testTransport) SetDeadline(d time.Time) error { return t.c.SetDeadline(d) }
+
+func TestClientConfigSkipHandshake(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		enc := json.NewEncoder(server)
+		r := bufio.NewReader(server)
+		for {
+			req, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			reqs = append(reqs, strings.TrimSpace(req))
+			mu.Unlock()
+
+			if err := enc.Encode(ack(req)); err != nil {
+				return
+			}
+		}
+	}()
+
+	c, err := heos.New(ctx, client, &heos.Config{SkipHandshake: true})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	// The handshake is performed only once, before the first command.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Query(ctx, "player/get_volume?pid=1", nil); err != nil {
+			t.Fatalf("failed to query: %v", err)
+		}
+	}
+
+	want := []string{
+		"heos://system/heart_beat",
+		"heos://system/prettify_json_response?enable=off",
+		"heos://player/get_volume?pid=1",
+		"heos://player/get_volume?pid=1",
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
 func TestClientSystemHeartbeat(t *testing.T) {
 	c, ctx, done := testClient(t, func(req string) interface{} {
 		if diff := cmp.Diff("heos://system/heart_beat\r\n", req); diff != "" {
@@ -58,11 +312,518 @@ func TestClientSystemHeartbeat(t *testing.T) {
 	}
 }
 
+func TestClientSystemPing(t *testing.T) {
+	c, ctx, done := testClient(t, func(_ string) interface{} {
+		time.Sleep(10 * time.Millisecond)
+		return response("system/heart_beat", "", nil)
+	})
+	defer done()
+
+	rtt, err := c.System.Ping(ctx)
+	if err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	if rtt < 10*time.Millisecond {
+		t.Fatalf("round trip time is too short: %v", rtt)
+	}
+
+	// Collect several samples and then stop.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var n int
+	err = c.System.SampleLatency(ctx, 20*time.Millisecond, func(rtt time.Duration, err error) {
+		if err != nil {
+			panicf("failed to sample latency: %v", err)
+		}
+
+		if n++; n == 3 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to sample latency: %v", err)
+	}
+
+	if diff := cmp.Diff(3, n); diff != "" {
+		t.Fatalf("unexpected number of samples (-want +got):\n%s", diff)
+	}
+
+	if err := c.System.SampleLatency(ctx, 0, nil); err == nil {
+		t.Fatal("expected an error for zero interval, but none occurred")
+	}
+}
+
+func TestClientSystemPingRateLimit(t *testing.T) {
+	cfg := &heos.Config{RateLimit: 200 * time.Millisecond}
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		return ack(req)
+	})
+	defer done()
+
+	// The handshake consumed the limiter's slots, and Ping must not wait for
+	// the next one.
+	rtt, err := c.System.Ping(ctx)
+	if err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	if rtt >= 100*time.Millisecond {
+		t.Fatalf("round trip time includes rate limiting: %v", rtt)
+	}
+}
+
+func TestClientDeviceError(t *testing.T) {
+	c, ctx, done := testClient(t, func(_ string) interface{} {
+		// Canned response captured from receiver.
+		return json.RawMessage(`{"heos": {"command": "system/heart_beat", "result": "fail", "message": "eid=2&text=ID Not Valid"}}`)
+	})
+	defer done()
+
+	err := c.System.Heartbeat(ctx)
+
+	var herr *heos.Error
+	if !errors.As(err, &herr) {
+		t.Fatalf("expected *heos.Error, but got: %v", err)
+	}
+
+	want := &heos.Error{
+		Command: "system/heart_beat",
+		EID:     2,
+		Text:    "ID Not Valid",
+	}
+
+	if diff := cmp.Diff(want, herr); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+	if !errors.Is(err, heos.ErrInvalidID) {
+		t.Fatalf("expected heos.ErrInvalidID, but got: %v", err)
+	}
+}
+
+func TestClientSystemPrettifyJSONResponse(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		if diff := cmp.Diff("heos://system/prettify_json_response?enable=on\r\n", req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		return nil
+	})
+	defer done()
+
+	if err := c.System.PrettifyJSONResponse(ctx, true); err != nil {
+		t.Fatalf("failed to enable prettified responses: %v", err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	var (
+		reqs     []string
+		signedIn bool
+	)
+
+	var buf bytes.Buffer
+	cfg := &heos.Config{
+		Logger:      slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Credentials: heos.StaticCredentials{Username: "user@example.com", Password: "hunter2&more"},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://system/check_account\r\n":
+			if signedIn {
+				return response("system/check_account", "signed_in&un=user@example.com", nil)
+			}
+			return response("system/check_account", "signed_out", nil)
+		case "heos://system/sign_in?un=user@example.com&pw=hunter2%26more\r\n":
+			signedIn = true
+			return frames{
+				response("system/sign_in", "command under process", nil),
+				response("system/sign_in", "signed_in&un=user@example.com", nil),
+			}
+		case "heos://browse/browse?sid=4\r\n":
+			if !signedIn {
+				return json.RawMessage(`{"heos": {"command": "browse/browse", "result": "fail", "message": "eid=8&text=User not logged in"}}`)
+			}
+			return response("browse/browse", "sid=4", []heos.MediaItem{})
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	if _, ok, err := c.System.CheckAccount(ctx); err != nil || ok {
+		t.Fatalf("expected signed out account, but got: %v, %v", ok, err)
+	}
+
+	// The first browse fails, so the Client must sign in and try again.
+	if _, err := c.Browse.Browse(ctx, 4, ""); err != nil {
+		t.Fatalf("failed to browse: %v", err)
+	}
+
+	un, ok, err := c.System.CheckAccount(ctx)
+	if err != nil || !ok {
+		t.Fatalf("expected signed in account, but got: %v, %v", ok, err)
+	}
+	if diff := cmp.Diff("user@example.com", un); diff != "" {
+		t.Fatalf("unexpected username (-want +got):\n%s", diff)
+	}
+
+	want := []string{
+		"heos://system/check_account\r\n",
+		"heos://browse/browse?sid=4\r\n",
+		"heos://system/sign_in?un=user@example.com&pw=hunter2%26more\r\n",
+		"heos://browse/browse?sid=4\r\n",
+		"heos://system/check_account\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("password must not appear in logs:\n%s", buf.String())
+	}
+}
+
+func TestClientSystemKeepSignedIn(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	cfg := &heos.Config{
+		Credentials: heos.StaticCredentials{Username: "user@example.com", Password: "hunter2"},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, req)
+
+		return response("system/sign_in", "signed_in&un=user@example.com", nil)
+	})
+	defer done()
+
+	events := make(chan heos.Event, 3)
+	events <- &heos.UserChanged{SignedIn: true, Username: "user@example.com"}
+	events <- &heos.PlayersChanged{}
+	events <- &heos.UserChanged{}
+	close(events)
+
+	if err := c.System.KeepSignedIn(ctx, events); err != nil {
+		t.Fatalf("failed to keep signed in: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Only the sign out triggers a sign in.
+	want := []string{"heos://system/sign_in?un=user@example.com&pw=hunter2\r\n"}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientSystemKeepSignedInEvents(t *testing.T) {
+	cfg := &heos.Config{
+		Credentials: heos.StaticCredentials{Username: "user@example.com", Password: "hunter2"},
+	}
+
+	var n int
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		// The first sign in succeeds, and the second fails.
+		n++
+		if n == 1 {
+			return response("system/sign_in", "signed_in&un=user@example.com", nil)
+		}
+
+		return json.RawMessage(`{"heos": {"command": "system/sign_in", "result": "fail", "message": "eid=6&text=Invalid credentials"}}`)
+	})
+	defer done()
+
+	events := make(chan heos.Event, 3)
+	events <- &heos.UserChanged{}
+	events <- &heos.PlayersChanged{}
+	events <- &heos.UserChanged{}
+	close(events)
+
+	out, err := c.System.KeepSignedInEvents(ctx, events)
+	if err != nil {
+		t.Fatalf("failed to keep signed in: %v", err)
+	}
+
+	var got []heos.Event
+	for e := range out {
+		got = append(got, e)
+	}
+
+	if diff := cmp.Diff(5, len(got)); diff != "" {
+		t.Fatalf("unexpected number of events (-want +got):\n%s", diff)
+	}
+
+	// Each sign out is followed by the result of signing in again.
+	in := got[1].(*heos.AutoSignIn)
+	if in.Err != nil || in.Username != "user@example.com" {
+		t.Fatalf("unexpected successful sign in: %+v", in)
+	}
+	if _, isPC := got[2].(*heos.PlayersChanged); !isPC {
+		t.Fatalf("expected PlayersChanged, but got: %#v", got[2])
+	}
+	if fail := got[4].(*heos.AutoSignIn); !errors.Is(fail.Err, heos.ErrUnauthorized) {
+		t.Fatalf("expected unauthorized error, but got: %v", fail.Err)
+	}
+
+	// Credentials are required.
+	c2, ctx2, done2 := testClient(t, nil)
+	defer done2()
+
+	if _, err := c2.System.KeepSignedInEvents(ctx2, events); err == nil {
+		t.Fatal("expected an error without credentials, but none occurred")
+	}
+}
+
+func TestClientSystemReboot(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		return ack(req)
+	})
+	defer done()
+
+	if err := c.System.Reboot(ctx); err != nil {
+		t.Fatalf("failed to reboot: %v", err)
+	}
+
+	// The Client is closed after a reboot.
+	if err := c.System.Heartbeat(ctx); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected closed connection, but got: %v", err)
+	}
+}
+
+func TestClientSystemRebootConnectionDropped(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		// Answer the handshake, then hang up without acknowledging the
+		// reboot.
+		enc := json.NewEncoder(server)
+		b := make([]byte, 128)
+		for i := 0; i < 2; i++ {
+			n, err := server.Read(b)
+			if err != nil {
+				panicf("failed to read request: %v", err)
+			}
+
+			if err := enc.Encode(ack(string(b[:n]))); err != nil {
+				panicf("failed to write response: %v", err)
+			}
+		}
+
+		_, _ = server.Read(b)
+	}()
+
+	c, err := heos.New(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.System.Reboot(ctx); err != nil {
+		t.Fatalf("failed to reboot: %v", err)
+	}
+}
+
+func TestClientSendReceive(t *testing.T) {
+	const resp = `{"heos": {"command": "player/get_volume", "result": "success", "message": "pid=1&level=20"}}`
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		if diff := cmp.Diff("heos://player/get_volume?pid=1\r\n", req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		return json.RawMessage(resp)
+	})
+	defer done()
+
+	if err := c.Send(ctx, "player/get_volume?pid=1"); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	f, err := c.Receive(ctx)
+	if err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+
+	// The test server re-encodes the response, so compare the decoded form.
+	var want, got interface{}
+	_ = json.Unmarshal([]byte(resp), &want)
+	if err := json.Unmarshal(f.Data, &got); err != nil {
+		t.Fatalf("failed to unmarshal frame data: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected frame data (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("pid=1&level=20", f.Command.HEOS.Message); diff != "" {
+		t.Fatalf("unexpected frame message (-want +got):\n%s", diff)
+	}
+	if !f.Command.Ok() {
+		t.Fatalf("expected successful command: %+v", f.Command)
+	}
+	if level, err := f.Command.Attributes().Int("level"); err != nil || level != 20 {
+		t.Fatalf("unexpected level attribute: %d, %v", level, err)
+	}
+
+	// No further data will arrive, so Receive must respect cancelation.
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Receive(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+}
+
+func TestClientSendRedactsPassword(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &heos.Config{
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		return ack(req)
+	})
+	defer done()
+
+	for _, cmd := range []string{
+		"system/sign_in?un=user@example.com&pw=hunter2",
+		"heos://system/sign_in?un=user@example.com&pw=hunter2",
+	} {
+		if err := c.Send(ctx, cmd); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+		if _, err := c.Receive(ctx); err != nil {
+			t.Fatalf("failed to receive: %v", err)
+		}
+	}
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("password must not appear in logs:\n%s", buf.String())
+	}
+}
+
+func TestClientMetrics(t *testing.T) {
+	m := &testMetrics{}
+	c, ctx, done := testClientConfig(t, &heos.Config{Metrics: m}, func(_ string) interface{} {
+		return nil
+	})
+	defer done()
+
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+
+	// Two queries for the handshake, and one for the explicit heartbeat.
+	want := []string{
+		"system/heart_beat",
+		"system/prettify_json_response",
+		"system/heart_beat",
+	}
+	if diff := cmp.Diff(want, m.commands); diff != "" {
+		t.Fatalf("unexpected observed commands (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &heos.Config{
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelInfo,
+		})),
+		LogLevel: slog.LevelInfo,
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(_ string) interface{} {
+		return json.RawMessage(`{"heos": {"command": "system/heart_beat", "result": "fail", "message": "eid=13&text=Processing previous command"}}`)
+	})
+	defer done()
+
+	if err := c.System.Heartbeat(ctx); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	// Expect the handshake's command and response and the failed command to
+	// appear in the log.
+	for _, s := range []string{
+		`msg="sending command" query=heos://system/heart_beat`,
+		`msg="received response" command=system/heart_beat result=success`,
+		`msg="command failed" command=system/heart_beat`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("log output does not contain %q:\n%s", s, buf.String())
+		}
+	}
+}
+
+func BenchmarkClientQuery(b *testing.B) {
+	res := response("player/get_volume", "pid=1&level=20", nil)
+	c, ctx, done := testClient(b, func(_ string) interface{} {
+		return res
+	})
+	defer done()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Players.GetVolume(ctx, 1); err != nil {
+			b.Fatalf("failed to get volume: %v", err)
+		}
+	}
+}
+
+var _ heos.Metrics = &testMetrics{}
+
+// testMetrics is a heos.Metrics implementation which records commands.
+type testMetrics struct {
+	mu         sync.Mutex
+	commands   []string
+	events     []string
+	reconnects []error
+}
+
+func (m *testMetrics) ObserveQuery(command string, _ time.Duration, _ error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commands = append(m.commands, command)
+}
+
+func (m *testMetrics) ObserveEvent(command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, command)
+}
+
+func (m *testMetrics) ObserveReconnect(_ int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnects = append(m.reconnects, err)
+}
+
 // testClient creates an ephemeral test client and server. The server will
 // invoke fn for each client request after the initial heartbeat handshake.
 //
 // Invoke the cleanup closure to close all connections.
-func testClient(t *testing.T, fn func(req string) interface{}) (*heos.Client, context.Context, func()) {
+func testClient(t testing.TB, fn func(req string) interface{}) (*heos.Client, context.Context, func()) {
+	t.Helper()
+	return testClientConfig(t, nil, fn)
+}
+
+// testClientConfig is like testClient, but also accepts a Config for the
+// Client.
+func testClientConfig(t testing.TB, cfg *heos.Config, fn func(req string) interface{}) (*heos.Client, context.Context, func()) {
 	t.Helper()
 
 	l, err := net.Listen("tcp", ":0")
@@ -83,29 +844,41 @@ func testClient(t *testing.T, fn func(req string) interface{}) (*heos.Client, co
 		defer c.Close()
 
 		enc := json.NewEncoder(c)
-		b := make([]byte, 128)
+		r := bufio.NewReader(c)
 		for i := 0; ; i++ {
-			n, err := c.Read(b)
+			// Requests are always terminated by "\r\n".
+			req, err := r.ReadString('\n')
 			if err != nil {
 				// On EOF, terminate this goroutine because the client is
-				// closing its connection.
-				if err == io.EOF {
+				// closing its connection. The connection is reset instead if
+				// the client closes it with a response left unread.
+				if err == io.EOF || errors.Is(err, syscall.ECONNRESET) {
 					return
 				}
 
 				panicf("failed to read request: %v", err)
 			}
 
-			// For the first request, always return a canned heartbeat response.
+			// For the handshake requests, always return a canned response.
 			// Otherwise, invoke the function to return a response.
-			if i == 0 {
-				// Canned response captured from receiver.
-				if _, err := io.WriteString(c, `{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}`); err != nil {
-					panicf("failed to write heartbeat response: %v", err)
+			if res, ok := handshake[req]; ok && i < len(handshake) {
+				if _, err := io.WriteString(c, res); err != nil {
+					panicf("failed to write handshake response: %v", err)
 				}
 			} else {
-				if err := enc.Encode(fn(string(b[:n]))); err != nil {
-					panicf("failed to encode JSON response: %v", err)
+				// Multiple frames may be sent in response to a single
+				// request, such as a command acknowledgement followed by
+				// events.
+				v := fn(req)
+				fs, ok := v.(frames)
+				if !ok {
+					fs = frames{v}
+				}
+
+				for _, f := range fs {
+					if err := enc.Encode(f); err != nil {
+						panicf("failed to encode JSON response: %v", err)
+					}
 				}
 			}
 		}
@@ -114,7 +887,7 @@ func testClient(t *testing.T, fn func(req string) interface{}) (*heos.Client, co
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	// Point the Client at our ephemeral server.
-	c, err := heos.Dial(ctx, l.Addr().String())
+	c, err := heos.Dial(ctx, l.Addr().String(), cfg)
 	if err != nil {
 		t.Fatalf("failed to dial: %v", err)
 	}
@@ -125,7 +898,8 @@ func testClient(t *testing.T, fn func(req string) interface{}) (*heos.Client, co
 			wg.Wait()
 		}()
 
-		if err := c.Close(); err != nil {
+		// The Client may have already been closed by a test.
+		if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 			t.Fatalf("failed to close client: %v", err)
 		}
 
@@ -135,6 +909,68 @@ func testClient(t *testing.T, fn func(req string) interface{}) (*heos.Client, co
 	}
 }
 
+// frames is a sequence of frames sent by the test server in response to a
+// single request.
+type frames []interface{}
+
+// event creates an event frame for command with the specified message.
+func event(command, message string) interface{} {
+	type heos struct {
+		Command string `json:"command"`
+		Message string `json:"message"`
+	}
+
+	return struct {
+		HEOS heos `json:"heos"`
+	}{
+		HEOS: heos{
+			Command: command,
+			Message: message,
+		},
+	}
+}
+
+// response creates a successful device response for command with the
+// specified message and optional payload.
+func response(command, message string, payload interface{}) interface{} {
+	return struct {
+		HEOS    heos.CommandHeader `json:"heos"`
+		Payload interface{}        `json:"payload,omitempty"`
+	}{
+		HEOS: heos.CommandHeader{
+			Command: command,
+			Result:  heos.ResultSuccess,
+			Message: message,
+		},
+		Payload: payload,
+	}
+}
+
+// ack creates a successful device response with no message or payload for
+// the command in req.
+func ack(req string) interface{} {
+	command := strings.TrimPrefix(strings.TrimSpace(req), "heos://")
+	if i := strings.IndexByte(command, '?'); i != -1 {
+		command = command[:i]
+	}
+
+	return response(command, "", nil)
+}
+
+// handshake contains canned responses captured from a receiver for each of
+// the requests made by heos.Dial.
+var handshake = map[string]string{
+	"heos://system/heart_beat\r\n":                        `{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}`,
+	"heos://system/prettify_json_response?enable=off\r\n": `{"heos": {"command": "system/prettify_json_response", "result": "success", "message": "enable=off"}}`,
+}
+
+// ignoreRaw ignores the raw JSON retained by payload types when comparing
+// decoded payloads.
+var ignoreRaw = cmp.FilterPath(func(p cmp.Path) bool {
+	sf, ok := p.Last().(cmp.StructField)
+	return ok && sf.Name() == "Raw"
+}, cmp.Ignore())
+
 func panicf(format string, a ...interface{}) {
 	panic(fmt.Sprintf(format, a...))
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// commands maps each known HEOS command to its attributes, in the order they
+// are typically specified.
+var commands = map[string][]string{
+	"system/register_for_change_events": {"enable"},
+	"system/check_account":              nil,
+	"system/sign_in":                    {"un", "pw"},
+	"system/sign_out":                   nil,
+	"system/heart_beat":                 nil,
+	"system/reboot":                     nil,
+	"system/prettify_json_response":     {"enable"},
+
+	"player/get_players":           nil,
+	"player/get_player_info":       {"pid"},
+	"player/get_play_state":        {"pid"},
+	"player/set_play_state":        {"pid", "state"},
+	"player/get_now_playing_media": {"pid"},
+	"player/get_volume":            {"pid"},
+	"player/set_volume":            {"pid", "level"},
+	"player/volume_up":             {"pid", "step"},
+	"player/volume_down":           {"pid", "step"},
+	"player/get_mute":              {"pid"},
+	"player/set_mute":              {"pid", "state"},
+	"player/toggle_mute":           {"pid"},
+	"player/get_play_mode":         {"pid"},
+	"player/set_play_mode":         {"pid", "repeat", "shuffle"},
+	"player/get_queue":             {"pid", "range"},
+	"player/play_queue":            {"pid", "qid"},
+	"player/remove_from_queue":     {"pid", "qid"},
+	"player/save_queue":            {"pid", "name"},
+	"player/clear_queue":           {"pid"},
+	"player/move_queue_item":       {"pid", "sqid", "dqid"},
+	"player/play_next":             {"pid"},
+	"player/play_previous":         {"pid"},
+	"player/check_update":          {"pid"},
+	"player/get_quickselects":      {"pid"},
+	"player/set_quickselect":       {"pid", "id"},
+	"player/play_quickselect":      {"pid", "id"},
+
+	"group/get_groups":     nil,
+	"group/get_group_info": {"gid"},
+	"group/set_group":      {"pid"},
+	"group/get_volume":     {"gid"},
+	"group/set_volume":     {"gid", "level"},
+	"group/volume_up":      {"gid", "step"},
+	"group/volume_down":    {"gid", "step"},
+	"group/get_mute":       {"gid"},
+	"group/set_mute":       {"gid", "state"},
+	"group/toggle_mute":    {"gid"},
+
+	"browse/get_music_sources":   nil,
+	"browse/get_source_info":     {"sid"},
+	"browse/browse":              {"sid", "cid", "range"},
+	"browse/get_search_criteria": {"sid"},
+	"browse/search":              {"sid", "search", "scid", "range"},
+	"browse/play_stream":         {"pid", "sid", "cid", "mid", "name"},
+	"browse/play_preset":         {"pid", "preset"},
+	"browse/play_input":          {"pid", "input"},
+	"browse/add_to_queue":        {"pid", "sid", "cid", "aid"},
+	"browse/rename_playlist":     {"sid", "cid", "name"},
+	"browse/delete_playlist":     {"sid", "cid"},
+	"browse/set_service_option":  {"sid", "option"},
+	"browse/retrieve_metadata":   {"sid", "cid"},
+	"browse/multi_search":        {"search", "sid", "scid"},
+}
+
+// complete returns the possible completions of a partial query, which may be
+// a partial command such as "player/get_v" or a command with partial
+// attributes such as "player/set_volume?pid=1&le".
+func complete(prefix string) []string {
+	var out []string
+
+	i := strings.IndexByte(prefix, '?')
+	if i == -1 {
+		// Complete command names, hinting at their attributes.
+		for cmd, attrs := range commands {
+			if !strings.HasPrefix(cmd, prefix) {
+				continue
+			}
+
+			if len(attrs) > 0 {
+				cmd += "?" + strings.Join(attrs, "=&") + "="
+			}
+			out = append(out, cmd)
+		}
+
+		sort.Strings(out)
+		return out
+	}
+
+	attrs, ok := commands[prefix[:i]]
+	if !ok {
+		return nil
+	}
+
+	// Complete the final attribute name, skipping those already specified.
+	query := prefix[i+1:]
+	j := strings.LastIndexByte(query, '&') + 1
+	prev, partial := query[:j], query[j:]
+	if strings.Contains(partial, "=") {
+		// The attribute name is already complete.
+		return nil
+	}
+
+	for _, a := range attrs {
+		if strings.HasPrefix(a, partial) && !strings.Contains("&"+prev, "&"+a+"=") {
+			out = append(out, prefix[:len(prefix)-len(partial)]+a+"=")
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,328 @@
+// Command heos is a command-line client for the Denon HEOS wireless music
+// system protocol.
+//
+// Given one or more queries as arguments, heos sends each query to a device
+// and prints the responses:
+//
+//	$ heos -a 192.168.1.10 player/get_players 'player/get_volume?pid=1'
+//
+// With no arguments, heos starts an interactive session which keeps its
+// connection open and prints change events as they arrive. Type "help" and a
+// partial query to list the matching commands and attributes.
+//
+// The -json flag prints each response as a JSON object on a single line, in
+// the same form sent by the device, so that the output can be processed by
+// other programs such as jq. The -watch flag prints change events until
+// interrupted instead of sending queries; with -json, each event is printed
+// as a JSON object in the form used by package heosws.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/heosws"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	var (
+		addr    = flag.String("a", "", "address of a HEOS device, such as 192.168.1.10")
+		timeout = flag.Duration("timeout", 5*time.Second, "timeout for each query")
+		events  = flag.Bool("events", true, "print change events during an interactive session")
+		asJSON  = flag.Bool("json", false, "print responses and events as JSON")
+		watch   = flag.Bool("watch", false, "print change events until interrupted")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -a address [flags] [query...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *addr == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	host := *addr
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "1255")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cfg := &heos.Config{Timeout: *timeout}
+	p := &printer{
+		w:    &console{w: os.Stdout},
+		json: *asJSON,
+	}
+
+	if *watch {
+		es, err := heos.DialEvents(ctx, host, cfg)
+		if err != nil {
+			log.Fatalf("heos: failed to register for events: %v", err)
+		}
+		defer es.Close()
+
+		if err := p.events(ctx, es.Events()); err != nil {
+			log.Fatalf("heos: %v", err)
+		}
+
+		return
+	}
+
+	c, err := heos.Dial(ctx, host, cfg)
+	if err != nil {
+		log.Fatalf("heos: failed to dial %q: %v", host, err)
+	}
+	defer c.Close()
+
+	if flag.NArg() > 0 {
+		for _, q := range flag.Args() {
+			if err := query(ctx, c, p, q); err != nil {
+				log.Fatalf("heos: %v", err)
+			}
+		}
+
+		return
+	}
+
+	if *events {
+		es, err := heos.DialEvents(ctx, host, cfg)
+		if err != nil {
+			log.Fatalf("heos: failed to register for events: %v", err)
+		}
+		defer es.Close()
+
+		// Redisplay the prompt after each event in an interactive session.
+		p.prompt = true
+		go func() { _ = p.events(ctx, es.Events()) }()
+	}
+
+	if err := repl(ctx, c, os.Stdin, p); err != nil {
+		log.Fatalf("heos: %v", err)
+	}
+}
+
+// A console is an io.Writer which serializes output from queries and events.
+type console struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write implements io.Writer.
+func (c *console) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.w.Write(b)
+}
+
+// prompt is printed before each line of input in an interactive session.
+const prompt = "heos> "
+
+// repl reads queries from r and prints the responses to out until r is
+// exhausted, ctx is canceled, or the user quits.
+func repl(ctx context.Context, c *heos.Client, r io.Reader, p *printer) error {
+	out := p.w
+
+	var (
+		lines = make(chan string)
+		errC  = make(chan error, 1)
+		done  = make(chan struct{})
+	)
+	defer close(done)
+
+	go func() {
+		defer close(lines)
+
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			select {
+			case lines <- s.Text():
+			case <-done:
+				return
+			}
+		}
+		errC <- s.Err()
+	}()
+
+	for {
+		fmt.Fprint(out, prompt)
+
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Fprintln(out)
+				select {
+				case err := <-errC:
+					return err
+				default:
+					return nil
+				}
+			}
+			line = l
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "quit" || fields[0] == "exit":
+			return nil
+		case fields[0] == "help":
+			var prefix string
+			if len(fields) > 1 {
+				prefix = fields[1]
+			}
+			printCompletions(out, prefix)
+			continue
+		}
+
+		// Attribute values may contain spaces, so send the entire line.
+		if err := query(ctx, c, p, strings.TrimSpace(line)); err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+// printCompletions prints the possible completions of prefix to out.
+func printCompletions(out io.Writer, prefix string) {
+	cs := complete(prefix)
+	if len(cs) == 0 {
+		fmt.Fprintf(out, "no commands match %q\n", prefix)
+		return
+	}
+
+	fmt.Fprintln(out, strings.Join(cs, "\n"))
+}
+
+// query sends q to a device and prints its response.
+func query(ctx context.Context, c *heos.Client, p *printer, q string) error {
+	q = strings.TrimPrefix(q, "heos://")
+
+	var payload json.RawMessage
+	cmd, err := c.Query(ctx, q, &payload)
+	if err != nil {
+		return err
+	}
+
+	return p.response(cmd, payload)
+}
+
+// A printer prints responses and events in human-readable or JSON form.
+type printer struct {
+	w    io.Writer
+	json bool
+
+	// prompt, if true, redisplays the interactive prompt after each event.
+	prompt bool
+}
+
+// response prints a response and its payload.
+func (p *printer) response(cmd *heos.Command, payload json.RawMessage) error {
+	if string(payload) == "null" {
+		payload = nil
+	}
+
+	var buf bytes.Buffer
+	if p.json {
+		err := encode(&buf, struct {
+			HEOS    heos.CommandHeader `json:"heos"`
+			Payload json.RawMessage    `json:"payload,omitempty"`
+		}{
+			HEOS:    cmd.HEOS,
+			Payload: payload,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(&buf, "%s: %s\n", cmd.HEOS.Command, cmd.HEOS.Message)
+		if len(payload) > 0 {
+			if err := json.Indent(&buf, payload, "", "\t"); err != nil {
+				return err
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+// events prints events until ctx is canceled or events is closed.
+func (p *printer) events(ctx context.Context, events <-chan heos.Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := p.event(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// event prints a single event.
+func (p *printer) event(e heos.Event) error {
+	v := reflect.ValueOf(e).Elem()
+
+	var buf bytes.Buffer
+	if p.prompt {
+		buf.WriteByte('\n')
+	}
+
+	if p.json {
+		err := encode(&buf, heosws.Message{
+			Type:  v.Type().Name(),
+			Event: e,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(&buf, "event: %s %+v\n", v.Type().Name(), v.Interface())
+	}
+
+	if p.prompt {
+		buf.WriteString(prompt)
+	}
+
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+// encode writes v to w as a single line of JSON. HTML characters such as '&',
+// which are common in HEOS messages, are not escaped.
+func encode(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name, prefix string
+		want         []string
+	}{
+		{
+			name:   "command",
+			prefix: "player/get_v",
+			want:   []string{"player/get_volume?pid="},
+		},
+		{
+			name:   "commands",
+			prefix: "group/set_",
+			want: []string{
+				"group/set_group?pid=",
+				"group/set_mute?gid=&state=",
+				"group/set_volume?gid=&level=",
+			},
+		},
+		{
+			name:   "attribute",
+			prefix: "player/set_volume?pid=1&le",
+			want:   []string{"player/set_volume?pid=1&level="},
+		},
+		{
+			name:   "remaining attributes",
+			prefix: "player/move_queue_item?sqid=1&",
+			want: []string{
+				"player/move_queue_item?sqid=1&pid=",
+				"player/move_queue_item?sqid=1&dqid=",
+			},
+		},
+		{
+			name:   "value",
+			prefix: "player/set_volume?pid=1",
+		},
+		{
+			name:   "unknown",
+			prefix: "foo/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, complete(tt.prefix)); diff != "" {
+				t.Fatalf("unexpected completions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestREPL(t *testing.T) {
+	c := testClient(t, map[string]string{
+		"heos://player/get_volume?pid=1\r\n":                   `{"heos": {"command": "player/get_volume", "result": "success", "message": "pid=1&level=20"}}`,
+		"heos://player/get_players\r\n":                        `{"heos": {"command": "player/get_players", "result": "success", "message": ""}, "payload": [{"name": "Kitchen", "pid": 1}]}`,
+		"heos://player/get_mute?pid=2\r\n":                     `{"heos": {"command": "player/get_mute", "result": "fail", "message": "eid=2&text=ID Not Valid"}}`,
+		"heos://browse/search?sid=1&search=Jazz FM&scid=1\r\n": `{"heos": {"command": "browse/search", "result": "success", "message": "sid=1&search=Jazz FM&scid=1&returned=0&count=0"}}`,
+	})
+
+	in := strings.Join([]string{
+		"player/get_volume?pid=1",
+		"",
+		"heos://player/get_players",
+		"player/get_mute?pid=2",
+		" browse/search?sid=1&search=Jazz FM&scid=1 ",
+		"help player/get_v",
+		"help system/sign",
+		"quit",
+		"player/get_volume?pid=1",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := repl(context.Background(), c, strings.NewReader(in), &printer{w: &out}); err != nil {
+		t.Fatalf("failed to run REPL: %v", err)
+	}
+
+	want := strings.Join([]string{
+		prompt + "player/get_volume: pid=1&level=20",
+		prompt + prompt + "player/get_players: ",
+		"[",
+		"\t{",
+		`		"name": "Kitchen",`,
+		`		"pid": 1`,
+		"\t}",
+		"]",
+		prompt + "error: heos: player/get_mute: error 2: ID Not Valid",
+		prompt + "browse/search: sid=1&search=Jazz FM&scid=1&returned=0&count=0",
+		prompt + "player/get_volume?pid=",
+		prompt + "system/sign_in?un=&pw=",
+		"system/sign_out",
+		prompt,
+	}, "\n")
+
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Fatalf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrinterJSON(t *testing.T) {
+	c := testClient(t, map[string]string{
+		"heos://player/get_players\r\n":      `{"heos": {"command": "player/get_players", "result": "success", "message": ""}, "payload": [{"name": "Kitchen", "pid": 1}]}`,
+		"heos://player/get_volume?pid=1\r\n": `{"heos": {"command": "player/get_volume", "result": "success", "message": "pid=1&level=20"}}`,
+	})
+
+	var out bytes.Buffer
+	p := &printer{w: &out, json: true}
+
+	for _, q := range []string{"player/get_players", "player/get_volume?pid=1"} {
+		if err := query(context.Background(), c, p, q); err != nil {
+			t.Fatalf("failed to query %q: %v", q, err)
+		}
+	}
+
+	if err := p.event(&heos.PlayerVolumeChanged{PID: 1, Level: 20}); err != nil {
+		t.Fatalf("failed to print event: %v", err)
+	}
+
+	want := strings.Join([]string{
+		`{"heos":{"command":"player/get_players","result":"success","message":""},"payload":[{"name":"Kitchen","pid":1}]}`,
+		`{"heos":{"command":"player/get_volume","result":"success","message":"pid=1&level=20"}}`,
+		`{"type":"PlayerVolumeChanged","event":{"PID":1,"Level":20,"Mute":false}}`,
+		"",
+	}, "\n")
+
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Fatalf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+// testClient creates a heos.Client connected to an in-memory device which
+// answers requests using the canned responses in res.
+func testClient(t *testing.T, res map[string]string) *heos.Client {
+	t.Helper()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		r := bufio.NewReader(server)
+		for {
+			req, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			var out string
+			switch req {
+			case "heos://system/heart_beat\r\n":
+				out = `{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}`
+			case "heos://system/prettify_json_response?enable=off\r\n":
+				out = `{"heos": {"command": "system/prettify_json_response", "result": "success", "message": "enable=off"}}`
+			default:
+				var ok bool
+				if out, ok = res[req]; !ok {
+					panic("unexpected device request: " + req)
+				}
+			}
+
+			if _, err := io.WriteString(server, out+"\r\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := heos.New(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
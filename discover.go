@@ -0,0 +1,266 @@
+package heos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/heos/netctx"
+)
+
+// DeviceType is the SSDP search target which HEOS devices respond to.
+const DeviceType = "urn:schemas-denon-com:device:ACT-Denon:1"
+
+const (
+	// ssdpAddr is the SSDP multicast group and port.
+	ssdpAddr = "239.255.255.250:1900"
+
+	// cliPort is the TCP port of the HEOS CLI on every device.
+	cliPort = 1255
+)
+
+// DiscoverConfig contains options for Discover and DiscoverAndDial. The zero
+// value or a nil DiscoverConfig are valid and enable the default behaviors.
+type DiscoverConfig struct {
+	// Address is the UDP address to which the SSDP search request is sent.
+	// If empty, the SSDP multicast address 239.255.255.250:1900 is used.
+	Address string
+
+	// Timeout is how long to wait for devices to respond. If zero, devices
+	// are given 2 seconds to respond.
+	Timeout time.Duration
+
+	// Connections is the number of connections DiscoverAndDial dials to each
+	// device. If zero, a single connection is dialed.
+	Connections int
+}
+
+// Discover searches the local network for HEOS devices using SSDP and
+// returns the addresses of their CLI ports, sorted and suitable for use
+// with Dial. Discover waits for responses until the configured timeout
+// elapses, and returns the context's error if the context is canceled
+// first. If cfg is nil, a default configuration is used.
+func Discover(ctx context.Context, cfg *DiscoverConfig) ([]string, error) {
+	if cfg == nil {
+		cfg = &DiscoverConfig{}
+	}
+
+	addr := cfg.Address
+	if addr == "" {
+		addr = ssdpAddr
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	dst, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return nil, fmt.Errorf("heos: invalid discovery address: %v", err)
+	}
+
+	pc, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer pc.Close()
+
+	req := []byte("M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: " + strconv.Itoa(int((timeout+time.Second-1)/time.Second)) + "\r\n" +
+		"ST: " + DeviceType + "\r\n\r\n")
+
+	dctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	seen := make(map[string]bool)
+	err = netctx.Do(dctx, pc, func() error {
+		if _, err := pc.WriteTo(req, dst); err != nil {
+			return err
+		}
+
+		b := make([]byte, 2048)
+		for {
+			n, from, err := pc.ReadFrom(b)
+			if err != nil {
+				return err
+			}
+
+			ua, ok := from.(*net.UDPAddr)
+			if !ok || !isDevice(b[:n]) {
+				// Ignore responses from other devices and services.
+				continue
+			}
+
+			seen[net.JoinHostPort(ua.IP.String(), strconv.Itoa(cliPort))] = true
+		}
+	})
+	switch {
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case err != nil && !errors.Is(err, context.DeadlineExceeded):
+		return nil, err
+	}
+
+	addrs := make([]string, 0, len(seen))
+	for a := range seen {
+		addrs = append(addrs, a)
+	}
+	sort.Strings(addrs)
+
+	return addrs, nil
+}
+
+// isDevice reports whether b is an SSDP search response from a HEOS device.
+func isDevice(b []byte) bool {
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil {
+		return false
+	}
+	_ = res.Body.Close()
+
+	return res.StatusCode == http.StatusOK && res.Header.Get("ST") == DeviceType
+}
+
+// A Controller controls a set of HEOS devices, as returned by
+// DiscoverAndDial.
+type Controller struct {
+	devices []*Device
+}
+
+// A Device is a HEOS device connected by DiscoverAndDial.
+type Device struct {
+	// Addr is the address of the device's CLI port.
+	Addr string
+
+	// Pool distributes commands across the connections to the device.
+	Pool *Pool
+}
+
+// A DialError reports a device which was discovered by DiscoverAndDial but
+// could not be dialed. Use errors.As to inspect each DialError.
+type DialError struct {
+	Addr string
+	Err  error
+}
+
+// Error implements error.
+func (e *DialError) Error() string {
+	return fmt.Sprintf("heos: failed to dial %s: %v", e.Addr, e.Err)
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *DialError) Unwrap() error { return e.Err }
+
+// DiscoverAndDial discovers HEOS devices using Discover, then concurrently
+// dials and performs the handshake with each device using DialPool and cfg.
+//
+// If some of the devices cannot be dialed, DiscoverAndDial returns a
+// Controller for the remaining devices along with an error which joins a
+// *DialError for each failure. If no devices are found or none can be
+// dialed, DiscoverAndDial returns only an error. If dcfg or cfg are nil,
+// default configurations are used.
+func DiscoverAndDial(ctx context.Context, dcfg *DiscoverConfig, cfg *Config) (*Controller, error) {
+	if dcfg == nil {
+		dcfg = &DiscoverConfig{}
+	}
+
+	addrs, err := Discover(ctx, dcfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("heos: no devices discovered")
+	}
+
+	size := dcfg.Connections
+	if size == 0 {
+		size = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		pools = make([]*Pool, len(addrs))
+		errs  = make([]error, len(addrs))
+	)
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+
+			p, err := DialPool(ctx, addr, size, cfg)
+			if err != nil {
+				errs[i] = &DialError{Addr: addr, Err: err}
+				return
+			}
+			pools[i] = p
+		}(i, addr)
+	}
+	wg.Wait()
+
+	ctl := &Controller{}
+	for i, p := range pools {
+		if p != nil {
+			ctl.devices = append(ctl.devices, &Device{Addr: addrs[i], Pool: p})
+		}
+	}
+
+	err = errors.Join(errs...)
+	if len(ctl.devices) == 0 {
+		return nil, err
+	}
+
+	return ctl, err
+}
+
+// Devices returns the Controller's devices, sorted by address.
+func (c *Controller) Devices() []*Device { return c.devices }
+
+// Device returns the device with address addr, or nil if the Controller has
+// no such device.
+func (c *Controller) Device(addr string) *Device {
+	for _, d := range c.devices {
+		if d.Addr == addr {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// Close closes the connections to all of the Controller's devices.
+func (c *Controller) Close() error {
+	errs := make([]error, 0, len(c.devices))
+	for _, d := range c.devices {
+		errs = append(errs, d.Pool.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Shutdown gracefully closes the connections to all of the Controller's
+// devices, as with Pool.Shutdown.
+func (c *Controller) Shutdown(ctx context.Context) error {
+	errs := make([]error, len(c.devices))
+
+	var wg sync.WaitGroup
+	for i, d := range c.devices {
+		wg.Add(1)
+		go func(i int, d *Device) {
+			defer wg.Done()
+			errs[i] = d.Pool.Shutdown(ctx)
+		}(i, d)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,115 @@
+package heos_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/heostest"
+)
+
+func TestDiscoverAndDial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Two HEOS devices and an unrelated UPnP device answer each search.
+	responders := map[string]string{
+		"127.0.0.1": heos.DeviceType,
+		"127.0.0.2": heos.DeviceType,
+		"127.0.0.3": "urn:schemas-upnp-org:device:MediaRenderer:1",
+	}
+
+	var search net.PacketConn
+	conns := make(map[string]net.PacketConn)
+	for ip := range responders {
+		pc, err := net.ListenPacket("udp4", ip+":0")
+		if err != nil {
+			t.Skipf("skipping, failed to listen on %s: %v", ip, err)
+		}
+		defer pc.Close()
+
+		conns[ip] = pc
+		if ip == "127.0.0.1" {
+			search = pc
+		}
+	}
+
+	go func() {
+		b := make([]byte, 1024)
+		n, from, err := search.ReadFrom(b)
+		if err != nil {
+			panicf("failed to read search: %v", err)
+		}
+		if !strings.Contains(string(b[:n]), "ST: "+heos.DeviceType+"\r\n") {
+			panicf("unexpected search request: %q", b[:n])
+		}
+
+		for ip, st := range responders {
+			res := "HTTP/1.1 200 OK\r\nST: " + st + "\r\nUSN: uuid:" + ip + "\r\n\r\n"
+			if _, err := conns[ip].WriteTo([]byte(res), from); err != nil {
+				panicf("failed to write response: %v", err)
+			}
+		}
+	}()
+
+	s, err := heostest.NewServer(heostest.HEOS1)
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	// The first device is emulated and the second is unreachable.
+	errRefused := errors.New("connection refused")
+	d := dialerFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr != "127.0.0.1:1255" {
+			return nil, errRefused
+		}
+
+		var nd net.Dialer
+		return nd.DialContext(ctx, network, s.Addr())
+	})
+
+	dcfg := &heos.DiscoverConfig{
+		Address: search.LocalAddr().String(),
+		Timeout: 250 * time.Millisecond,
+	}
+
+	ctl, err := heos.DiscoverAndDial(ctx, dcfg, &heos.Config{Dialer: d})
+	if ctl == nil {
+		t.Fatalf("failed to discover and dial: %v", err)
+	}
+	defer ctl.Close()
+
+	var derr *heos.DialError
+	if !errors.As(err, &derr) || derr.Addr != "127.0.0.2:1255" || !errors.Is(err, errRefused) {
+		t.Fatalf("expected dial error for second device, but got: %v", err)
+	}
+
+	var addrs []string
+	for _, d := range ctl.Devices() {
+		addrs = append(addrs, d.Addr)
+	}
+	if diff := cmp.Diff([]string{"127.0.0.1:1255"}, addrs); diff != "" {
+		t.Fatalf("unexpected devices (-want +got):\n%s", diff)
+	}
+
+	// The connected device is ready for use.
+	err = ctl.Device("127.0.0.1:1255").Pool.Do(ctx, func(c *heos.Client) error {
+		return c.System.Heartbeat(ctx)
+	})
+	if err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+}
+
+// A dialerFunc is a function which implements heos.ContextDialer.
+type dialerFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (fn dialerFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return fn(ctx, network, addr)
+}
@@ -0,0 +1,156 @@
+package heos
+
+import "context"
+
+// An EventHandler handles change events dispatched by Dispatch.
+type EventHandler interface {
+	HandleEvent(e Event)
+}
+
+// EventHandlerFunc adapts a function to an EventHandler.
+type EventHandlerFunc func(e Event)
+
+// HandleEvent implements EventHandler.
+func (fn EventHandlerFunc) HandleEvent(e Event) { fn(e) }
+
+// Dispatch invokes h for each event received from events, which typically
+// come from an EventStream, for applications which prefer callbacks to
+// receiving from a channel. Events are handled one at a time in the order
+// they are received. Dispatch consumes all events from events and blocks
+// until the context is canceled or events is closed.
+func Dispatch(ctx context.Context, events <-chan Event, h EventHandler) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			h.HandleEvent(e)
+		}
+	}
+}
+
+// An EventMux is an EventHandler which invokes callbacks registered for
+// specific event types. Events with no registered callback are ignored. The
+// zero value is ready to use. Callbacks must be registered before the EventMux
+// is used to handle events.
+type EventMux struct {
+	sourcesChanged           func(e *SourcesChanged)
+	playersChanged           func(e *PlayersChanged)
+	groupsChanged            func(e *GroupsChanged)
+	playerStateChanged       func(e *PlayerStateChanged)
+	playerNowPlayingChanged  func(e *PlayerNowPlayingChanged)
+	playerNowPlayingProgress func(e *PlayerNowPlayingProgress)
+	playerPlaybackError      func(e *PlayerPlaybackError)
+	playerQueueChanged       func(e *PlayerQueueChanged)
+	playerVolumeChanged      func(e *PlayerVolumeChanged)
+	groupVolumeChanged       func(e *GroupVolumeChanged)
+	userChanged              func(e *UserChanged)
+	autoSignIn               func(e *AutoSignIn)
+	reconnected              func(e *Reconnected)
+	unknownEvent             func(e *UnknownEvent)
+}
+
+var _ EventHandler = &EventMux{}
+
+// OnSourcesChanged registers fn to handle SourcesChanged events.
+func (m *EventMux) OnSourcesChanged(fn func(e *SourcesChanged)) { m.sourcesChanged = fn }
+
+// OnPlayersChanged registers fn to handle PlayersChanged events.
+func (m *EventMux) OnPlayersChanged(fn func(e *PlayersChanged)) { m.playersChanged = fn }
+
+// OnGroupsChanged registers fn to handle GroupsChanged events.
+func (m *EventMux) OnGroupsChanged(fn func(e *GroupsChanged)) { m.groupsChanged = fn }
+
+// OnPlayerStateChanged registers fn to handle PlayerStateChanged events.
+func (m *EventMux) OnPlayerStateChanged(fn func(e *PlayerStateChanged)) {
+	m.playerStateChanged = fn
+}
+
+// OnPlayerNowPlayingChanged registers fn to handle PlayerNowPlayingChanged
+// events.
+func (m *EventMux) OnPlayerNowPlayingChanged(fn func(e *PlayerNowPlayingChanged)) {
+	m.playerNowPlayingChanged = fn
+}
+
+// OnPlayerNowPlayingProgress registers fn to handle PlayerNowPlayingProgress
+// events.
+func (m *EventMux) OnPlayerNowPlayingProgress(fn func(e *PlayerNowPlayingProgress)) {
+	m.playerNowPlayingProgress = fn
+}
+
+// OnPlayerPlaybackError registers fn to handle PlayerPlaybackError events.
+func (m *EventMux) OnPlayerPlaybackError(fn func(e *PlayerPlaybackError)) {
+	m.playerPlaybackError = fn
+}
+
+// OnPlayerQueueChanged registers fn to handle PlayerQueueChanged events.
+func (m *EventMux) OnPlayerQueueChanged(fn func(e *PlayerQueueChanged)) {
+	m.playerQueueChanged = fn
+}
+
+// OnPlayerVolumeChanged registers fn to handle PlayerVolumeChanged events.
+func (m *EventMux) OnPlayerVolumeChanged(fn func(e *PlayerVolumeChanged)) {
+	m.playerVolumeChanged = fn
+}
+
+// OnGroupVolumeChanged registers fn to handle GroupVolumeChanged events.
+func (m *EventMux) OnGroupVolumeChanged(fn func(e *GroupVolumeChanged)) {
+	m.groupVolumeChanged = fn
+}
+
+// OnUserChanged registers fn to handle UserChanged events.
+func (m *EventMux) OnUserChanged(fn func(e *UserChanged)) { m.userChanged = fn }
+
+// OnAutoSignIn registers fn to handle AutoSignIn events.
+func (m *EventMux) OnAutoSignIn(fn func(e *AutoSignIn)) { m.autoSignIn = fn }
+
+// OnReconnected registers fn to handle Reconnected events.
+func (m *EventMux) OnReconnected(fn func(e *Reconnected)) { m.reconnected = fn }
+
+// OnUnknownEvent registers fn to handle UnknownEvent events.
+func (m *EventMux) OnUnknownEvent(fn func(e *UnknownEvent)) { m.unknownEvent = fn }
+
+// HandleEvent implements EventHandler.
+func (m *EventMux) HandleEvent(e Event) {
+	switch e := e.(type) {
+	case *SourcesChanged:
+		call(m.sourcesChanged, e)
+	case *PlayersChanged:
+		call(m.playersChanged, e)
+	case *GroupsChanged:
+		call(m.groupsChanged, e)
+	case *PlayerStateChanged:
+		call(m.playerStateChanged, e)
+	case *PlayerNowPlayingChanged:
+		call(m.playerNowPlayingChanged, e)
+	case *PlayerNowPlayingProgress:
+		call(m.playerNowPlayingProgress, e)
+	case *PlayerPlaybackError:
+		call(m.playerPlaybackError, e)
+	case *PlayerQueueChanged:
+		call(m.playerQueueChanged, e)
+	case *PlayerVolumeChanged:
+		call(m.playerVolumeChanged, e)
+	case *GroupVolumeChanged:
+		call(m.groupVolumeChanged, e)
+	case *UserChanged:
+		call(m.userChanged, e)
+	case *AutoSignIn:
+		call(m.autoSignIn, e)
+	case *Reconnected:
+		call(m.reconnected, e)
+	case *UnknownEvent:
+		call(m.unknownEvent, e)
+	}
+}
+
+// call invokes fn with e if fn is not nil.
+func call[E Event](fn func(e E), e E) {
+	if fn != nil {
+		fn(e)
+	}
+}
@@ -0,0 +1,45 @@
+package heos_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestDispatchEventMux(t *testing.T) {
+	events := make(chan heos.Event, 4)
+	events <- &heos.PlayerVolumeChanged{PID: 1, Level: 20}
+	events <- &heos.PlayerNowPlayingProgress{PID: 1}
+	events <- &heos.PlayerStateChanged{PID: 1, State: heos.StatePause}
+	events <- &heos.PlayerVolumeChanged{PID: 2, Level: 30}
+	close(events)
+
+	var (
+		volumes []heos.PlayerVolumeChanged
+		states  []string
+		m       heos.EventMux
+	)
+
+	m.OnPlayerVolumeChanged(func(e *heos.PlayerVolumeChanged) {
+		volumes = append(volumes, *e)
+	})
+	m.OnPlayerStateChanged(func(e *heos.PlayerStateChanged) {
+		states = append(states, e.State)
+	})
+
+	heos.Dispatch(context.Background(), events, &m)
+
+	wantVolumes := []heos.PlayerVolumeChanged{
+		{PID: 1, Level: 20},
+		{PID: 2, Level: 30},
+	}
+	if diff := cmp.Diff(wantVolumes, volumes); diff != "" {
+		t.Fatalf("unexpected volume events (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{heos.StatePause}, states); diff != "" {
+		t.Fatalf("unexpected states (-want +got):\n%s", diff)
+	}
+}
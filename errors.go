@@ -0,0 +1,70 @@
+package heos
+
+import "errors"
+
+// HEOS error IDs reported by devices in the "eid" attribute of a failed
+// command.
+const (
+	eidUnrecognizedCommand  = 1
+	eidInvalidID            = 2
+	eidResourceNotAvailable = 5
+	eidInvalidCredentials   = 6
+	eidUserNotLoggedIn      = 8
+	eidOutOfRange           = 9
+	eidProcessingPrevious   = 13
+	eidOptionNotSupported   = 15
+)
+
+// Sentinel errors which match an *Error reported by a device using errors.Is,
+// so that callers can handle common failures without inspecting error IDs:
+//
+//	if errors.Is(err, heos.ErrInvalidID) {
+//		// The player was removed from the network.
+//	}
+//
+// Use errors.As with an *Error to inspect the device's error ID and text.
+var (
+	// ErrUnauthorized matches errors reported when a command requires a
+	// signed in user or when sign in credentials are rejected.
+	ErrUnauthorized = errors.New("heos: unauthorized")
+
+	// ErrInvalidID matches errors reported when a command specifies a
+	// player, group, source, or other ID unknown to the device.
+	ErrInvalidID = errors.New("heos: invalid ID")
+
+	// ErrOutOfRange matches errors reported when a command parameter is
+	// outside of its valid range.
+	ErrOutOfRange = errors.New("heos: parameter out of range")
+
+	// ErrBusy matches errors reported when a device is temporarily unable to
+	// process a command, such as while processing a previous one. Commands
+	// which fail with ErrBusy may succeed when sent again later.
+	ErrBusy = errors.New("heos: system busy")
+
+	// ErrUnsupported matches errors reported when a device does not recognize
+	// a command or does not support one of its options.
+	ErrUnsupported = errors.New("heos: unsupported command")
+)
+
+// ErrMessageTooLarge is returned when a device sends a message which exceeds
+// Config.MaxMessageSize.
+var ErrMessageTooLarge = errors.New("heos: message exceeds maximum size")
+
+// Is implements errors.Is by matching the sentinel errors which correspond
+// to the Error's EID.
+func (e *Error) Is(target error) bool {
+	switch e.EID {
+	case eidInvalidCredentials, eidUserNotLoggedIn:
+		return target == ErrUnauthorized
+	case eidInvalidID:
+		return target == ErrInvalidID
+	case eidOutOfRange:
+		return target == ErrOutOfRange
+	case eidResourceNotAvailable, eidProcessingPrevious:
+		return target == ErrBusy
+	case eidUnrecognizedCommand, eidOptionNotSupported:
+		return target == ErrUnsupported
+	default:
+		return false
+	}
+}
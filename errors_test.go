@@ -0,0 +1,47 @@
+package heos_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mdlayher/heos"
+)
+
+func TestErrorIs(t *testing.T) {
+	sentinels := []error{
+		heos.ErrUnauthorized,
+		heos.ErrInvalidID,
+		heos.ErrOutOfRange,
+		heos.ErrBusy,
+		heos.ErrUnsupported,
+	}
+
+	tests := []struct {
+		eid  int
+		want error
+	}{
+		{eid: 1, want: heos.ErrUnsupported},
+		{eid: 2, want: heos.ErrInvalidID},
+		{eid: 4},
+		{eid: 5, want: heos.ErrBusy},
+		{eid: 6, want: heos.ErrUnauthorized},
+		{eid: 8, want: heos.ErrUnauthorized},
+		{eid: 9, want: heos.ErrOutOfRange},
+		{eid: 13, want: heos.ErrBusy},
+		{eid: 15, want: heos.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("eid %d", tt.eid), func(t *testing.T) {
+			// Errors are typically wrapped by callers.
+			err := fmt.Errorf("failed: %w", &heos.Error{Command: "player/get_volume", EID: tt.eid})
+
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Fatalf("unexpected match for %v: %v", s, got)
+				}
+			}
+		})
+	}
+}
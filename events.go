@@ -0,0 +1,480 @@
+package heos
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/heos/wire"
+)
+
+// An Event is an unsolicited change event sent by a HEOS device. Use a type
+// switch to inspect the concrete type of an Event, such as
+// *PlayerStateChanged.
+type Event interface {
+	isEvent()
+}
+
+// Possible player play states.
+const (
+	StatePlay  = "play"
+	StatePause = "pause"
+	StateStop  = "stop"
+)
+
+// SourcesChanged indicates that the available music sources have changed.
+type SourcesChanged struct{}
+
+// PlayersChanged indicates that players have been added or removed.
+type PlayersChanged struct{}
+
+// GroupsChanged indicates that groups have been created, modified, or removed.
+type GroupsChanged struct{}
+
+// PlayerStateChanged indicates that a player's play state has changed.
+type PlayerStateChanged struct {
+	PID PlayerID
+
+	// State is one of StatePlay, StatePause, or StateStop.
+	State string
+}
+
+// PlayerNowPlayingChanged indicates that the media playing on a player has
+// changed. The event carries no information about the new media; use
+// Players.GetNowPlayingMedia or Players.WatchNowPlaying to retrieve it.
+type PlayerNowPlayingChanged struct {
+	PID PlayerID
+}
+
+// PlayerNowPlayingProgress reports the playback progress of the media playing
+// on a player. Devices typically send this event once per second while media
+// is playing.
+type PlayerNowPlayingProgress struct {
+	PID PlayerID
+
+	// Position is the current playback position, and Duration is the total
+	// length of the media. Duration is zero for media with no fixed length,
+	// such as internet radio stations.
+	Position time.Duration
+	Duration time.Duration
+}
+
+// PlayerPlaybackError indicates that a player failed to play media, such as
+// when a stream cannot be downloaded. PlayerPlaybackError also implements
+// error.
+type PlayerPlaybackError struct {
+	PID PlayerID
+
+	// Text is the error text reported by the device.
+	Text string
+}
+
+// Error implements error.
+func (e *PlayerPlaybackError) Error() string {
+	return fmt.Sprintf("heos: playback error on player %d: %s", e.PID, e.Text)
+}
+
+// PlayerQueueChanged indicates that the contents of a player's queue have
+// changed. Use Players.GetQueue or a QueueCache to retrieve the new queue.
+type PlayerQueueChanged struct {
+	PID PlayerID
+}
+
+// PlayerVolumeChanged indicates that a player's volume or mute state has
+// changed.
+type PlayerVolumeChanged struct {
+	PID   PlayerID
+	Level int
+	Mute  bool
+}
+
+// GroupVolumeChanged indicates that a group's volume or mute state has
+// changed.
+type GroupVolumeChanged struct {
+	GID   GroupID
+	Level int
+	Mute  bool
+}
+
+// UserChanged indicates that a user has signed in to or out of a HEOS
+// account on a device. Use System.KeepSignedIn or System.KeepSignedInEvents to
+// sign in again automatically when a user signs out.
+type UserChanged struct {
+	// SignedIn reports whether a user is signed in, and if so, Username is
+	// the user's username.
+	SignedIn bool
+	Username string
+}
+
+// AutoSignIn is delivered by System.KeepSignedInEvents after a user signed out
+// and the Client attempted to sign in again using Config.Credentials.
+type AutoSignIn struct {
+	// Username is the username used to sign in, if the Client's
+	// CredentialProvider returned one.
+	Username string
+
+	// Err is nil if the Client signed in successfully, or the error which
+	// occurred otherwise.
+	Err error `json:"-"`
+}
+
+// An UnknownEvent is an event which is not otherwise recognized by this
+// package.
+type UnknownEvent struct {
+	// Command is the event's command, such as "event/repeat_mode_changed".
+	Command string
+
+	// Message contains the event's raw message attributes.
+	Message string
+}
+
+func (*SourcesChanged) isEvent()           {}
+func (*PlayersChanged) isEvent()           {}
+func (*GroupsChanged) isEvent()            {}
+func (*PlayerStateChanged) isEvent()       {}
+func (*PlayerNowPlayingChanged) isEvent()  {}
+func (*PlayerNowPlayingProgress) isEvent() {}
+func (*PlayerPlaybackError) isEvent()      {}
+func (*PlayerQueueChanged) isEvent()       {}
+func (*PlayerVolumeChanged) isEvent()      {}
+func (*GroupVolumeChanged) isEvent()       {}
+func (*UserChanged) isEvent()              {}
+func (*AutoSignIn) isEvent()               {}
+func (*UnknownEvent) isEvent()             {}
+
+// An EventFilter reports whether an EventStream should deliver an Event.
+type EventFilter func(e Event) bool
+
+// EventTypes returns an EventFilter which accepts only events with the same
+// concrete types as the example events in types. For example, to receive only
+// volume and play state changes:
+//
+//	heos.EventTypes(&heos.PlayerVolumeChanged{}, &heos.PlayerStateChanged{})
+func EventTypes(types ...Event) EventFilter {
+	accept := make(map[reflect.Type]struct{}, len(types))
+	for _, t := range types {
+		accept[reflect.TypeOf(t)] = struct{}{}
+	}
+
+	return func(e Event) bool {
+		_, ok := accept[reflect.TypeOf(e)]
+		return ok
+	}
+}
+
+// parseEvent parses an Event from the header of an event frame.
+func parseEvent(h wire.Header) (Event, error) {
+	attrs := h.Attributes()
+
+	// Accumulate the first error while parsing attributes so each event need
+	// not check each attribute individually.
+	var err error
+	integer := func(key string) int {
+		v, perr := attrs.Int(key)
+		if perr != nil && err == nil {
+			err = perr
+		}
+		return v
+	}
+	millis := func(key string) time.Duration {
+		return time.Duration(integer(key)) * time.Millisecond
+	}
+	onOff := func(key string) bool {
+		v, perr := parseOnOff(attrs[key])
+		if perr != nil && err == nil {
+			err = perr
+		}
+		return v
+	}
+
+	var e Event
+	switch h.Command {
+	case "event/sources_changed":
+		e = &SourcesChanged{}
+	case "event/players_changed":
+		e = &PlayersChanged{}
+	case "event/groups_changed":
+		e = &GroupsChanged{}
+	case "event/player_state_changed":
+		e = &PlayerStateChanged{
+			PID:   PlayerID(integer("pid")),
+			State: attrs["state"],
+		}
+	case "event/player_now_playing_changed":
+		e = &PlayerNowPlayingChanged{PID: PlayerID(integer("pid"))}
+	case "event/player_now_playing_progress":
+		e = &PlayerNowPlayingProgress{
+			PID:      PlayerID(integer("pid")),
+			Position: millis("cur_pos"),
+			Duration: millis("duration"),
+		}
+	case "event/player_playback_error":
+		e = &PlayerPlaybackError{
+			PID:  PlayerID(integer("pid")),
+			Text: attrs["error"],
+		}
+	case "event/player_queue_changed":
+		e = &PlayerQueueChanged{PID: PlayerID(integer("pid"))}
+	case "event/player_volume_changed":
+		e = &PlayerVolumeChanged{
+			PID:   PlayerID(integer("pid")),
+			Level: integer("level"),
+			Mute:  onOff("mute"),
+		}
+	case "event/group_volume_changed":
+		e = &GroupVolumeChanged{
+			GID:   GroupID(integer("gid")),
+			Level: integer("level"),
+			Mute:  onOff("mute"),
+		}
+	case "event/user_changed":
+		// Messages are of the form "signed_in&un=user@example.com" or
+		// "signed_out".
+		_, signedIn := attrs["signed_in"]
+		e = &UserChanged{
+			SignedIn: signedIn,
+			Username: attrs["un"],
+		}
+	default:
+		e = &UnknownEvent{
+			Command: h.Command,
+			Message: h.Message,
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("heos: failed to parse %s: %v", h.Command, err)
+	}
+
+	return e, nil
+}
+
+// An EventStream receives change events from a HEOS device using a dedicated
+// connection.
+type EventStream struct {
+	c       *Client
+	events  chan Event
+	history *history
+	done    chan struct{}
+	err     error
+
+	// redial and policy are set when the EventStream reconnects after its
+	// connection fails.
+	redial func(ctx context.Context) (*Client, error)
+	policy *ReconnectPolicy
+
+	cancel    func()
+	stopRead  func()
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// DialEvents dials a dedicated connection to the device specified by addr and
+// registers it to receive change events. The context is used for cancelation
+// and to set timeouts while dialing. If cfg is nil, a default configuration
+// is used.
+//
+// If cfg.Reconnect is set, the EventStream redials addr when its connection
+// fails rather than stopping. See ReconnectPolicy for details.
+func DialEvents(ctx context.Context, addr string, cfg *Config) (*EventStream, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	dial := func(ctx context.Context) (*Client, error) {
+		c, err := Dial(ctx, addr, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.System.RegisterForChangeEvents(ctx, true); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+
+		return c, nil
+	}
+
+	c, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	es := newEventStream(c)
+	if cfg.Reconnect != nil {
+		es.redial = dial
+		es.policy = cfg.Reconnect
+	}
+	es.start()
+
+	return es, nil
+}
+
+// NewEventStream registers c to receive change events and returns an
+// EventStream which delivers them. c must be dedicated to the EventStream, and
+// is closed when the EventStream is closed. The context is used for
+// cancelation and to set timeouts while registering. An EventStream created
+// by NewEventStream does not reconnect, because it cannot dial a replacement
+// for c; use DialEvents with Config.Reconnect instead.
+func NewEventStream(ctx context.Context, c *Client) (*EventStream, error) {
+	if err := c.System.RegisterForChangeEvents(ctx, true); err != nil {
+		return nil, err
+	}
+
+	es := newEventStream(c)
+	es.start()
+
+	return es, nil
+}
+
+// newEventStream creates an EventStream for c, which must already be
+// registered to receive change events.
+func newEventStream(c *Client) *EventStream {
+	return &EventStream{
+		c:       c,
+		events:  make(chan Event, 16),
+		history: newHistory(c.history),
+		done:    make(chan struct{}),
+	}
+}
+
+// start begins receiving events in the background.
+func (es *EventStream) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	readCtx, stopRead := context.WithCancel(ctx)
+	es.cancel = cancel
+	es.stopRead = stopRead
+
+	es.wg.Add(1)
+	go func() {
+		defer es.wg.Done()
+		es.receive(readCtx, ctx)
+	}()
+}
+
+// Events returns a channel which delivers change events. The channel is
+// closed exactly once, when the EventStream is closed or its connection fails
+// and is not re-established. Use Err to determine why the channel was closed.
+func (es *EventStream) Events() <-chan Event {
+	return es.events
+}
+
+// Run blocks until the context is canceled or the EventStream fails, and is
+// an alternative to waiting for the Events channel to be closed. If the
+// context is canceled, Run closes the EventStream and returns the context's
+// error. Otherwise, Run returns the error which stopped the EventStream, as
+// reported by Err. Events must still be received from the Events channel
+// while Run is blocked.
+func (es *EventStream) Run(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		_ = es.Close()
+		return ctx.Err()
+	case <-es.done:
+		return es.err
+	}
+}
+
+// Err returns the error which caused the EventStream to stop, such as a
+// decoding or connection failure. Err returns nil if the EventStream is still
+// running or was stopped by Close.
+func (es *EventStream) Err() error {
+	select {
+	case <-es.done:
+		return es.err
+	default:
+		return nil
+	}
+}
+
+// Close stops receiving events and closes the EventStream's connection. Close
+// is safe to call more than once; subsequent calls return the result of the
+// first call.
+func (es *EventStream) Close() error {
+	es.closeOnce.Do(func() {
+		es.cancel()
+		es.wg.Wait()
+		es.closeErr = es.c.Close()
+	})
+	return es.closeErr
+}
+
+// receive receives events until readCtx is canceled or the connection fails,
+// and delivers them until ctx is canceled.
+func (es *EventStream) receive(readCtx, ctx context.Context) {
+	defer func() {
+		close(es.events)
+		close(es.done)
+	}()
+
+	for {
+		f, err := es.c.Receive(readCtx)
+		if err != nil {
+			if readCtx.Err() != nil {
+				// The stream was stopped by Close or Shutdown.
+				return
+			}
+
+			es.c.log(ctx, slog.LevelWarn, "event stream failed", slog.Any("error", err))
+			if es.redial == nil {
+				es.err = err
+				return
+			}
+
+			if rerr := es.reconnect(readCtx); rerr != nil {
+				if readCtx.Err() == nil {
+					es.err = rerr
+				}
+				return
+			}
+
+			r := &Reconnected{Err: err}
+			es.history.add(r)
+
+			select {
+			case es.events <- r:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		h := wire.Header{
+			Command: f.Command.HEOS.Command,
+			Message: f.Command.HEOS.Message,
+		}
+		if !strings.HasPrefix(h.Command, "event/") {
+			// Not an event; nothing to do.
+			continue
+		}
+		if es.c.metrics != nil {
+			es.c.metrics.ObserveEvent(h.Command)
+		}
+
+		e, err := parseEvent(h)
+		if err != nil {
+			es.c.log(ctx, slog.LevelWarn, "failed to parse event", slog.Any("error", err))
+			continue
+		}
+
+		es.c.log(ctx, es.c.logLevel.Level(), "received event",
+			slog.String("command", h.Command),
+			slog.String("message", h.Message),
+		)
+
+		if es.c.filter != nil && !es.c.filter(e) {
+			continue
+		}
+		es.history.add(e)
+
+		select {
+		case es.events <- e:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package heos_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestEventStream(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		if diff := cmp.Diff("heos://system/register_for_change_events?enable=on\r\n", req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		return frames{
+			response("system/register_for_change_events", "enable=on", nil),
+			event("event/players_changed", ""),
+			event("event/player_state_changed", "pid=1&state=play"),
+			event("event/player_now_playing_progress", "pid=1&cur_pos=61500&duration=240000"),
+			event("event/player_playback_error", "pid=1&error=Could Not Download"),
+			event("event/player_queue_changed", "pid=1"),
+			event("event/player_volume_changed", "pid=1&level=20&mute=off"),
+			event("event/group_volume_changed", "gid=1&level=30&mute=on"),
+			// Malformed events are skipped.
+			event("event/player_now_playing_changed", "pid=foo"),
+			event("event/player_now_playing_changed", "pid=1"),
+			event("event/user_changed", "signed_in&un=user@example.com"),
+			event("event/user_changed", "signed_out"),
+			event("event/repeat_mode_changed", "pid=1&repeat=on_all"),
+		}
+	})
+	defer done()
+
+	es, err := heos.NewEventStream(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create event stream: %v", err)
+	}
+
+	want := []heos.Event{
+		&heos.PlayersChanged{},
+		&heos.PlayerStateChanged{PID: 1, State: heos.StatePlay},
+		&heos.PlayerNowPlayingProgress{
+			PID:      1,
+			Position: 61*time.Second + 500*time.Millisecond,
+			Duration: 4 * time.Minute,
+		},
+		&heos.PlayerPlaybackError{PID: 1, Text: "Could Not Download"},
+		&heos.PlayerQueueChanged{PID: 1},
+		&heos.PlayerVolumeChanged{PID: 1, Level: 20},
+		&heos.GroupVolumeChanged{GID: 1, Level: 30, Mute: true},
+		&heos.PlayerNowPlayingChanged{PID: 1},
+		&heos.UserChanged{SignedIn: true, Username: "user@example.com"},
+		&heos.UserChanged{},
+		&heos.UnknownEvent{
+			Command: "event/repeat_mode_changed",
+			Message: "pid=1&repeat=on_all",
+		},
+	}
+
+	var got []heos.Event
+	for range want {
+		got = append(got, <-es.Events())
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	if err := es.Close(); err != nil {
+		t.Fatalf("failed to close event stream: %v", err)
+	}
+
+	if _, ok := <-es.Events(); ok {
+		t.Fatal("expected events channel to be closed")
+	}
+}
+
+func TestEventStreamFilter(t *testing.T) {
+	cfg := &heos.Config{
+		EventFilter: heos.EventTypes(&heos.PlayerVolumeChanged{}, &heos.PlayerStateChanged{}),
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		return frames{
+			response("system/register_for_change_events", "enable=on", nil),
+			event("event/player_now_playing_progress", "pid=1&cur_pos=1000&duration=240000"),
+			event("event/player_state_changed", "pid=1&state=play"),
+			event("event/player_now_playing_progress", "pid=1&cur_pos=2000&duration=240000"),
+			event("event/player_volume_changed", "pid=1&level=20&mute=off"),
+		}
+	})
+	defer done()
+
+	es, err := heos.NewEventStream(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create event stream: %v", err)
+	}
+	defer es.Close()
+
+	want := []heos.Event{
+		&heos.PlayerStateChanged{PID: 1, State: heos.StatePlay},
+		&heos.PlayerVolumeChanged{PID: 1, Level: 20},
+	}
+
+	var got []heos.Event
+	for range want {
+		got = append(got, <-es.Events())
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestEventStreamHistory(t *testing.T) {
+	cfg := &heos.Config{
+		EventFilter: func(e heos.Event) bool {
+			_, ok := e.(*heos.PlayerNowPlayingProgress)
+			return !ok
+		},
+		EventHistory: 2,
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		return frames{
+			response("system/register_for_change_events", "enable=on", nil),
+			event("event/players_changed", ""),
+			event("event/player_state_changed", "pid=1&state=play"),
+			event("event/player_now_playing_progress", "pid=1&cur_pos=1000&duration=240000"),
+			event("event/player_volume_changed", "pid=1&level=20&mute=off"),
+		}
+	})
+	defer done()
+
+	es, err := heos.NewEventStream(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create event stream: %v", err)
+	}
+	defer es.Close()
+
+	for i := 0; i < 3; i++ {
+		<-es.Events()
+	}
+
+	// Only the most recent events which passed the filter are retained.
+	want := []heos.Event{
+		&heos.PlayerStateChanged{PID: 1, State: heos.StatePlay},
+		&heos.PlayerVolumeChanged{PID: 1, Level: 20},
+	}
+	if diff := cmp.Diff(want, es.Recent()); diff != "" {
+		t.Fatalf("unexpected recent events (-want +got):\n%s", diff)
+	}
+}
+
+func TestEventStreamRunConnectionFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, server := net.Pipe()
+	go func() {
+		// Answer the handshake and registration, send a single event, and
+		// then hang up.
+		enc := json.NewEncoder(server)
+		b := make([]byte, 128)
+		for i := 0; i < 3; i++ {
+			n, err := server.Read(b)
+			if err != nil {
+				panicf("failed to read request: %v", err)
+			}
+
+			if err := enc.Encode(ack(string(b[:n]))); err != nil {
+				panicf("failed to write response: %v", err)
+			}
+		}
+
+		if err := enc.Encode(event("event/players_changed", "")); err != nil {
+			panicf("failed to write event: %v", err)
+		}
+		_ = server.Close()
+	}()
+
+	c, err := heos.New(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	es, err := heos.NewEventStream(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create event stream: %v", err)
+	}
+
+	var got []heos.Event
+	for e := range es.Events() {
+		got = append(got, e)
+	}
+
+	if diff := cmp.Diff([]heos.Event{&heos.PlayersChanged{}}, got); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	// Depending on timing, the client observes either EOF or a closed pipe.
+	err = es.Run(ctx)
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("expected connection error from Run, but got: %v", err)
+	}
+	if err2 := es.Err(); err2 != err {
+		t.Fatalf("expected Err to return %v, but got: %v", err, err2)
+	}
+
+	// Close is safe to call more than once.
+	_ = es.Close()
+	_ = es.Close()
+}
+
+func TestEventStreamRunContextCanceled(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		return response("system/register_for_change_events", "enable=on", nil)
+	})
+	defer done()
+
+	es, err := heos.NewEventStream(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create event stream: %v", err)
+	}
+
+	rctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := es.Run(rctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, but got: %v", err)
+	}
+
+	if _, ok := <-es.Events(); ok {
+		t.Fatal("expected events channel to be closed")
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("expected no error after Close, but got: %v", err)
+	}
+}
+
+func TestEventStreamMetrics(t *testing.T) {
+	m := &testMetrics{}
+	c, ctx, done := testClientConfig(t, &heos.Config{Metrics: m}, func(req string) interface{} {
+		return frames{
+			response("system/register_for_change_events", "enable=on", nil),
+			event("event/players_changed", ""),
+			event("event/player_state_changed", "pid=1&state=play"),
+		}
+	})
+	defer done()
+
+	es, err := heos.NewEventStream(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create event stream: %v", err)
+	}
+
+	<-es.Events()
+	<-es.Events()
+
+	if err := es.Close(); err != nil {
+		t.Fatalf("failed to close event stream: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	want := []string{"event/players_changed", "event/player_state_changed"}
+	if diff := cmp.Diff(want, m.events); diff != "" {
+		t.Fatalf("unexpected observed events (-want +got):\n%s", diff)
+	}
+}
+
+func TestEventStreamReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	// Each connection answers the handshake and registration and sends a
+	// single event. The first then hangs up, and the second stays open until
+	// the test hangs up its listener.
+	hangup := make(chan struct{})
+	go func() {
+		for i, e := range []string{"event/players_changed", "event/groups_changed"} {
+			c, err := l.Accept()
+			if err != nil {
+				panicf("failed to accept: %v", err)
+			}
+
+			enc := json.NewEncoder(c)
+			r := bufio.NewReader(c)
+			for j := 0; j < 3; j++ {
+				req, err := r.ReadString('\n')
+				if err != nil {
+					panicf("failed to read request: %v", err)
+				}
+				if err := enc.Encode(ack(req)); err != nil {
+					panicf("failed to write response: %v", err)
+				}
+			}
+
+			if err := enc.Encode(event(e, "")); err != nil {
+				panicf("failed to write event: %v", err)
+			}
+
+			if i == 1 {
+				<-hangup
+			}
+			_ = c.Close()
+		}
+	}()
+
+	tr := &testTracer{}
+	m := &testMetrics{}
+	cfg := &heos.Config{
+		Reconnect: &heos.ReconnectPolicy{
+			Attempts: 2,
+			Backoff:  time.Millisecond,
+		},
+		Metrics: m,
+		Tracer:  tr,
+	}
+
+	es, err := heos.DialEvents(ctx, l.Addr().String(), cfg)
+	if err != nil {
+		t.Fatalf("failed to dial events: %v", err)
+	}
+	defer es.Close()
+
+	var types []string
+	for i := 0; i < 3; i++ {
+		e := <-es.Events()
+		if r, ok := e.(*heos.Reconnected); ok && r.Err == nil {
+			t.Fatal("expected Reconnected event to report an error")
+		}
+
+		types = append(types, fmt.Sprintf("%T", e))
+	}
+
+	want := []string{"*heos.PlayersChanged", "*heos.Reconnected", "*heos.GroupsChanged"}
+	if diff := cmp.Diff(want, types); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	// Once the device is unreachable, the EventStream gives up after its
+	// configured attempts.
+	_ = l.Close()
+	close(hangup)
+
+	if _, ok := <-es.Events(); ok {
+		t.Fatal("expected events channel to be closed")
+	}
+	if err := es.Err(); err == nil || !strings.Contains(err.Error(), "failed to reconnect") {
+		t.Fatalf("expected reconnect error, but got: %v", err)
+	}
+
+	// Each reconnect is traced with its number of attempts.
+	var spans []testSpan
+	for _, s := range tr.ended() {
+		if s.Name == "heos reconnect" {
+			spans = append(spans, s)
+		}
+	}
+
+	wantSpans := []testSpan{
+		{Name: "heos reconnect", Attrs: map[string]string{"heos.attempts": "1"}},
+		{Name: "heos reconnect", Attrs: map[string]string{"heos.attempts": "2"}, Failed: true},
+	}
+	if diff := cmp.Diff(wantSpans, spans); diff != "" {
+		t.Fatalf("unexpected reconnect spans (-want +got):\n%s", diff)
+	}
+
+	// Each attempt is observed: one success, then two failures.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var failed []bool
+	for _, err := range m.reconnects {
+		failed = append(failed, err != nil)
+	}
+	if diff := cmp.Diff([]bool{false, true, true}, failed); diff != "" {
+		t.Fatalf("unexpected observed reconnects (-want +got):\n%s", diff)
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/mdlayher/heos
 
-go 1.13
+go 1.21
 
 require github.com/google/go-cmp v0.3.1
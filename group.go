@@ -0,0 +1,320 @@
+package heos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Groups wraps HEOS Group commands.
+type Groups struct {
+	c *Client
+}
+
+// Possible GroupPlayer roles.
+const (
+	RoleLeader = "leader"
+	RoleMember = "member"
+)
+
+// GroupInfo contains information about a HEOS group.
+type GroupInfo struct {
+	Name    string        `json:"name"`
+	GID     GroupID       `json:"gid"`
+	Players []GroupPlayer `json:"players"`
+
+	// Raw is the JSON object from which the GroupInfo was decoded, which may
+	// contain fields not otherwise supported by this package.
+	Raw json.RawMessage `json:"-"`
+}
+
+// A GroupPlayer is a player which belongs to a group.
+type GroupPlayer struct {
+	Name string   `json:"name"`
+	PID  PlayerID `json:"pid"`
+
+	// Role is either RoleLeader or RoleMember.
+	Role string `json:"role"`
+}
+
+// GetGroups returns information about all of the groups known to a device.
+func (g *Groups) GetGroups(ctx context.Context) ([]GroupInfo, error) {
+	var gs []GroupInfo
+	if _, err := g.c.Query(ctx, "group/get_groups", &gs); err != nil {
+		return nil, err
+	}
+
+	return gs, nil
+}
+
+// GetGroupInfo returns information about the group specified by gid.
+func (g *Groups) GetGroupInfo(ctx context.Context, gid GroupID) (*GroupInfo, error) {
+	var gi GroupInfo
+	if _, err := g.c.Query(ctx, fmt.Sprintf("group/get_group_info?gid=%d", gid), &gi); err != nil {
+		return nil, err
+	}
+
+	return &gi, nil
+}
+
+// SetGroup creates or modifies a group with the player specified by leader as
+// the group leader and the players specified by members as group members. If
+// no members are specified, the group led by leader is ungrouped.
+func (g *Groups) SetGroup(ctx context.Context, leader PlayerID, members ...PlayerID) error {
+	pids := make([]string, 0, 1+len(members))
+	pids = append(pids, leader.String())
+	for _, m := range members {
+		pids = append(pids, m.String())
+	}
+
+	_, err := g.c.Query(ctx, "group/set_group?pid="+strings.Join(pids, ","), nil)
+	return err
+}
+
+// GroupAll groups every player known to the device under the player specified
+// by leader, such as for a party mode. If events is not nil, GroupAll waits
+// until the device reports that the leader's group contains every player.
+// The groups are checked once after the group is set, and again after each
+// GroupsChanged event received from events, which typically come from an
+// EventStream. Other events received while waiting are discarded.
+func (g *Groups) GroupAll(ctx context.Context, leader PlayerID, events <-chan Event) error {
+	ps, err := g.c.Players.GetPlayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		found   bool
+		members []PlayerID
+	)
+	for _, p := range ps {
+		if p.PID == leader {
+			found = true
+			continue
+		}
+
+		members = append(members, p.PID)
+	}
+	if !found {
+		return fmt.Errorf("heos: leader player %d not found", leader)
+	}
+
+	if err := g.SetGroup(ctx, leader, members...); err != nil {
+		return err
+	}
+	if events == nil || len(members) == 0 {
+		return nil
+	}
+
+	for {
+		// The device may have applied the group before sending an event, or
+		// may send no event at all if the players were already grouped, so
+		// check the groups before waiting.
+		ok, err := g.grouped(ctx, leader, members)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case e, ok := <-events:
+				if !ok {
+					return errors.New("heos: events closed before players were grouped")
+				}
+
+				if _, ok := e.(*GroupsChanged); ok {
+					break wait
+				}
+			}
+		}
+	}
+}
+
+// grouped reports whether the device has a group led by leader which
+// contains all of members.
+func (g *Groups) grouped(ctx context.Context, leader PlayerID, members []PlayerID) (bool, error) {
+	gs, err := g.GetGroups(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, gi := range gs {
+		in := make(map[PlayerID]bool, len(gi.Players))
+		led := false
+		for _, p := range gi.Players {
+			in[p.PID] = true
+			if p.PID == leader && p.Role == RoleLeader {
+				led = true
+			}
+		}
+		if !led {
+			continue
+		}
+
+		for _, pid := range members {
+			if !in[pid] {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// UngroupAll dissolves every group known to the device, so that each player
+// plays independently.
+func (g *Groups) UngroupAll(ctx context.Context) error {
+	gs, err := g.GetGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, gi := range gs {
+		ps := sortLeader(gi.Players)
+		if len(ps) == 0 || ps[0].Role != RoleLeader {
+			return fmt.Errorf("heos: group %d has no leader", gi.GID)
+		}
+
+		// Setting a group with only a leader dissolves the group.
+		if err := g.SetGroup(ctx, ps[0].PID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMute reports whether the group specified by gid is muted.
+func (g *Groups) GetMute(ctx context.Context, gid GroupID) (bool, error) {
+	cmd, err := g.c.Query(ctx, fmt.Sprintf("group/get_mute?gid=%d", gid), nil)
+	if err != nil {
+		return false, err
+	}
+
+	return parseOnOff(cmd.Attributes()["state"])
+}
+
+// SetMute mutes or unmutes the group specified by gid.
+func (g *Groups) SetMute(ctx context.Context, gid GroupID, mute bool) error {
+	_, err := g.c.Query(ctx, fmt.Sprintf("group/set_mute?gid=%d&state=%s", gid, onOff(mute)), nil)
+	return err
+}
+
+// ToggleMute toggles the mute state of the group specified by gid.
+func (g *Groups) ToggleMute(ctx context.Context, gid GroupID) error {
+	_, err := g.c.Query(ctx, fmt.Sprintf("group/toggle_mute?gid=%d", gid), nil)
+	return err
+}
+
+// GetVolume returns the volume level of the group specified by gid, in the
+// range 0-100.
+func (g *Groups) GetVolume(ctx context.Context, gid GroupID) (int, error) {
+	cmd, err := g.c.Query(ctx, fmt.Sprintf("group/get_volume?gid=%d", gid), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Attributes().Int("level")
+}
+
+// SetVolume sets the volume level of the group specified by gid, in the range
+// 0-100.
+func (g *Groups) SetVolume(ctx context.Context, gid GroupID, level int) error {
+	if err := checkVolume(level); err != nil {
+		return err
+	}
+
+	max, ok, err := g.volumeLimit(ctx, gid)
+	if err != nil {
+		return err
+	}
+	if ok {
+		level, err = g.c.volumeLimit.clamp(fmt.Sprintf("group %d", gid), level, max)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = g.c.Query(ctx, fmt.Sprintf("group/set_volume?gid=%d&level=%d", gid, level), nil)
+	return err
+}
+
+// VolumeUp increases the volume level of the group specified by gid by step,
+// in the range 1-10.
+func (g *Groups) VolumeUp(ctx context.Context, gid GroupID, step int) error {
+	if err := checkStep(step); err != nil {
+		return err
+	}
+
+	max, ok, err := g.volumeLimit(ctx, gid)
+	if err != nil {
+		return err
+	}
+	if ok {
+		level, err := g.c.volumeLimit.step(fmt.Sprintf("group %d", gid), max, step, func() (int, error) {
+			return g.GetVolume(ctx, gid)
+		})
+		if err != nil {
+			return err
+		}
+		if level != -1 {
+			_, err := g.c.Query(ctx, fmt.Sprintf("group/set_volume?gid=%d&level=%d", gid, level), nil)
+			return err
+		}
+	}
+
+	return g.volumeStep(ctx, "volume_up", gid, step)
+}
+
+// volumeLimit returns the Client's volume limit for the group specified by
+// gid, and whether the group has a limit.
+func (g *Groups) volumeLimit(ctx context.Context, gid GroupID) (int, bool, error) {
+	if g.c.volumeLimit == nil {
+		return 0, false, nil
+	}
+
+	gi, err := g.GetGroupInfo(ctx, gid)
+	if err != nil {
+		return 0, false, err
+	}
+
+	max, ok := g.c.volumeLimit.groupLimit(gi)
+	return max, ok, nil
+}
+
+// VolumeDown decreases the volume level of the group specified by gid by
+// step, in the range 1-10.
+func (g *Groups) VolumeDown(ctx context.Context, gid GroupID, step int) error {
+	return g.volumeStep(ctx, "volume_down", gid, step)
+}
+
+// volumeStep issues a volume step command for a group.
+func (g *Groups) volumeStep(ctx context.Context, command string, gid GroupID, step int) error {
+	if err := checkStep(step); err != nil {
+		return err
+	}
+
+	_, err := g.c.Query(ctx, fmt.Sprintf("group/%s?gid=%d&step=%d", command, gid, step), nil)
+	return err
+}
+
+// FadeVolume gradually transitions the volume level of the group specified
+// by gid from its current level to level over the duration d, using a series
+// of volume changes. FadeVolume returns when the target level is reached or
+// the context is canceled.
+func (g *Groups) FadeVolume(ctx context.Context, gid GroupID, level int, d time.Duration) error {
+	return fadeVolume(ctx, level, d,
+		func() (int, error) { return g.GetVolume(ctx, gid) },
+		func(level int) error { return g.SetVolume(ctx, gid, level) },
+	)
+}
@@ -0,0 +1,281 @@
+package heos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestGroupsVolume(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://group/get_volume?gid=1\r\n":
+			return response("group/get_volume", "gid=1&level=20", nil)
+		case "heos://group/set_volume?gid=1&level=0\r\n":
+			return response("group/set_volume", "gid=1&level=0", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	level, err := c.Groups.GetVolume(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+	if diff := cmp.Diff(20, level); diff != "" {
+		t.Fatalf("unexpected volume level (-want +got):\n%s", diff)
+	}
+
+	if err := c.Groups.SetVolume(ctx, 1, 0); err != nil {
+		t.Fatalf("failed to set volume: %v", err)
+	}
+}
+
+func TestGroupsGetGroups(t *testing.T) {
+	want := []heos.GroupInfo{{
+		Name: "Living Room + Kitchen",
+		GID:  1,
+		Players: []heos.GroupPlayer{
+			{Name: "Living Room", PID: 1, Role: heos.RoleLeader},
+			{Name: "Kitchen", PID: 2, Role: heos.RoleMember},
+		},
+	}}
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		if diff := cmp.Diff("heos://group/get_groups\r\n", req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		return response("group/get_groups", "", want)
+	})
+	defer done()
+
+	got, err := c.Groups.GetGroups(ctx)
+	if err != nil {
+		t.Fatalf("failed to get groups: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected groups (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupsGroupAll(t *testing.T) {
+	var (
+		reqs   []string
+		groups int
+	)
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://player/get_players\r\n":
+			return response("player/get_players", "", []heos.PlayerInfo{
+				{Name: "Kitchen", PID: 1},
+				{Name: "Living Room", PID: 2},
+				{Name: "Office", PID: 3},
+			})
+		case "heos://group/get_groups\r\n":
+			// The first two queries observe a partially applied group, and
+			// the rest observe the complete group.
+			groups++
+			ps := []heos.GroupPlayer{
+				{PID: 2, Role: heos.RoleLeader},
+				{PID: 1, Role: heos.RoleMember},
+			}
+			if groups > 2 {
+				ps = append(ps, heos.GroupPlayer{PID: 3, Role: heos.RoleMember})
+			}
+
+			return response("group/get_groups", "", []heos.GroupInfo{{GID: 2, Players: ps}})
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	// A stale GroupsChanged event must not be mistaken for confirmation.
+	events := make(chan heos.Event, 3)
+	events <- &heos.PlayersChanged{}
+	events <- &heos.GroupsChanged{}
+	events <- &heos.GroupsChanged{}
+
+	if err := c.Groups.GroupAll(ctx, 2, events); err != nil {
+		t.Fatalf("failed to group all players: %v", err)
+	}
+
+	// The players are already grouped, so no event is necessary.
+	if err := c.Groups.GroupAll(ctx, 2, make(chan heos.Event)); err != nil {
+		t.Fatalf("failed to group all players again: %v", err)
+	}
+
+	if err := c.Groups.GroupAll(ctx, 4, nil); err == nil {
+		t.Fatal("expected an error for unknown leader, but none occurred")
+	}
+
+	want := []string{
+		"heos://player/get_players\r\n",
+		"heos://group/set_group?pid=2,1,3\r\n",
+		"heos://group/get_groups\r\n",
+		"heos://group/get_groups\r\n",
+		"heos://group/get_groups\r\n",
+		"heos://player/get_players\r\n",
+		"heos://group/set_group?pid=2,1,3\r\n",
+		"heos://group/get_groups\r\n",
+		"heos://player/get_players\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupsUngroupAll(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://group/get_groups\r\n":
+			return response("group/get_groups", "", []heos.GroupInfo{
+				{
+					GID: 1,
+					Players: []heos.GroupPlayer{
+						{PID: 2, Role: heos.RoleMember},
+						{PID: 1, Role: heos.RoleLeader},
+					},
+				},
+				{
+					GID: 3,
+					Players: []heos.GroupPlayer{
+						{PID: 3, Role: heos.RoleLeader},
+						{PID: 4, Role: heos.RoleMember},
+					},
+				},
+			})
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	if err := c.Groups.UngroupAll(ctx); err != nil {
+		t.Fatalf("failed to ungroup all players: %v", err)
+	}
+
+	want := []string{
+		"heos://group/get_groups\r\n",
+		"heos://group/set_group?pid=1\r\n",
+		"heos://group/set_group?pid=3\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupsVolumeStep(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+		return ack(req)
+	})
+	defer done()
+
+	if err := c.Groups.VolumeUp(ctx, 1, 5); err != nil {
+		t.Fatalf("failed to increase volume: %v", err)
+	}
+	if err := c.Groups.VolumeDown(ctx, 1, 10); err != nil {
+		t.Fatalf("failed to decrease volume: %v", err)
+	}
+
+	for _, step := range []int{0, 11} {
+		if err := c.Groups.VolumeUp(ctx, 1, step); err == nil {
+			t.Fatalf("expected an out of range error for step %d, but none occurred", step)
+		}
+	}
+
+	want := []string{
+		"heos://group/volume_up?gid=1&step=5\r\n",
+		"heos://group/volume_down?gid=1&step=10\r\n",
+	}
+
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupsVolumeLimit(t *testing.T) {
+	var reqs []string
+	cfg := &heos.Config{
+		VolumeLimit: &heos.VolumeLimit{
+			Max:     60,
+			Players: map[heos.PlayerID]int{2: 30},
+		},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://group/get_group_info?gid=1\r\n":
+			return response("group/get_group_info", "gid=1", heos.GroupInfo{
+				GID: 1,
+				Players: []heos.GroupPlayer{
+					{PID: 1, Role: heos.RoleLeader},
+					{PID: 2, Role: heos.RoleMember},
+				},
+			})
+		case "heos://group/get_volume?gid=1\r\n":
+			return response("group/get_volume", "gid=1&level=28", nil)
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	// The group is limited by its member with the lowest limit.
+	if err := c.Groups.SetVolume(ctx, 1, 80); err != nil {
+		t.Fatalf("failed to set volume: %v", err)
+	}
+	if err := c.Groups.VolumeUp(ctx, 1, 5); err != nil {
+		t.Fatalf("failed to increase volume: %v", err)
+	}
+
+	want := []string{
+		"heos://group/get_group_info?gid=1\r\n",
+		"heos://group/set_volume?gid=1&level=30\r\n",
+		"heos://group/get_group_info?gid=1\r\n",
+		"heos://group/get_volume?gid=1\r\n",
+		"heos://group/set_volume?gid=1&level=30\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupsFadeVolumeCanceled(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://group/get_volume?gid=1\r\n":
+			return response("group/get_volume", "gid=1&level=50", nil)
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+	defer cancel()
+
+	// The fade would take far longer than the context permits.
+	err := c.Groups.FadeVolume(ctx, 1, 0, 1*time.Minute)
+	if diff := cmp.Diff(context.DeadlineExceeded, err, cmp.Comparer(func(x, y error) bool {
+		return x == y
+	})); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,220 @@
+package heos
+
+import (
+	"context"
+	"log/slog"
+)
+
+// A GroupChange describes how a group changed between two queries of the
+// device's groups, as delivered by Groups.WatchGroups.
+type GroupChange struct {
+	// Before and After are the group before and after the change. Before is
+	// nil for a group which was created, and After is nil for a group which
+	// was removed.
+	Before, After *GroupInfo
+
+	// Joined and Left are the players which joined and left the group,
+	// including its leader.
+	Joined, Left []PlayerID
+
+	// LeaderChanged reports whether a different player leads the group.
+	// Because HEOS identifies a group by the ID of its leader, Before and
+	// After have different group IDs when LeaderChanged is true.
+	LeaderChanged bool
+}
+
+// WatchGroups returns a channel which delivers changes to the groups known to
+// the device: first a GroupChange creating each current group, and then the
+// changes found by querying the groups again each time a GroupsChanged event
+// is received from events. Each value holds all of the changes found by one
+// query, and is not delivered if the groups did not change. Groups are
+// queried again after a Reconnected event too, since a change may have been
+// missed.
+//
+// WatchGroups consumes all events from events, which typically come from an
+// EventStream. The returned channel is closed when the context is canceled or
+// events is closed. The Client must not be the Client used by an EventStream,
+// since its connection is busy receiving events.
+func (g *Groups) WatchGroups(ctx context.Context, events <-chan Event) (<-chan []GroupChange, error) {
+	gs, err := g.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan []GroupChange, 1)
+	if changes := diffGroups(nil, gs); len(changes) > 0 {
+		out <- changes
+	}
+
+	go func() {
+		defer close(out)
+
+		for {
+			var e Event
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				e = ev
+			}
+
+			switch e.(type) {
+			case *GroupsChanged, *Reconnected:
+			default:
+				continue
+			}
+
+			next, err := g.GetGroups(ctx)
+			if err != nil {
+				// The context may have been canceled or the device may be
+				// temporarily unable to respond; try again on the next event.
+				g.c.log(ctx, slog.LevelWarn, "failed to query groups", slog.Any("error", err))
+				continue
+			}
+
+			changes := diffGroups(gs, next)
+			gs = next
+			if len(changes) == 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- changes:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// diffGroups returns the changes between the groups before and after. Groups
+// are matched by group ID, and then groups which remain unmatched are matched
+// with the group with which they share the most players, so that a change of
+// leader is reported as a change to one group.
+func diffGroups(before, after []GroupInfo) []GroupChange {
+	var (
+		used  = make([]bool, len(before))
+		match = make([]int, len(after))
+	)
+	for i, a := range after {
+		match[i] = -1
+		for j, b := range before {
+			if !used[j] && a.GID == b.GID {
+				match[i], used[j] = j, true
+				break
+			}
+		}
+	}
+
+	for i, a := range after {
+		if match[i] != -1 {
+			continue
+		}
+
+		best, most := -1, 0
+		for j, b := range before {
+			if n := len(sharedPlayers(a, b)); !used[j] && n > most {
+				best, most = j, n
+			}
+		}
+		if best != -1 {
+			match[i], used[best] = best, true
+		}
+	}
+
+	var changes []GroupChange
+	for i := range after {
+		var b *GroupInfo
+		if j := match[i]; j != -1 {
+			b = &before[j]
+		}
+
+		if gc := newGroupChange(b, &after[i]); gc.changed() {
+			changes = append(changes, gc)
+		}
+	}
+	for j := range before {
+		if !used[j] {
+			changes = append(changes, newGroupChange(&before[j], nil))
+		}
+	}
+
+	return changes
+}
+
+// newGroupChange creates a GroupChange from the group before to the group
+// after, either of which may be nil.
+func newGroupChange(before, after *GroupInfo) GroupChange {
+	gc := GroupChange{Before: before, After: after}
+
+	bpids, apids := groupPIDs(before), groupPIDs(after)
+	for _, pid := range apids {
+		if !containsPID(bpids, pid) {
+			gc.Joined = append(gc.Joined, pid)
+		}
+	}
+	for _, pid := range bpids {
+		if !containsPID(apids, pid) {
+			gc.Left = append(gc.Left, pid)
+		}
+	}
+
+	gc.LeaderChanged = before != nil && after != nil && groupLeader(before) != groupLeader(after)
+	return gc
+}
+
+// changed reports whether gc describes a change.
+func (gc *GroupChange) changed() bool {
+	switch {
+	case gc.Before == nil || gc.After == nil:
+		return true
+	case len(gc.Joined) > 0, len(gc.Left) > 0, gc.LeaderChanged:
+		return true
+	default:
+		return gc.Before.Name != gc.After.Name
+	}
+}
+
+// groupPIDs returns the IDs of the players in gi, which may be nil.
+func groupPIDs(gi *GroupInfo) []PlayerID {
+	if gi == nil {
+		return nil
+	}
+
+	pids := make([]PlayerID, 0, len(gi.Players))
+	for _, p := range gi.Players {
+		pids = append(pids, p.PID)
+	}
+
+	return pids
+}
+
+// groupLeader returns the ID of the leader of gi, or 0 if it has none.
+func groupLeader(gi *GroupInfo) PlayerID {
+	for _, p := range gi.Players {
+		if p.Role == RoleLeader {
+			return p.PID
+		}
+	}
+
+	return 0
+}
+
+// sharedPlayers returns the IDs of the players which belong to both a and b.
+func sharedPlayers(a, b GroupInfo) []PlayerID {
+	bpids := groupPIDs(&b)
+
+	var pids []PlayerID
+	for _, pid := range groupPIDs(&a) {
+		if containsPID(bpids, pid) {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids
+}
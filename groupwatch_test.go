@@ -0,0 +1,94 @@
+package heos_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestGroupsWatchGroups(t *testing.T) {
+	var (
+		kitchen = heos.GroupInfo{
+			Name: "Kitchen + Dining",
+			GID:  1,
+			Players: []heos.GroupPlayer{
+				{Name: "Kitchen", PID: 1, Role: heos.RoleLeader},
+				{Name: "Dining", PID: 2, Role: heos.RoleMember},
+			},
+		}
+		office = heos.GroupInfo{
+			Name: "Office + Den",
+			GID:  3,
+			Players: []heos.GroupPlayer{
+				{Name: "Office", PID: 3, Role: heos.RoleLeader},
+				{Name: "Den", PID: 4, Role: heos.RoleMember},
+			},
+		}
+		// Dining takes over the kitchen group and Patio joins, while the
+		// office group is dissolved.
+		dining = heos.GroupInfo{
+			Name: "Dining + Kitchen + Patio",
+			GID:  2,
+			Players: []heos.GroupPlayer{
+				{Name: "Dining", PID: 2, Role: heos.RoleLeader},
+				{Name: "Kitchen", PID: 1, Role: heos.RoleMember},
+				{Name: "Patio", PID: 5, Role: heos.RoleMember},
+			},
+		}
+	)
+
+	responses := [][]heos.GroupInfo{
+		{kitchen, office},
+		{dining},
+		// Unchanged, so nothing is delivered.
+		{dining},
+	}
+
+	var n int
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		if req != "heos://group/get_groups\r\n" {
+			panicf("unexpected client request: %q", req)
+		}
+
+		gs := responses[n]
+		n++
+		return response("group/get_groups", "", gs)
+	})
+	defer done()
+
+	events := make(chan heos.Event, 4)
+	changes, err := c.Groups.WatchGroups(ctx, events)
+	if err != nil {
+		t.Fatalf("failed to watch groups: %v", err)
+	}
+
+	events <- &heos.PlayerVolumeChanged{PID: 1, Level: 10}
+	events <- &heos.GroupsChanged{}
+	events <- &heos.GroupsChanged{}
+	close(events)
+
+	var got [][]heos.GroupChange
+	for gcs := range changes {
+		got = append(got, gcs)
+	}
+
+	want := [][]heos.GroupChange{
+		{
+			{After: &kitchen, Joined: []heos.PlayerID{1, 2}},
+			{After: &office, Joined: []heos.PlayerID{3, 4}},
+		},
+		{
+			{
+				Before:        &kitchen,
+				After:         &dining,
+				Joined:        []heos.PlayerID{5},
+				LeaderChanged: true,
+			},
+			{Before: &office, Left: []heos.PlayerID{3, 4}},
+		},
+	}
+	if diff := cmp.Diff(want, got, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected group changes (-want +got):\n%s", diff)
+	}
+}
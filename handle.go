@@ -0,0 +1,280 @@
+package heos
+
+import (
+	"context"
+	"time"
+)
+
+// A Player is a handle to a single HEOS player, bound to its player ID. A
+// Player is cheap to create and is safe for concurrent use. Create Players
+// using Client.Player, such as with the results of Players.GetPlayers:
+//
+//	ps, _ := c.Players.GetPlayers(ctx)
+//	for _, pi := range ps {
+//		_ = c.Player(pi.PID).SetVolume(ctx, 20)
+//	}
+type Player struct {
+	c   *Client
+	pid PlayerID
+}
+
+// Player returns a Player handle for the player specified by pid.
+func (c *Client) Player(pid PlayerID) *Player {
+	return &Player{c: c, pid: pid}
+}
+
+// PID returns the player ID the Player is bound to.
+func (p *Player) PID() PlayerID { return p.pid }
+
+// Info returns information about the Player.
+func (p *Player) Info(ctx context.Context) (*PlayerInfo, error) {
+	return p.c.Players.GetPlayerInfo(ctx, p.pid)
+}
+
+// GetNowPlayingMedia returns information about the media playing on the
+// Player.
+func (p *Player) GetNowPlayingMedia(ctx context.Context) (*NowPlayingMedia, error) {
+	return p.c.Players.GetNowPlayingMedia(ctx, p.pid)
+}
+
+// WatchNowPlaying returns a channel which delivers the media playing on the
+// Player as it changes. See Players.WatchNowPlaying for details. The Player's
+// Client must not be the Client used by an EventStream.
+func (p *Player) WatchNowPlaying(ctx context.Context, events <-chan Event) (<-chan *NowPlayingMedia, error) {
+	return p.c.Players.WatchNowPlaying(ctx, p.pid, events)
+}
+
+// OnPlaybackError invokes fn for each PlayerPlaybackError event for the Player
+// received from events, which typically come from an EventStream, so that an
+// application can react when a stream fails. OnPlaybackError consumes all
+// events from events and blocks until the context is canceled or events is
+// closed.
+func (p *Player) OnPlaybackError(ctx context.Context, events <-chan Event, fn func(err *PlayerPlaybackError)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if e, ok := e.(*PlayerPlaybackError); ok && e.PID == p.pid {
+				fn(e)
+			}
+		}
+	}
+}
+
+// GetPlayState returns the play state of the Player.
+func (p *Player) GetPlayState(ctx context.Context) (string, error) {
+	return p.c.Players.GetPlayState(ctx, p.pid)
+}
+
+// SetPlayState sets the play state of the Player.
+func (p *Player) SetPlayState(ctx context.Context, state string) error {
+	return p.c.Players.SetPlayState(ctx, p.pid, state)
+}
+
+// Sleep stops playback on the Player after d. See Players.Sleep for details.
+func (p *Player) Sleep(ctx context.Context, d, fade time.Duration) error {
+	return p.c.Players.Sleep(ctx, p.pid, d, fade)
+}
+
+// GetVolume returns the volume level of the Player, in the range 0-100.
+func (p *Player) GetVolume(ctx context.Context) (int, error) {
+	return p.c.Players.GetVolume(ctx, p.pid)
+}
+
+// SetVolume sets the volume level of the Player, in the range 0-100.
+func (p *Player) SetVolume(ctx context.Context, level int) error {
+	return p.c.Players.SetVolume(ctx, p.pid, level)
+}
+
+// VolumeUp increases the volume level of the Player by step, in the range
+// 1-10.
+func (p *Player) VolumeUp(ctx context.Context, step int) error {
+	return p.c.Players.VolumeUp(ctx, p.pid, step)
+}
+
+// VolumeDown decreases the volume level of the Player by step, in the range
+// 1-10.
+func (p *Player) VolumeDown(ctx context.Context, step int) error {
+	return p.c.Players.VolumeDown(ctx, p.pid, step)
+}
+
+// FadeVolume gradually transitions the volume level of the Player to level
+// over the duration d. See Players.FadeVolume for details.
+func (p *Player) FadeVolume(ctx context.Context, level int, d time.Duration) error {
+	return p.c.Players.FadeVolume(ctx, p.pid, level, d)
+}
+
+// A Group is a handle to a HEOS group, bound to its group ID. A Group is cheap
+// to create and is safe for concurrent use. Create Groups using Client.Group.
+type Group struct {
+	c   *Client
+	gid GroupID
+}
+
+// Group returns a Group handle for the group specified by gid.
+func (c *Client) Group(gid GroupID) *Group {
+	return &Group{c: c, gid: gid}
+}
+
+// GID returns the group ID the Group is bound to.
+func (g *Group) GID() GroupID { return g.gid }
+
+// Info returns information about the Group.
+func (g *Group) Info(ctx context.Context) (*GroupInfo, error) {
+	return g.c.Groups.GetGroupInfo(ctx, g.gid)
+}
+
+// Members returns Player handles for each of the players in the Group. The
+// group leader is always returned first.
+func (g *Group) Members(ctx context.Context) ([]*Player, error) {
+	gi, err := g.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := make([]*Player, 0, len(gi.Players))
+	for _, p := range sortLeader(gi.Players) {
+		ps = append(ps, g.c.Player(p.PID))
+	}
+
+	return ps, nil
+}
+
+// AddMembers adds the players specified by pids to the Group.
+func (g *Group) AddMembers(ctx context.Context, pids ...PlayerID) error {
+	leader, members, err := g.membership(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pid := range pids {
+		if !containsPID(members, pid) && pid != leader {
+			members = append(members, pid)
+		}
+	}
+
+	return g.c.Groups.SetGroup(ctx, leader, members...)
+}
+
+// RemoveMembers removes the players specified by pids from the Group. The
+// group leader cannot be removed; to dissolve the Group, use
+// Groups.SetGroup with only the leader's player ID.
+func (g *Group) RemoveMembers(ctx context.Context, pids ...PlayerID) error {
+	leader, members, err := g.membership(ctx)
+	if err != nil {
+		return err
+	}
+
+	keep := make([]PlayerID, 0, len(members))
+	for _, m := range members {
+		if !containsPID(pids, m) {
+			keep = append(keep, m)
+		}
+	}
+
+	return g.c.Groups.SetGroup(ctx, leader, keep...)
+}
+
+// membership returns the player IDs of the Group's leader and members.
+func (g *Group) membership(ctx context.Context) (PlayerID, []PlayerID, error) {
+	gi, err := g.Info(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// The group ID is the leader's player ID unless the device says
+	// otherwise.
+	leader := PlayerID(g.gid)
+	var members []PlayerID
+	for _, p := range gi.Players {
+		if p.Role == RoleLeader {
+			leader = p.PID
+			continue
+		}
+
+		members = append(members, p.PID)
+	}
+
+	return leader, members, nil
+}
+
+// GetVolume returns the volume level of the Group, in the range 0-100.
+func (g *Group) GetVolume(ctx context.Context) (int, error) {
+	return g.c.Groups.GetVolume(ctx, g.gid)
+}
+
+// SetVolume sets the volume level of the Group, in the range 0-100.
+func (g *Group) SetVolume(ctx context.Context, level int) error {
+	return g.c.Groups.SetVolume(ctx, g.gid, level)
+}
+
+// VolumeUp increases the volume level of the Group by step, in the range
+// 1-10.
+func (g *Group) VolumeUp(ctx context.Context, step int) error {
+	return g.c.Groups.VolumeUp(ctx, g.gid, step)
+}
+
+// VolumeDown decreases the volume level of the Group by step, in the range
+// 1-10.
+func (g *Group) VolumeDown(ctx context.Context, step int) error {
+	return g.c.Groups.VolumeDown(ctx, g.gid, step)
+}
+
+// FadeVolume gradually transitions the volume level of the Group to level
+// over the duration d. See Groups.FadeVolume for details.
+func (g *Group) FadeVolume(ctx context.Context, level int, d time.Duration) error {
+	return g.c.Groups.FadeVolume(ctx, g.gid, level, d)
+}
+
+// Sleep stops playback on the Group after d. See Players.Sleep for details.
+func (g *Group) Sleep(ctx context.Context, d, fade time.Duration) error {
+	return g.c.Groups.Sleep(ctx, g.gid, d, fade)
+}
+
+// GetMute reports whether the Group is muted.
+func (g *Group) GetMute(ctx context.Context) (bool, error) {
+	return g.c.Groups.GetMute(ctx, g.gid)
+}
+
+// SetMute mutes or unmutes the Group.
+func (g *Group) SetMute(ctx context.Context, mute bool) error {
+	return g.c.Groups.SetMute(ctx, g.gid, mute)
+}
+
+// ToggleMute toggles the mute state of the Group.
+func (g *Group) ToggleMute(ctx context.Context) error {
+	return g.c.Groups.ToggleMute(ctx, g.gid)
+}
+
+// sortLeader returns a copy of ps with the group leader first.
+func sortLeader(ps []GroupPlayer) []GroupPlayer {
+	out := make([]GroupPlayer, 0, len(ps))
+	for _, p := range ps {
+		if p.Role == RoleLeader {
+			out = append(out, p)
+		}
+	}
+	for _, p := range ps {
+		if p.Role != RoleLeader {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
+
+// containsPID reports whether pids contains pid.
+func containsPID(pids []PlayerID, pid PlayerID) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,191 @@
+package heos_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientPlayer(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://player/get_players\r\n":
+			return response("player/get_players", "", []heos.PlayerInfo{
+				{Name: "Living Room", PID: -1234, Model: "HEOS 1"},
+				{Name: "Kitchen", PID: 5678, Model: "HEOS 3"},
+			})
+		case "heos://player/set_volume?pid=-1234&level=20\r\n",
+			"heos://player/set_volume?pid=5678&level=20\r\n":
+			return response("player/set_volume", "", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	ps, err := c.Players.GetPlayers(ctx)
+	if err != nil {
+		t.Fatalf("failed to get players: %v", err)
+	}
+
+	var pids []heos.PlayerID
+	for _, pi := range ps {
+		p := c.Player(pi.PID)
+		if err := p.SetVolume(ctx, 20); err != nil {
+			t.Fatalf("failed to set volume for %d: %v", p.PID(), err)
+		}
+
+		pids = append(pids, p.PID())
+	}
+
+	if diff := cmp.Diff([]heos.PlayerID{-1234, 5678}, pids); diff != "" {
+		t.Fatalf("unexpected player IDs (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientGroup(t *testing.T) {
+	info := heos.GroupInfo{
+		Name: "Living Room + Kitchen",
+		GID:  1,
+		Players: []heos.GroupPlayer{
+			{Name: "Kitchen", PID: 2, Role: heos.RoleMember},
+			{Name: "Living Room", PID: 1, Role: heos.RoleLeader},
+			{Name: "Den", PID: 3, Role: heos.RoleMember},
+		},
+	}
+
+	var sets []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch {
+		case req == "heos://group/get_group_info?gid=1\r\n":
+			return response("group/get_group_info", "gid=1", info)
+		case req == "heos://group/get_mute?gid=1\r\n":
+			return response("group/get_mute", "gid=1&state=on", nil)
+		case strings.HasPrefix(req, "heos://group/set_group?"):
+			sets = append(sets, strings.TrimSpace(req))
+			return response("group/set_group", "", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	g := c.Group(1)
+
+	ps, err := g.Members(ctx)
+	if err != nil {
+		t.Fatalf("failed to get members: %v", err)
+	}
+
+	var pids []heos.PlayerID
+	for _, p := range ps {
+		pids = append(pids, p.PID())
+	}
+
+	// The leader must be first.
+	if diff := cmp.Diff([]heos.PlayerID{1, 2, 3}, pids); diff != "" {
+		t.Fatalf("unexpected member player IDs (-want +got):\n%s", diff)
+	}
+
+	muted, err := g.GetMute(ctx)
+	if err != nil {
+		t.Fatalf("failed to get mute: %v", err)
+	}
+	if !muted {
+		t.Fatal("expected group to be muted")
+	}
+
+	if err := g.AddMembers(ctx, 2, 4); err != nil {
+		t.Fatalf("failed to add members: %v", err)
+	}
+	if err := g.RemoveMembers(ctx, 3); err != nil {
+		t.Fatalf("failed to remove members: %v", err)
+	}
+
+	want := []string{
+		"heos://group/set_group?pid=1,2,3,4",
+		"heos://group/set_group?pid=1,2",
+	}
+
+	if diff := cmp.Diff(want, sets); diff != "" {
+		t.Fatalf("unexpected set_group requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayerOnPlaybackError(t *testing.T) {
+	c, _, done := testClient(t, nil)
+	defer done()
+
+	events := make(chan heos.Event, 3)
+	events <- &heos.PlayerPlaybackError{PID: 2, Text: "Wrong Player"}
+	events <- &heos.PlayerStateChanged{PID: 1, State: heos.StateStop}
+	events <- &heos.PlayerPlaybackError{PID: 1, Text: "Could Not Download"}
+	close(events)
+
+	var errs []string
+	c.Player(1).OnPlaybackError(context.Background(), events, func(err *heos.PlayerPlaybackError) {
+		errs = append(errs, err.Error())
+	})
+
+	want := []string{"heos: playback error on player 1: Could Not Download"}
+	if diff := cmp.Diff(want, errs); diff != "" {
+		t.Fatalf("unexpected playback errors (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientSpeaker(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, strings.TrimSpace(req))
+
+		switch req {
+		case "heos://player/get_player_info?pid=2\r\n":
+			// A member of group 1.
+			return response("player/get_player_info", "pid=2", heos.PlayerInfo{PID: 2, GID: 1})
+		case "heos://player/get_player_info?pid=3\r\n":
+			return response("player/get_player_info", "pid=3", heos.PlayerInfo{PID: 3})
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	for _, pid := range []heos.PlayerID{2, 3} {
+		s := c.Speaker(pid)
+		if err := s.SetVolume(ctx, 20); err != nil {
+			t.Fatalf("failed to set volume for %d: %v", s.PID(), err)
+		}
+		if err := s.SetPlayState(ctx, heos.StatePlay); err != nil {
+			t.Fatalf("failed to set play state for %d: %v", s.PID(), err)
+		}
+	}
+
+	g, err := c.Speaker(3).Group(ctx)
+	if err != nil {
+		t.Fatalf("failed to get group: %v", err)
+	}
+	if g != nil {
+		t.Fatalf("expected no group for ungrouped speaker, but got: %d", g.GID())
+	}
+
+	want := []string{
+		"heos://player/get_player_info?pid=2",
+		"heos://group/set_volume?gid=1&level=20",
+		"heos://player/get_player_info?pid=2",
+		"heos://player/set_play_state?pid=1&state=play",
+		"heos://player/get_player_info?pid=3",
+		"heos://player/set_volume?pid=3&level=20",
+		"heos://player/get_player_info?pid=3",
+		"heos://player/set_play_state?pid=3&state=play",
+		"heos://player/get_player_info?pid=3",
+	}
+
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,354 @@
+// Package heoshttp exposes a heos.Client as a small HTTP API, so that programs
+// which do not speak the HEOS protocol, such as curl or Home Assistant REST
+// sensors, can drive HEOS devices.
+//
+// All responses are JSON. The following endpoints are available, where pid
+// and gid are player and group IDs:
+//
+//	GET  /players
+//	GET  /players/{pid}
+//	GET  /players/{pid}/now_playing
+//	GET  /players/{pid}/volume       POST {"level": 20}
+//	GET  /players/{pid}/mute         POST {"mute": true}
+//	GET  /players/{pid}/play_state   POST {"state": "play"}
+//	GET  /groups
+//	GET  /groups/{gid}
+//	GET  /groups/{gid}/volume        POST {"level": 20}
+//	GET  /groups/{gid}/mute          POST {"mute": true}
+//
+// Successful POST requests return 204 No Content. Errors are returned as a
+// JSON object with an "error" field, and an "eid" field for errors reported
+// by a HEOS device.
+package heoshttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mdlayher/heos"
+)
+
+// maxBody is the maximum size of a request body.
+const maxBody = 1 << 10
+
+var _ http.Handler = &Handler{}
+
+// A Handler is an http.Handler which serves the HTTP API for a heos.Client.
+type Handler struct {
+	c *heos.Client
+}
+
+// NewHandler creates a Handler which serves requests using c.
+func NewHandler(c *heos.Client) *Handler {
+	return &Handler{c: c}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Paths are of the form /{kind}[/{id}[/{property}]].
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 3 {
+		writeError(w, errNotFound)
+		return
+	}
+
+	var run func(context.Context, *http.Request, []string) (interface{}, error)
+	switch parts[0] {
+	case "players":
+		run = h.players
+	case "groups":
+		run = h.groups
+	default:
+		writeError(w, errNotFound)
+		return
+	}
+
+	out, err := run(r.Context(), r, parts[1:])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if out == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// players handles requests for /players.
+func (h *Handler) players(ctx context.Context, r *http.Request, parts []string) (interface{}, error) {
+	p := h.c.Players
+
+	if len(parts) == 0 {
+		if err := method(r, http.MethodGet); err != nil {
+			return nil, err
+		}
+
+		return p.GetPlayers(ctx)
+	}
+
+	pid, err := id[heos.PlayerID](parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var property string
+	if len(parts) == 2 {
+		property = parts[1]
+	}
+
+	switch property {
+	case "":
+		if err := method(r, http.MethodGet); err != nil {
+			return nil, err
+		}
+
+		return p.GetPlayerInfo(ctx, pid)
+	case "now_playing":
+		if err := method(r, http.MethodGet); err != nil {
+			return nil, err
+		}
+
+		return p.GetNowPlayingMedia(ctx, pid)
+	case "volume":
+		return volume(ctx, r, pid, p.GetVolume, p.SetVolume)
+	case "mute":
+		return mute(ctx, r, pid, p.GetMute, p.SetMute)
+	case "play_state":
+		switch r.Method {
+		case http.MethodGet:
+			state, err := p.GetPlayState(ctx, pid)
+			if err != nil {
+				return nil, err
+			}
+
+			return playState{State: state}, nil
+		case http.MethodPost:
+			var body playState
+			if err := decode(r, &body); err != nil {
+				return nil, err
+			}
+
+			switch body.State {
+			case heos.StatePlay, heos.StatePause, heos.StateStop:
+			default:
+				return nil, badRequest(fmt.Errorf("invalid play state %q", body.State))
+			}
+
+			return nil, p.SetPlayState(ctx, pid, body.State)
+		default:
+			return nil, errMethod
+		}
+	default:
+		return nil, errNotFound
+	}
+}
+
+// groups handles requests for /groups.
+func (h *Handler) groups(ctx context.Context, r *http.Request, parts []string) (interface{}, error) {
+	g := h.c.Groups
+
+	if len(parts) == 0 {
+		if err := method(r, http.MethodGet); err != nil {
+			return nil, err
+		}
+
+		return g.GetGroups(ctx)
+	}
+
+	gid, err := id[heos.GroupID](parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var property string
+	if len(parts) == 2 {
+		property = parts[1]
+	}
+
+	switch property {
+	case "":
+		if err := method(r, http.MethodGet); err != nil {
+			return nil, err
+		}
+
+		return g.GetGroupInfo(ctx, gid)
+	case "volume":
+		return volume(ctx, r, gid, g.GetVolume, g.SetVolume)
+	case "mute":
+		return mute(ctx, r, gid, g.GetMute, g.SetMute)
+	default:
+		return nil, errNotFound
+	}
+}
+
+// A level is the body of a volume request or response.
+type level struct {
+	Level *int `json:"level"`
+}
+
+// A muted is the body of a mute request or response.
+type muted struct {
+	Mute *bool `json:"mute"`
+}
+
+// A playState is the body of a play state request or response.
+type playState struct {
+	State string `json:"state"`
+}
+
+// volume gets or sets the volume of the player or group specified by id.
+func volume[ID heos.PlayerID | heos.GroupID](
+	ctx context.Context,
+	r *http.Request,
+	id ID,
+	get func(ctx context.Context, id ID) (int, error),
+	set func(ctx context.Context, id ID, level int) error,
+) (interface{}, error) {
+	switch r.Method {
+	case http.MethodGet:
+		v, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return level{Level: &v}, nil
+	case http.MethodPost:
+		var body level
+		if err := decode(r, &body); err != nil {
+			return nil, err
+		}
+		if body.Level == nil || *body.Level < 0 || *body.Level > 100 {
+			return nil, badRequest(errors.New("level must be in the range 0-100"))
+		}
+
+		return nil, set(ctx, id, *body.Level)
+	default:
+		return nil, errMethod
+	}
+}
+
+// mute gets or sets the mute state of the player or group specified by id.
+func mute[ID heos.PlayerID | heos.GroupID](
+	ctx context.Context,
+	r *http.Request,
+	id ID,
+	get func(ctx context.Context, id ID) (bool, error),
+	set func(ctx context.Context, id ID, mute bool) error,
+) (interface{}, error) {
+	switch r.Method {
+	case http.MethodGet:
+		v, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return muted{Mute: &v}, nil
+	case http.MethodPost:
+		var body muted
+		if err := decode(r, &body); err != nil {
+			return nil, err
+		}
+		if body.Mute == nil {
+			return nil, badRequest(errors.New("mute must be specified"))
+		}
+
+		return nil, set(ctx, id, *body.Mute)
+	default:
+		return nil, errMethod
+	}
+}
+
+// An httpError is an error with an associated HTTP status code.
+type httpError struct {
+	code int
+	err  error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+
+var (
+	errNotFound = &httpError{code: http.StatusNotFound, err: errors.New("not found")}
+	errMethod   = &httpError{code: http.StatusMethodNotAllowed, err: errors.New("method not allowed")}
+)
+
+// badRequest wraps err with a 400 Bad Request status.
+func badRequest(err error) error {
+	return &httpError{code: http.StatusBadRequest, err: err}
+}
+
+// method verifies that r uses the HTTP method want.
+func method(r *http.Request, want string) error {
+	if r.Method != want {
+		return errMethod
+	}
+
+	return nil
+}
+
+// id parses a player or group ID from a path element.
+func id[ID heos.PlayerID | heos.GroupID](s string) (ID, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, badRequest(fmt.Errorf("invalid ID %q", s))
+	}
+
+	return ID(v), nil
+}
+
+// decode decodes a JSON request body from r into v.
+func decode(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return badRequest(fmt.Errorf("invalid request body: %v", err))
+	}
+
+	return nil
+}
+
+// status returns the HTTP status code for err.
+func status(err error) int {
+	var herr *httpError
+	var eerr *heos.Error
+	switch {
+	case errors.As(err, &herr):
+		return herr.code
+	case errors.Is(err, heos.ErrVolumeLimit):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &eerr):
+		// The device rejected the command.
+		return http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError writes err to w as a JSON object with an appropriate status code.
+func writeError(w http.ResponseWriter, err error) {
+	body := struct {
+		Error string `json:"error"`
+		EID   int    `json:"eid,omitempty"`
+	}{
+		Error: err.Error(),
+	}
+
+	var eerr *heos.Error
+	if errors.As(err, &eerr) {
+		body.EID = eerr.EID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status(err))
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,195 @@
+package heoshttp_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/heoshttp"
+)
+
+func TestHandler(t *testing.T) {
+	h, reqs := testHandler(t, map[string]string{
+		"heos://player/get_players\r\n":                     `{"heos": {"command": "player/get_players", "result": "success", "message": ""}, "payload": [{"name": "Kitchen", "pid": 1}]}`,
+		"heos://player/get_volume?pid=1\r\n":                `{"heos": {"command": "player/get_volume", "result": "success", "message": "pid=1&level=20"}}`,
+		"heos://player/set_volume?pid=1&level=30\r\n":       `{"heos": {"command": "player/set_volume", "result": "success", "message": "pid=1&level=30"}}`,
+		"heos://player/get_play_state?pid=1\r\n":            `{"heos": {"command": "player/get_play_state", "result": "success", "message": "pid=1&state=pause"}}`,
+		"heos://player/set_play_state?pid=1&state=play\r\n": `{"heos": {"command": "player/set_play_state", "result": "success", "message": "pid=1&state=play"}}`,
+		"heos://group/get_mute?gid=2\r\n":                   `{"heos": {"command": "group/get_mute", "result": "fail", "message": "eid=2&text=ID Not Valid"}}`,
+	})
+
+	tests := []struct {
+		name, method, path, body string
+		code                     int
+		want                     string
+	}{
+		{
+			name:   "players",
+			method: http.MethodGet,
+			path:   "/players",
+			code:   http.StatusOK,
+			want:   `[{"name":"Kitchen","pid":1,`,
+		},
+		{
+			name:   "get volume",
+			method: http.MethodGet,
+			path:   "/players/1/volume",
+			code:   http.StatusOK,
+			want:   `{"level":20}`,
+		},
+		{
+			name:   "set volume",
+			method: http.MethodPost,
+			path:   "/players/1/volume",
+			body:   `{"level": 30}`,
+			code:   http.StatusNoContent,
+		},
+		{
+			name:   "set volume out of range",
+			method: http.MethodPost,
+			path:   "/players/1/volume",
+			body:   `{"level": 101}`,
+			code:   http.StatusBadRequest,
+			want:   `{"error":"level must be in the range 0-100"}`,
+		},
+		{
+			name:   "get play state",
+			method: http.MethodGet,
+			path:   "/players/1/play_state",
+			code:   http.StatusOK,
+			want:   `{"state":"pause"}`,
+		},
+		{
+			name:   "set play state",
+			method: http.MethodPost,
+			path:   "/players/1/play_state",
+			body:   `{"state": "play"}`,
+			code:   http.StatusNoContent,
+		},
+		{
+			name:   "device error",
+			method: http.MethodGet,
+			path:   "/groups/2/mute",
+			code:   http.StatusBadGateway,
+			want:   `{"error":`,
+		},
+		{
+			name:   "bad ID",
+			method: http.MethodGet,
+			path:   "/players/foo",
+			code:   http.StatusBadRequest,
+			want:   `{"error":"invalid ID \"foo\""}`,
+		},
+		{
+			name:   "bad method",
+			method: http.MethodDelete,
+			path:   "/players",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "not found",
+			method: http.MethodGet,
+			path:   "/players/1/foo",
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if diff := cmp.Diff(tt.code, w.Code); diff != "" {
+				t.Fatalf("unexpected status code (-want +got):\n%s\nbody: %s", diff, w.Body.String())
+			}
+			if !strings.HasPrefix(w.Body.String(), tt.want) {
+				t.Fatalf("unexpected body:\nwant prefix: %s\n        got: %s", tt.want, w.Body.String())
+			}
+		})
+	}
+
+	// Invalid requests must never reach the device.
+	want := []string{
+		"heos://player/get_players",
+		"heos://player/get_volume?pid=1",
+		"heos://player/set_volume?pid=1&level=30",
+		"heos://player/get_play_state?pid=1",
+		"heos://player/set_play_state?pid=1&state=play",
+		"heos://group/get_mute?gid=2",
+	}
+
+	if diff := cmp.Diff(want, reqs()); diff != "" {
+		t.Fatalf("unexpected device requests (-want +got):\n%s", diff)
+	}
+}
+
+// testHandler creates a Handler backed by an in-memory device which answers
+// requests using the canned responses in res. The returned function reports
+// the requests received by the device after the handshake.
+func testHandler(t *testing.T, res map[string]string) (*heoshttp.Handler, func() []string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		r := bufio.NewReader(server)
+		for {
+			req, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			var out string
+			switch req {
+			case "heos://system/heart_beat\r\n":
+				out = `{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}`
+			case "heos://system/prettify_json_response?enable=off\r\n":
+				out = `{"heos": {"command": "system/prettify_json_response", "result": "success", "message": "enable=off"}}`
+			default:
+				mu.Lock()
+				reqs = append(reqs, strings.TrimSpace(req))
+				mu.Unlock()
+
+				var ok bool
+				if out, ok = res[req]; !ok {
+					panic("unexpected device request: " + req)
+				}
+			}
+
+			if _, err := io.WriteString(server, out+"\r\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := heos.New(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return heoshttp.NewHandler(c), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return reqs
+	}
+}
@@ -0,0 +1,243 @@
+// Package heosmqtt bridges HEOS players to an MQTT broker, so that
+// home-automation systems can observe and control HEOS devices using MQTT
+// topics.
+//
+// heosmqtt does not implement MQTT itself. Instead, a Bridge uses a Client,
+// which is typically a small adapter around an existing MQTT library.
+//
+// For each player, a Bridge publishes retained messages to the following
+// topics, where prefix is Bridge.Prefix and pid is the player ID:
+//
+//	prefix/players/pid/volume       "20"
+//	prefix/players/pid/mute         "true" or "false"
+//	prefix/players/pid/play_state   "play", "pause", or "stop"
+//	prefix/players/pid/now_playing  JSON heos.NowPlayingMedia
+//
+// A Bridge also subscribes to the same topics with a "/set" suffix, such as
+// "prefix/players/pid/volume/set", and applies the values published to them.
+package heosmqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mdlayher/heos"
+)
+
+// A Client is an MQTT client used by a Bridge.
+type Client interface {
+	// Publish publishes payload to topic, optionally as a retained message.
+	Publish(topic string, retained bool, payload []byte) error
+
+	// Subscribe subscribes to topic, which may contain MQTT wildcards, and
+	// invokes fn for each message received. fn may be called concurrently.
+	Subscribe(topic string, fn func(topic string, payload []byte)) error
+}
+
+// A Bridge publishes HEOS player state to MQTT topics and applies commands
+// received on MQTT topics.
+type Bridge struct {
+	// HEOS is used to query and control players. It must not be the Client
+	// which is used by the EventStream whose events are passed to Run, since
+	// Run queries players while processing events.
+	HEOS *heos.Client
+
+	// MQTT is used to publish and subscribe to MQTT topics.
+	MQTT Client
+
+	// Prefix is prepended to all topics. If empty, "heos" is used.
+	Prefix string
+
+	// Logger, if not nil, logs failures to publish state or apply commands.
+	Logger *slog.Logger
+}
+
+// Run publishes the state of all players, then publishes state changes for
+// events and applies commands received from MQTT until ctx is canceled or
+// events is closed.
+func (b *Bridge) Run(ctx context.Context, events <-chan heos.Event) error {
+	err := b.MQTT.Subscribe(b.topic("players/+/+/set"), func(topic string, payload []byte) {
+		if err := b.command(ctx, topic, payload); err != nil {
+			b.log(ctx, "failed to apply command", topic, err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("heosmqtt: failed to subscribe to commands: %w", err)
+	}
+
+	ps, err := b.HEOS.Players.GetPlayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range ps {
+		if err := b.publishPlayer(ctx, p.PID); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := b.event(ctx, e); err != nil {
+				b.log(ctx, "failed to publish state", "", err)
+			}
+		}
+	}
+}
+
+// publishPlayer publishes the complete state of the player specified by pid.
+func (b *Bridge) publishPlayer(ctx context.Context, pid heos.PlayerID) error {
+	p := b.HEOS.Players
+
+	level, err := p.GetVolume(ctx, pid)
+	if err != nil {
+		return err
+	}
+	mute, err := p.GetMute(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if err := b.publishVolume(pid, level, mute); err != nil {
+		return err
+	}
+
+	state, err := p.GetPlayState(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if err := b.publish(pid, "play_state", []byte(state)); err != nil {
+		return err
+	}
+
+	return b.publishNowPlaying(ctx, pid)
+}
+
+// event publishes the state changes indicated by e.
+func (b *Bridge) event(ctx context.Context, e heos.Event) error {
+	switch e := e.(type) {
+	case *heos.PlayerVolumeChanged:
+		return b.publishVolume(e.PID, e.Level, e.Mute)
+	case *heos.PlayerStateChanged:
+		return b.publish(e.PID, "play_state", []byte(e.State))
+	case *heos.PlayerNowPlayingChanged:
+		return b.publishNowPlaying(ctx, e.PID)
+	case *heos.PlayersChanged, *heos.Reconnected:
+		// Republish every player, since players or their state may have
+		// changed without notice.
+		ps, err := b.HEOS.Players.GetPlayers(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range ps {
+			if err := b.publishPlayer(ctx, p.PID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// publishVolume publishes the volume and mute state of a player.
+func (b *Bridge) publishVolume(pid heos.PlayerID, level int, mute bool) error {
+	if err := b.publish(pid, "volume", []byte(strconv.Itoa(level))); err != nil {
+		return err
+	}
+
+	return b.publish(pid, "mute", []byte(strconv.FormatBool(mute)))
+}
+
+// publishNowPlaying publishes the media playing on a player.
+func (b *Bridge) publishNowPlaying(ctx context.Context, pid heos.PlayerID) error {
+	np, err := b.HEOS.Players.GetNowPlayingMedia(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(np)
+	if err != nil {
+		return err
+	}
+
+	return b.publish(pid, "now_playing", j)
+}
+
+// publish publishes a retained value for a player property.
+func (b *Bridge) publish(pid heos.PlayerID, property string, payload []byte) error {
+	return b.MQTT.Publish(b.topic(fmt.Sprintf("players/%d/%s", pid, property)), true, payload)
+}
+
+// command applies the command in payload received on topic.
+func (b *Bridge) command(ctx context.Context, topic string, payload []byte) error {
+	// Topics are of the form prefix/players/pid/property/set.
+	parts := strings.Split(strings.TrimPrefix(topic, b.topic("players/")), "/")
+	if len(parts) != 3 || parts[2] != "set" {
+		return fmt.Errorf("heosmqtt: unexpected command topic %q", topic)
+	}
+
+	v, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("heosmqtt: invalid player ID in topic %q", topic)
+	}
+	pid := heos.PlayerID(v)
+
+	p := b.HEOS.Players
+	value := strings.TrimSpace(string(payload))
+
+	switch parts[1] {
+	case "volume":
+		level, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("heosmqtt: invalid volume level %q", value)
+		}
+
+		return p.SetVolume(ctx, pid, level)
+	case "mute":
+		mute, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("heosmqtt: invalid mute state %q", value)
+		}
+
+		return p.SetMute(ctx, pid, mute)
+	case "play_state":
+		return p.SetPlayState(ctx, pid, value)
+	default:
+		return fmt.Errorf("heosmqtt: unknown command topic %q", topic)
+	}
+}
+
+// topic returns the full topic name for suffix.
+func (b *Bridge) topic(suffix string) string {
+	prefix := b.Prefix
+	if prefix == "" {
+		prefix = "heos"
+	}
+
+	return prefix + "/" + suffix
+}
+
+// log logs err if a Logger is configured.
+func (b *Bridge) log(ctx context.Context, msg, topic string, err error) {
+	if b.Logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{slog.Any("error", err)}
+	if topic != "" {
+		attrs = append(attrs, slog.String("topic", topic))
+	}
+
+	b.Logger.LogAttrs(ctx, slog.LevelWarn, msg, attrs...)
+}
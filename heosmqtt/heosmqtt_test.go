@@ -0,0 +1,171 @@
+package heosmqtt_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/heosmqtt"
+)
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := testClient(t, map[string]string{
+		"heos://player/get_players\r\n":                 `{"heos": {"command": "player/get_players", "result": "success", "message": ""}, "payload": [{"name": "Kitchen", "pid": 1}]}`,
+		"heos://player/get_volume?pid=1\r\n":            `{"heos": {"command": "player/get_volume", "result": "success", "message": "pid=1&level=20"}}`,
+		"heos://player/get_mute?pid=1\r\n":              `{"heos": {"command": "player/get_mute", "result": "success", "message": "pid=1&state=off"}}`,
+		"heos://player/get_play_state?pid=1\r\n":        `{"heos": {"command": "player/get_play_state", "result": "success", "message": "pid=1&state=pause"}}`,
+		"heos://player/get_now_playing_media?pid=1\r\n": `{"heos": {"command": "player/get_now_playing_media", "result": "success", "message": "pid=1"}, "payload": {"type": "song", "song": "Song"}}`,
+		"heos://player/set_volume?pid=1&level=30\r\n":   `{"heos": {"command": "player/set_volume", "result": "success", "message": "pid=1&level=30"}}`,
+	})
+
+	mc := &testMQTT{subscribed: make(chan struct{})}
+	b := &heosmqtt.Bridge{
+		HEOS:   c,
+		MQTT:   mc,
+		Prefix: "home/heos",
+	}
+
+	events := make(chan heos.Event)
+	runC := make(chan error, 1)
+	go func() { runC <- b.Run(ctx, events) }()
+
+	<-mc.subscribed
+	events <- &heos.PlayerVolumeChanged{PID: 1, Level: 25, Mute: true}
+	events <- &heos.PlayerStateChanged{PID: 1, State: heos.StatePlay}
+
+	// Apply a command, and an invalid command which must be ignored.
+	mc.deliver("home/heos/players/1/volume/set", "30")
+	mc.deliver("home/heos/players/1/volume/set", "loud")
+
+	close(events)
+	if err := <-runC; err != nil {
+		t.Fatalf("failed to run bridge: %v", err)
+	}
+
+	want := []string{
+		"home/heos/players/1/volume: 20",
+		"home/heos/players/1/mute: false",
+		"home/heos/players/1/play_state: pause",
+		`home/heos/players/1/now_playing: {"type":"song","song":"Song",`,
+		"home/heos/players/1/volume: 25",
+		"home/heos/players/1/mute: true",
+		"home/heos/players/1/play_state: play",
+	}
+
+	got := mc.published()
+	if len(got) == len(want) {
+		// Only compare the prefix of the JSON now playing media.
+		if strings.HasPrefix(got[3], want[3]) {
+			got[3] = want[3]
+		}
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected published messages (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff("home/heos/players/+/+/set", mc.topic); diff != "" {
+		t.Fatalf("unexpected subscription (-want +got):\n%s", diff)
+	}
+}
+
+// A testMQTT is an in-memory heosmqtt.Client.
+type testMQTT struct {
+	subscribed chan struct{}
+
+	mu    sync.Mutex
+	msgs  []string
+	topic string
+	fn    func(topic string, payload []byte)
+}
+
+func (c *testMQTT) Publish(topic string, retained bool, payload []byte) error {
+	if !retained {
+		panic("message is not retained: " + topic)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msgs = append(c.msgs, topic+": "+string(payload))
+	return nil
+}
+
+func (c *testMQTT) Subscribe(topic string, fn func(topic string, payload []byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.topic, c.fn = topic, fn
+	close(c.subscribed)
+	return nil
+}
+
+func (c *testMQTT) deliver(topic, payload string) {
+	c.mu.Lock()
+	fn := c.fn
+	c.mu.Unlock()
+
+	fn(topic, []byte(payload))
+}
+
+func (c *testMQTT) published() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.msgs
+}
+
+// testClient creates a heos.Client connected to an in-memory device which
+// answers requests using the canned responses in res.
+func testClient(t *testing.T, res map[string]string) *heos.Client {
+	t.Helper()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		r := bufio.NewReader(server)
+		for {
+			req, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			var out string
+			switch req {
+			case "heos://system/heart_beat\r\n":
+				out = `{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}`
+			case "heos://system/prettify_json_response?enable=off\r\n":
+				out = `{"heos": {"command": "system/prettify_json_response", "result": "success", "message": "enable=off"}}`
+			default:
+				var ok bool
+				if out, ok = res[req]; !ok {
+					panic("unexpected device request: " + req)
+				}
+			}
+
+			if _, err := io.WriteString(server, out+"\r\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := heos.New(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
@@ -0,0 +1,504 @@
+// Package heostest implements an emulated HEOS device for testing programs
+// which use package heos, without access to real hardware.
+//
+// A Server speaks the HEOS protocol on a local TCP listener and emulates a
+// small set of system and player commands, with state such as player volume
+// shared by all of its connections. Each Server emulates a Profile, which
+// selects the players it reports and the quirks of a family of devices, such
+// as commands it does not recognize, IDs encoded as strings rather than
+// numbers, and prettified JSON responses. The built-in profiles are
+// representative of the differences seen between device generations, rather
+// than exact emulations of any firmware.
+package heostest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/wire"
+)
+
+// A Profile describes the players and quirks of an emulated device.
+type Profile struct {
+	// Name is the name of the Profile, such as "HEOS 1".
+	Name string
+
+	// Players are the players reported by the device. Each starts stopped,
+	// unmuted, and at volume level 20.
+	Players []heos.PlayerInfo
+
+	// Unsupported are commands which the device reports that it does not
+	// recognize, such as "player/get_quickselects", in addition to any
+	// commands the Server does not emulate.
+	Unsupported []string
+
+	// StringIDs, if true, causes player IDs in payloads to be encoded as JSON
+	// strings rather than numbers.
+	StringIDs bool
+
+	// Prettify, if true, causes the device to send prettified JSON responses
+	// on each connection until prettified responses are disabled with
+	// "system/prettify_json_response".
+	Prettify bool
+}
+
+// Built-in Profiles.
+var (
+	// HEOS1 emulates a single compact speaker on older firmware, which
+	// encodes IDs as strings and does not support quick selects.
+	HEOS1 = Profile{
+		Name: "HEOS 1",
+		Players: []heos.PlayerInfo{{
+			Name:    "Bathroom",
+			PID:     -1000,
+			Model:   "HEOS 1",
+			Version: "1.430.160",
+			IP:      "192.0.2.10",
+			Network: heos.NetworkWiFi,
+			LineOut: heos.LineOutVariable,
+		}},
+		Unsupported: []string{"player/get_quickselects", "player/set_quickselect"},
+		StringIDs:   true,
+	}
+
+	// HEOSDrive emulates a multi-zone amplifier which reports each of its
+	// zones as a player and sends prettified JSON responses by default.
+	HEOSDrive = Profile{
+		Name:        "HEOS Drive",
+		Players:     driveZones(4),
+		Unsupported: []string{"player/get_quickselects", "player/set_quickselect"},
+		Prettify:    true,
+	}
+
+	// DenonAVR emulates an AV receiver with a main zone and a second zone,
+	// which report serial numbers and support quick selects.
+	DenonAVR = Profile{
+		Name: "Denon AVR",
+		Players: []heos.PlayerInfo{
+			{
+				Name:    "Living Room",
+				PID:     2000,
+				Model:   "Denon AVR-X3700H",
+				Version: "3.34.410",
+				IP:      "192.0.2.30",
+				Network: heos.NetworkWired,
+				LineOut: heos.LineOutFixed,
+				Serial:  "BBW00200000",
+			},
+			{
+				Name:    "Living Room Zone2",
+				PID:     2001,
+				Model:   "Denon AVR-X3700H",
+				Version: "3.34.410",
+				IP:      "192.0.2.30",
+				Network: heos.NetworkWired,
+				LineOut: heos.LineOutFixed,
+				Serial:  "BBW00200000",
+			},
+		},
+	}
+)
+
+// driveZones returns n players which are zones of a HEOS Drive.
+func driveZones(n int) []heos.PlayerInfo {
+	ps := make([]heos.PlayerInfo, 0, n)
+	for i := 0; i < n; i++ {
+		ps = append(ps, heos.PlayerInfo{
+			Name:    fmt.Sprintf("Zone %d", i+1),
+			PID:     heos.PlayerID(3000 + i),
+			Model:   "HEOS Drive",
+			Version: "1.520.200",
+			IP:      "192.0.2.20",
+			Network: heos.NetworkWired,
+			LineOut: heos.LineOutVariable,
+		})
+	}
+
+	return ps
+}
+
+// A Server is an emulated HEOS device.
+type Server struct {
+	p Profile
+	l net.Listener
+
+	mu      sync.Mutex
+	players map[heos.PlayerID]*player
+	conns   map[*conn]struct{}
+	closed  bool
+
+	wg sync.WaitGroup
+}
+
+// A player is the state of an emulated player.
+type player struct {
+	level int
+	mute  bool
+	state string
+}
+
+// NewServer starts a Server which emulates the device described by p on an
+// ephemeral port of the loopback interface. Use Addr to dial the Server, and
+// Close to stop it.
+func NewServer(p Profile) (*Server, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		p:       p,
+		l:       l,
+		players: make(map[heos.PlayerID]*player, len(p.Players)),
+		conns:   make(map[*conn]struct{}),
+	}
+	for _, pi := range p.Players {
+		s.players[pi.PID] = &player{level: 20, state: heos.StateStop}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.serve()
+	}()
+
+	return s, nil
+}
+
+// Addr returns the address of the Server, for use with heos.Dial.
+func (s *Server) Addr() string { return s.l.Addr().String() }
+
+// Close stops the Server and closes all of its connections.
+func (s *Server) Close() error {
+	err := s.l.Close()
+
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		_ = c.c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// serve accepts connections until the listener is closed.
+func (s *Server) serve() {
+	for {
+		nc, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &conn{c: nc, prettify: s.p.Prettify}
+		s.mu.Lock()
+		if s.closed {
+			// Close raced with Accept.
+			s.mu.Unlock()
+			_ = nc.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, c)
+				s.mu.Unlock()
+				_ = nc.Close()
+			}()
+
+			s.handle(c)
+		}()
+	}
+}
+
+// A conn is a connection to a Server.
+type conn struct {
+	c net.Conn
+
+	mu         sync.Mutex
+	prettify   bool
+	registered bool
+}
+
+// send writes a frame to c.
+func (c *conn) send(f frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		b   []byte
+		err error
+	)
+	if c.prettify {
+		b, err = json.MarshalIndent(f, "", "    ")
+	} else {
+		b, err = json.Marshal(f)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.c.Write(append(b, "\r\n"...))
+	return err
+}
+
+// A frame is a response or event sent by a Server.
+type frame struct {
+	HEOS    wire.Header `json:"heos"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// handle serves requests from c until the connection is closed.
+func (s *Server) handle(c *conn) {
+	r := bufio.NewReader(c.c)
+	for {
+		req, err := r.ReadString('\n')
+		if err != nil {
+			// The client hung up or the Server is closing.
+			return
+		}
+
+		f, events := s.process(c, strings.TrimSpace(req))
+		if err := c.send(f); err != nil {
+			return
+		}
+
+		s.broadcast(events)
+	}
+}
+
+// broadcast sends events to each connection registered for change events.
+func (s *Server) broadcast(events []wire.Header) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.mu.Lock()
+		registered := c.registered
+		c.mu.Unlock()
+		if !registered {
+			continue
+		}
+
+		for _, e := range events {
+			_ = c.send(frame{HEOS: e})
+		}
+	}
+}
+
+// process processes a request from c, returning its response and any change
+// events it caused.
+func (s *Server) process(c *conn, req string) (frame, []wire.Header) {
+	u, err := url.Parse(strings.TrimPrefix(req, "heos://"))
+	if err != nil {
+		return failure(req, 1, "Unrecognized Command", ""), nil
+	}
+
+	command := u.Path
+	attrs := wire.ParseAttributes(u.RawQuery)
+	for _, cmd := range s.p.Unsupported {
+		if cmd == command {
+			return failure(command, 1, "Unrecognized Command", attrs.Encode()), nil
+		}
+	}
+
+	switch command {
+	case "system/heart_beat":
+		return success(command, "", nil), nil
+	case "system/prettify_json_response", "system/register_for_change_events":
+		on, ok := onOff(attrs["enable"])
+		if !ok {
+			return failure(command, 3, "Invalid Option", attrs.Encode()), nil
+		}
+
+		c.mu.Lock()
+		if command == "system/prettify_json_response" {
+			c.prettify = on
+		} else {
+			c.registered = on
+		}
+		c.mu.Unlock()
+
+		return success(command, attrs.Encode(), nil), nil
+	case "player/get_players":
+		ps := make([]interface{}, 0, len(s.p.Players))
+		for _, pi := range s.p.Players {
+			ps = append(ps, s.playerPayload(pi))
+		}
+
+		return success(command, "", ps), nil
+	}
+
+	if !strings.HasPrefix(command, "player/") {
+		return failure(command, 1, "Unrecognized Command", attrs.Encode()), nil
+	}
+
+	pid, err := strconv.Atoi(attrs["pid"])
+	if err != nil {
+		return failure(command, 2, "ID Not Valid", attrs.Encode()), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.players[heos.PlayerID(pid)]
+	if !ok {
+		return failure(command, 2, "ID Not Valid", attrs.Encode()), nil
+	}
+
+	return s.processPlayer(command, attrs, heos.PlayerID(pid), p)
+}
+
+// processPlayer processes a player command for p. The caller must hold s.mu.
+func (s *Server) processPlayer(command string, attrs wire.Attributes, pid heos.PlayerID, p *player) (frame, []wire.Header) {
+	msg := func(kv ...string) string {
+		a := wire.Attributes{"pid": pid.String()}
+		for i := 0; i < len(kv); i += 2 {
+			a[kv[i]] = kv[i+1]
+		}
+
+		return a.Encode()
+	}
+
+	volumeChanged := wire.Header{Command: "event/player_volume_changed"}
+
+	switch command {
+	case "player/get_player_info":
+		for _, pi := range s.p.Players {
+			if pi.PID == pid {
+				return success(command, msg(), s.playerPayload(pi)), nil
+			}
+		}
+	case "player/get_volume":
+		return success(command, msg("level", strconv.Itoa(p.level)), nil), nil
+	case "player/set_volume":
+		level, err := strconv.Atoi(attrs["level"])
+		if err != nil || level < 0 || level > 100 {
+			return failure(command, 9, "Parameter out of range", attrs.Encode()), nil
+		}
+
+		p.level = level
+		volumeChanged.Message = msg("level", strconv.Itoa(p.level), "mute", offOn(p.mute))
+		return success(command, msg("level", attrs["level"]), nil), []wire.Header{volumeChanged}
+	case "player/get_mute":
+		return success(command, msg("state", offOn(p.mute)), nil), nil
+	case "player/set_mute":
+		mute, ok := onOff(attrs["state"])
+		if !ok {
+			return failure(command, 3, "Invalid Option", attrs.Encode()), nil
+		}
+
+		p.mute = mute
+		volumeChanged.Message = msg("level", strconv.Itoa(p.level), "mute", offOn(p.mute))
+		return success(command, msg("state", attrs["state"]), nil), []wire.Header{volumeChanged}
+	case "player/get_play_state":
+		return success(command, msg("state", p.state), nil), nil
+	case "player/set_play_state":
+		switch state := attrs["state"]; state {
+		case heos.StatePlay, heos.StatePause, heos.StateStop:
+			p.state = state
+			return success(command, msg("state", state), nil), []wire.Header{{
+				Command: "event/player_state_changed",
+				Message: msg("state", state),
+			}}
+		default:
+			return failure(command, 3, "Invalid Option", attrs.Encode()), nil
+		}
+	}
+
+	return failure(command, 1, "Unrecognized Command", attrs.Encode()), nil
+}
+
+// playerPayload returns the payload object for pi, encoding its ID according
+// to the Server's Profile.
+func (s *Server) playerPayload(pi heos.PlayerInfo) interface{} {
+	b, err := json.Marshal(pi)
+	if err != nil {
+		panic(fmt.Sprintf("heostest: failed to encode player: %v", err))
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		panic(fmt.Sprintf("heostest: failed to decode player: %v", err))
+	}
+
+	if s.p.StringIDs {
+		m["pid"] = pi.PID.String()
+		if pi.GID != 0 {
+			m["gid"] = pi.GID.String()
+		}
+	}
+
+	return m
+}
+
+// success creates a successful response frame.
+func success(command, message string, payload interface{}) frame {
+	return frame{
+		HEOS: wire.Header{
+			Command: command,
+			Result:  string(heos.ResultSuccess),
+			Message: message,
+		},
+		Payload: payload,
+	}
+}
+
+// failure creates a failed response frame with the specified error ID and
+// text, followed by the request's attributes, as devices do.
+func failure(command string, eid int, text, attrs string) frame {
+	message := wire.Attributes{"eid": strconv.Itoa(eid), "text": text}.Encode()
+	if attrs != "" {
+		message += "&" + attrs
+	}
+
+	return frame{
+		HEOS: wire.Header{
+			Command: command,
+			Result:  string(heos.ResultFail),
+			Message: message,
+		},
+	}
+}
+
+// onOff parses the "on" and "off" values used in HEOS commands.
+func onOff(s string) (on, ok bool) {
+	switch s {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// offOn formats a boolean as the "on" and "off" values used in HEOS commands.
+func offOn(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
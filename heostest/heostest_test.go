@@ -0,0 +1,114 @@
+package heostest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/heostest"
+)
+
+func TestServerProfiles(t *testing.T) {
+	tests := []struct {
+		p           heostest.Profile
+		quickSelect bool
+	}{
+		{p: heostest.HEOS1},
+		{p: heostest.HEOSDrive},
+		{p: heostest.DenonAVR, quickSelect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.p.Name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			s, err := heostest.NewServer(tt.p)
+			if err != nil {
+				t.Fatalf("failed to start server: %v", err)
+			}
+			defer s.Close()
+
+			// Strict decoding verifies that each profile's quirks are
+			// handled by the Client.
+			c, err := heos.Dial(ctx, s.Addr(), &heos.Config{Strict: true})
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			ps, err := c.Players.GetPlayers(ctx)
+			if err != nil {
+				t.Fatalf("failed to get players: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.p.Players, ps, cmpopts.IgnoreFields(heos.PlayerInfo{}, "Raw")); diff != "" {
+				t.Fatalf("unexpected players (-want +got):\n%s", diff)
+			}
+
+			pid := ps[0].PID
+			if err := c.Players.SetVolume(ctx, pid, 35); err != nil {
+				t.Fatalf("failed to set volume: %v", err)
+			}
+
+			level, err := c.Players.GetVolume(ctx, pid)
+			if err != nil {
+				t.Fatalf("failed to get volume: %v", err)
+			}
+			if diff := cmp.Diff(35, level); diff != "" {
+				t.Fatalf("unexpected volume (-want +got):\n%s", diff)
+			}
+
+			_, err = c.Query(ctx, "player/get_quickselects?pid="+pid.String(), nil)
+			if tt.quickSelect {
+				// Quick selects are not emulated, but the command is not
+				// rejected by the profile.
+				var herr *heos.Error
+				if !errors.As(err, &herr) {
+					t.Fatalf("expected *heos.Error, but got: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, heos.ErrUnsupported) {
+				t.Fatalf("expected heos.ErrUnsupported, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := heostest.NewServer(heostest.DenonAVR)
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	es, err := heos.DialEvents(ctx, s.Addr(), nil)
+	if err != nil {
+		t.Fatalf("failed to dial events: %v", err)
+	}
+	defer es.Close()
+
+	c, err := heos.Dial(ctx, s.Addr(), nil)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	// Changes made on one connection are delivered as events to the others.
+	if err := c.Players.SetMute(ctx, 2001, true); err != nil {
+		t.Fatalf("failed to mute: %v", err)
+	}
+
+	want := &heos.PlayerVolumeChanged{PID: 2001, Level: 20, Mute: true}
+	if diff := cmp.Diff(heos.Event(want), <-es.Events()); diff != "" {
+		t.Fatalf("unexpected event (-want +got):\n%s", diff)
+	}
+}
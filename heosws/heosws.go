@@ -0,0 +1,440 @@
+// Package heosws streams HEOS events to web clients over WebSockets, so that
+// web dashboards can display real-time player state without speaking the HEOS
+// protocol.
+//
+// Each event is sent as a JSON text message containing the event's type name
+// and its fields, such as:
+//
+//	{"type": "PlayerVolumeChanged", "event": {"PID": 1, "Level": 20, "Mute": false}}
+//
+// time.Duration fields are encoded as integer nanoseconds. heosws implements
+// only the subset of RFC 6455 needed to push messages to clients, and messages
+// sent by clients are discarded.
+package heosws
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/heos"
+)
+
+// A Message is the JSON representation of an event sent to a client.
+type Message struct {
+	// Type is the name of the event's type, such as "PlayerVolumeChanged".
+	Type  string     `json:"type"`
+	Event heos.Event `json:"event"`
+}
+
+// Values used during the WebSocket opening handshake and framing.
+const (
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+
+	// maxFrame is the largest client frame which is read and discarded before
+	// the connection is closed.
+	maxFrame = 1 << 16
+
+	// writeTimeout bounds each write to a client.
+	writeTimeout = 10 * time.Second
+)
+
+var _ http.Handler = &Handler{}
+
+// A Handler is an http.Handler which upgrades requests to WebSocket
+// connections and streams the events passed to Run to each connected client.
+type Handler struct {
+	// Buffer is the number of messages buffered for each client. A client
+	// which falls more than Buffer messages behind is disconnected. If zero,
+	// a default of 64 is used.
+	Buffer int
+
+	// CheckOrigin, if not nil, reports whether a WebSocket upgrade request
+	// is permitted based on its Origin header. If nil, requests are rejected
+	// when their Origin header is present and its host does not match the
+	// request's Host header, so that web pages served by other sites cannot
+	// subscribe to events.
+	CheckOrigin func(r *http.Request) bool
+
+	// Logger, if not nil, logs events which cannot be encoded.
+	Logger *slog.Logger
+
+	mu    sync.Mutex
+	conns map[*conn]struct{}
+	done  bool
+}
+
+// Run broadcasts events to all connected clients until ctx is canceled or
+// events is closed, and then disconnects all clients.
+func (h *Handler) Run(ctx context.Context, events <-chan heos.Event) error {
+	defer h.closeAll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if e == nil {
+				continue
+			}
+
+			b, err := json.Marshal(Message{
+				Type:  typeName(e),
+				Event: e,
+			})
+			if err != nil {
+				// Skip the event rather than disconnecting every client.
+				if h.Logger != nil {
+					h.Logger.LogAttrs(ctx, slog.LevelWarn, "failed to encode event",
+						slog.String("type", typeName(e)),
+						slog.Any("error", err),
+					)
+				}
+				continue
+			}
+
+			h.broadcast(b)
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, err := checkUpgrade(r)
+	if err != nil {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	checkOrigin := h.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "heosws: request origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection does not support WebSockets", http.StatusInternalServerError)
+		return
+	}
+
+	nc, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+
+	buffer := h.Buffer
+	if buffer <= 0 {
+		buffer = 64
+	}
+
+	c := &conn{
+		nc:   nc,
+		out:  make(chan []byte, buffer),
+		done: make(chan struct{}),
+	}
+
+	// Register the client before completing the handshake, so that it
+	// receives all events broadcast after the handshake completes.
+	if !h.add(c) {
+		_ = c.close()
+		return
+	}
+	defer h.remove(c)
+
+	_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	go c.read(rw.Reader)
+	c.write()
+}
+
+// add registers c to receive broadcasts, unless Run has returned.
+func (h *Handler) add(c *conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.done {
+		return false
+	}
+	if h.conns == nil {
+		h.conns = make(map[*conn]struct{})
+	}
+	h.conns[c] = struct{}{}
+
+	return true
+}
+
+// remove unregisters and closes c.
+func (h *Handler) remove(c *conn) {
+	h.mu.Lock()
+	delete(h.conns, c)
+	h.mu.Unlock()
+
+	_ = c.close()
+}
+
+// broadcast queues b for all clients, disconnecting any which are too far
+// behind.
+func (h *Handler) broadcast(b []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.conns {
+		select {
+		case c.out <- b:
+		default:
+			_ = c.close()
+		}
+	}
+}
+
+// closeAll disconnects all clients and rejects new ones.
+func (h *Handler) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.done = true
+	for c := range h.conns {
+		_ = c.close()
+	}
+}
+
+// A conn is a WebSocket connection to a client.
+type conn struct {
+	nc  net.Conn
+	out chan []byte
+
+	wmu sync.Mutex
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// write sends queued messages to the client until the connection is closed.
+func (c *conn) write() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case b := <-c.out:
+			if err := c.writeFrame(opText, b); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read consumes frames sent by the client, answering pings and close frames,
+// until the connection is closed.
+func (c *conn) read(r *bufio.Reader) {
+	defer c.close()
+
+	for {
+		op, payload, err := readFrame(r)
+		if err != nil {
+			return
+		}
+
+		switch op {
+		case opClose:
+			// Echo the status code, if any, and hang up.
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			_ = c.writeFrame(opClose, payload)
+			return
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close closes the connection. It is safe to call close more than once.
+func (c *conn) close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.nc.Close()
+	})
+
+	return err
+}
+
+// writeFrame writes a single unmasked, unfragmented frame to the client.
+func (c *conn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_ = c.nc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := c.nc.Write(appendFrame(nil, op, payload))
+	return err
+}
+
+// appendFrame appends a final frame with opcode op and payload to b.
+func appendFrame(b []byte, op byte, payload []byte) []byte {
+	b = append(b, 0x80|op)
+
+	switch n := len(payload); {
+	case n < 126:
+		b = append(b, byte(n))
+	case n <= 0xffff:
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+
+	return append(b, payload...)
+}
+
+// errFrame is returned when a client sends a frame which is not permitted.
+var errFrame = errors.New("heosws: invalid frame")
+
+// readFrame reads a single masked frame from a client, returning its opcode
+// and unmasked payload.
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return 0, nil, err
+	}
+
+	op := h[0] & 0x0f
+	if h[1]&0x80 == 0 {
+		// Clients must mask all frames.
+		return 0, nil, errFrame
+	}
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	if n > maxFrame {
+		return 0, nil, errFrame
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return op, payload, nil
+}
+
+// checkUpgrade verifies that r is a valid WebSocket opening handshake, and
+// returns its key.
+func checkUpgrade(r *http.Request) (string, error) {
+	switch {
+	case r.Method != http.MethodGet:
+		return "", errors.New("heosws: WebSocket upgrade requires GET")
+	case !hasToken(r.Header, "Connection", "upgrade"), !hasToken(r.Header, "Upgrade", "websocket"):
+		return "", errors.New("heosws: request is not a WebSocket upgrade")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		return "", errors.New("heosws: unsupported WebSocket version")
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return "", errors.New("heosws: invalid WebSocket key")
+	}
+
+	return key, nil
+}
+
+// sameOrigin reports whether r has no Origin header, or an Origin header
+// whose host matches r's Host header.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// typeName returns the name of e's type without its package or pointer
+// prefix, such as "PlayerVolumeChanged".
+func typeName(e heos.Event) string {
+	s := fmt.Sprintf("%T", e)
+	if i := strings.LastIndexByte(s, '.'); i != -1 {
+		s = s[i+1:]
+	}
+
+	return strings.TrimPrefix(s, "*")
+}
+
+// hasToken reports whether the comma-separated header name contains token.
+func hasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// accept computes the Sec-WebSocket-Accept value for key.
+func accept(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
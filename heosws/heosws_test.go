@@ -0,0 +1,248 @@
+package heosws_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+	"github.com/mdlayher/heos/heosws"
+)
+
+func TestHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var h heosws.Handler
+	srv := httptest.NewServer(&h)
+	defer srv.Close()
+
+	events := make(chan heos.Event)
+	runC := make(chan error, 1)
+	go func() { runC <- h.Run(ctx, events) }()
+
+	conn, r := dial(t, srv.URL, "")
+	defer conn.Close()
+
+	// Events which cannot be encoded are skipped without disconnecting the
+	// client.
+	events <- nil
+	events <- badEvent{C: make(chan int)}
+	events <- &heos.PlayerVolumeChanged{PID: 1, Level: 20}
+	events <- &heos.GroupsChanged{}
+
+	want := []heosws.Message{
+		{Type: "PlayerVolumeChanged", Event: &heos.PlayerVolumeChanged{PID: 1, Level: 20}},
+		{Type: "GroupsChanged", Event: &heos.GroupsChanged{}},
+	}
+
+	for _, w := range want {
+		op, b := readFrame(t, r)
+		if op != 0x1 {
+			t.Fatalf("unexpected opcode: %#x", op)
+		}
+
+		wb, err := json.Marshal(w)
+		if err != nil {
+			t.Fatalf("failed to marshal message: %v", err)
+		}
+
+		if diff := cmp.Diff(string(wb), string(b)); diff != "" {
+			t.Fatalf("unexpected message (-want +got):\n%s", diff)
+		}
+	}
+
+	// Pings must be answered, and close frames echoed.
+	writeFrame(t, conn, 0x9, []byte("hi"))
+	if op, b := readFrame(t, r); op != 0xa || string(b) != "hi" {
+		t.Fatalf("unexpected pong: %#x %q", op, b)
+	}
+
+	writeFrame(t, conn, 0x8, []byte{0x03, 0xe8})
+	if op, _ := readFrame(t, r); op != 0x8 {
+		t.Fatalf("unexpected close opcode: %#x", op)
+	}
+
+	close(events)
+	if err := <-runC; err != nil {
+		t.Fatalf("failed to run handler: %v", err)
+	}
+}
+
+func TestHandlerNotUpgrade(t *testing.T) {
+	srv := httptest.NewServer(&heosws.Handler{})
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to perform request: %v", err)
+	}
+	defer res.Body.Close()
+
+	if diff := cmp.Diff(http.StatusBadRequest, res.StatusCode); diff != "" {
+		t.Fatalf("unexpected status code (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandlerOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  func(r *http.Request) bool
+		origin string
+		ok     bool
+	}{
+		{
+			name: "no origin",
+			ok:   true,
+		},
+		{
+			name:   "same origin",
+			origin: "<server>",
+			ok:     true,
+		},
+		{
+			name:   "cross origin",
+			origin: "http://example.com",
+		},
+		{
+			name:   "cross origin allowed",
+			check:  func(r *http.Request) bool { return r.Header.Get("Origin") == "http://example.com" },
+			origin: "http://example.com",
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&heosws.Handler{CheckOrigin: tt.check})
+			defer srv.Close()
+
+			origin := tt.origin
+			if origin == "<server>" {
+				origin = srv.URL
+			}
+
+			if tt.ok {
+				conn, _ := dial(t, srv.URL, origin)
+				_ = conn.Close()
+				return
+			}
+
+			req, err := upgradeRequest(srv.URL, origin)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to perform request: %v", err)
+			}
+			defer res.Body.Close()
+
+			if diff := cmp.Diff(http.StatusForbidden, res.StatusCode); diff != "" {
+				t.Fatalf("unexpected status code (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// A badEvent is a heos.Event which cannot be encoded as JSON.
+type badEvent struct {
+	heos.Event
+	C chan int
+}
+
+// upgradeRequest creates a WebSocket opening handshake request for rawURL,
+// using the example key from RFC 6455 and an optional Origin header.
+func upgradeRequest(rawURL, origin string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+
+	return req, nil
+}
+
+// dial performs a WebSocket opening handshake with the server at rawURL.
+func dial(t *testing.T, rawURL, origin string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(rawURL, "http://"))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+
+	req, err := upgradeRequest(rawURL, origin)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	if diff := cmp.Diff(http.StatusSwitchingProtocols, res.StatusCode); diff != "" {
+		t.Fatalf("unexpected status code (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept")); diff != "" {
+		t.Fatalf("unexpected accept key (-want +got):\n%s", diff)
+	}
+
+	return conn, r
+}
+
+// readFrame reads a single short, unmasked frame sent by the server.
+func readFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	t.Helper()
+
+	var h [2]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		t.Fatalf("failed to read frame header: %v", err)
+	}
+	if h[0]&0x80 == 0 || h[1]&0x80 != 0 || h[1] >= 126 {
+		t.Fatalf("unexpected frame header: %#x", h)
+	}
+
+	b := make([]byte, h[1])
+	if _, err := io.ReadFull(r, b); err != nil {
+		t.Fatalf("failed to read frame payload: %v", err)
+	}
+
+	return h[0] & 0x0f, b
+}
+
+// writeFrame writes a single short, masked frame to the server.
+func writeFrame(t *testing.T, w io.Writer, op byte, payload []byte) {
+	t.Helper()
+
+	mask := [4]byte{0x01, 0x02, 0x03, 0x04}
+	b := append([]byte{0x80 | op, 0x80 | byte(len(payload))}, mask[:]...)
+	for i, c := range payload {
+		b = append(b, c^mask[i%4])
+	}
+
+	if _, err := w.Write(b); err != nil {
+		t.Fatalf("failed to write frame: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package heos
+
+import "sync"
+
+// Recent returns up to the most recent Config.EventHistory events delivered
+// by the EventStream, oldest first, so that a consumer which starts late or
+// briefly stops receiving can rebuild its state without querying the device.
+// Reconnected events are included, and indicate that events may have been
+// lost at that point in the history. Recent returns nil if Config.EventHistory
+// is zero. The returned events must not be modified.
+func (es *EventStream) Recent() []Event {
+	return es.history.events()
+}
+
+// A history is a fixed size ring buffer of recent events. A nil history
+// records nothing.
+type history struct {
+	mu   sync.Mutex
+	buf  []Event
+	next int
+	full bool
+}
+
+// newHistory creates a history which retains up to size events, or returns
+// nil if size is not positive.
+func newHistory(size int) *history {
+	if size <= 0 {
+		return nil
+	}
+
+	return &history{buf: make([]Event, size)}
+}
+
+// add records e, replacing the oldest event if the history is full.
+func (h *history) add(e Event) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf[h.next] = e
+	h.next = (h.next + 1) % len(h.buf)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// events returns the recorded events, oldest first.
+func (h *history) events() []Event {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]Event(nil), h.buf[:h.next]...)
+	}
+
+	es := make([]Event, 0, len(h.buf))
+	es = append(es, h.buf[h.next:]...)
+	return append(es, h.buf[:h.next]...)
+}
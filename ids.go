@@ -0,0 +1,65 @@
+package heos
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// A PlayerID identifies a HEOS player.
+type PlayerID int
+
+// A GroupID identifies a HEOS group. A group's ID is the PlayerID of its
+// leader.
+type GroupID int
+
+// A SourceID identifies a music source.
+type SourceID int
+
+// A QueueID identifies an item in a player's queue.
+type QueueID int
+
+// String returns the decimal form of an ID, as used in command attributes.
+func (id PlayerID) String() string { return strconv.Itoa(int(id)) }
+
+// String returns the decimal form of an ID, as used in command attributes.
+func (id GroupID) String() string { return strconv.Itoa(int(id)) }
+
+// String returns the decimal form of an ID, as used in command attributes.
+func (id SourceID) String() string { return strconv.Itoa(int(id)) }
+
+// String returns the decimal form of an ID, as used in command attributes.
+func (id QueueID) String() string { return strconv.Itoa(int(id)) }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *PlayerID) UnmarshalJSON(b []byte) error { return unmarshalID((*int)(id), "player", b) }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *GroupID) UnmarshalJSON(b []byte) error { return unmarshalID((*int)(id), "group", b) }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *SourceID) UnmarshalJSON(b []byte) error { return unmarshalID((*int)(id), "source", b) }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *QueueID) UnmarshalJSON(b []byte) error { return unmarshalID((*int)(id), "queue", b) }
+
+// unmarshalID decodes an ID of kind from b into id. Depending on the command
+// and firmware version, devices encode IDs as JSON numbers or as strings
+// containing numbers, so both are accepted. Empty strings and null decode as
+// zero.
+func unmarshalID(id *int, kind string, b []byte) error {
+	b = bytes.Trim(b, `"`)
+	switch string(b) {
+	case "", "null":
+		*id = 0
+		return nil
+	}
+
+	v, err := strconv.Atoi(string(b))
+	if err != nil {
+		return fmt.Errorf("heos: invalid %s ID %s", kind, b)
+	}
+
+	*id = v
+	return nil
+}
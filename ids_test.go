@@ -0,0 +1,107 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestIDsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want heos.GroupPlayer
+		ok   bool
+	}{
+		{
+			name: "number",
+			in:   `{"pid": -1234}`,
+			want: heos.GroupPlayer{PID: -1234},
+			ok:   true,
+		},
+		{
+			name: "string",
+			in:   `{"pid": "-1234"}`,
+			want: heos.GroupPlayer{PID: -1234},
+			ok:   true,
+		},
+		{
+			name: "empty",
+			in:   `{"pid": ""}`,
+			ok:   true,
+		},
+		{
+			name: "null",
+			in:   `{"pid": null}`,
+			ok:   true,
+		},
+		{
+			name: "invalid",
+			in:   `{"pid": "foo"}`,
+		},
+		{
+			name: "float",
+			in:   `{"pid": 1.5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got heos.GroupPlayer
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected player (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClientMixedIDs(t *testing.T) {
+	// Devices running different firmware versions encode IDs differently, even
+	// within a single response.
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		return response("player/get_now_playing_media", "pid=1", json.RawMessage(`{
+			"type": "song",
+			"song": "Blue in Green",
+			"sid": "1024",
+			"qid": 3
+		}`))
+	})
+	defer done()
+
+	npm, err := c.Players.GetNowPlayingMedia(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get now playing media: %v", err)
+	}
+
+	want := &heos.NowPlayingMedia{
+		Type: "song",
+		Song: "Blue in Green",
+		SID:  1024,
+		QID:  3,
+	}
+	if diff := cmp.Diff(want, npm, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected now playing media (-want +got):\n%s", diff)
+	}
+
+	// IDs are always encoded as numbers.
+	b, err := json.Marshal(heos.GroupPlayer{PID: 1})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if diff := cmp.Diff(`{"name":"","pid":1,"role":""}`, string(b)); diff != "" {
+		t.Fatalf("unexpected JSON (-want +got):\n%s", diff)
+	}
+}
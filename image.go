@@ -0,0 +1,115 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxImageSize is the maximum size of an image fetched by an ImageFetcher.
+const maxImageSize = 16 << 20
+
+// An Image is an image such as album art, referenced by the ImageURL field of
+// NowPlayingMedia, MediaItem, and similar types.
+type Image struct {
+	// ContentType is the MIME type reported by the server, such as
+	// "image/jpeg".
+	ContentType string
+	Data        []byte
+}
+
+// An ImageFetcher fetches images over HTTP, optionally caching them by URL.
+// The zero value is a valid ImageFetcher which uses http.DefaultClient and
+// does not cache images. An ImageFetcher is safe for concurrent use.
+type ImageFetcher struct {
+	// HTTPClient, if not nil, is used to fetch images.
+	HTTPClient *http.Client
+
+	// CacheSize, if non-zero, is the maximum number of images to cache. When
+	// the cache is full, the oldest image is evicted.
+	CacheSize int
+
+	mu    sync.Mutex
+	cache map[string]*Image
+	order []string
+}
+
+// Fetch fetches the image at url. The context is used for cancelation and to
+// set timeouts. The returned Image may be shared with other callers and must
+// not be modified.
+func (f *ImageFetcher) Fetch(ctx context.Context, url string) (*Image, error) {
+	if url == "" {
+		return nil, errors.New("heos: no image URL")
+	}
+
+	f.mu.Lock()
+	img, ok := f.cache[url]
+	f.mu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := f.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("heos: failed to fetch image %q: HTTP %d", url, res.StatusCode)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxImageSize {
+		return nil, fmt.Errorf("heos: image %q exceeds maximum size of %d bytes", url, maxImageSize)
+	}
+
+	img = &Image{
+		ContentType: res.Header.Get("Content-Type"),
+		Data:        b,
+	}
+
+	f.store(url, img)
+	return img, nil
+}
+
+// store adds img to the cache, if enabled.
+func (f *ImageFetcher) store(url string, img *Image) {
+	if f.CacheSize <= 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cache == nil {
+		f.cache = make(map[string]*Image)
+	}
+	if _, ok := f.cache[url]; ok {
+		return
+	}
+
+	for len(f.order) >= f.CacheSize {
+		delete(f.cache, f.order[0])
+		f.order = f.order[1:]
+	}
+
+	f.cache[url] = img
+	f.order = append(f.order, url)
+}
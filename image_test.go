@@ -0,0 +1,52 @@
+package heos_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestImageFetcher(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		if r.URL.Path != "/art.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("jpeg"))
+	}))
+	defer srv.Close()
+
+	f := &heos.ImageFetcher{CacheSize: 1}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		img, err := f.Fetch(ctx, srv.URL+"/art.jpg")
+		if err != nil {
+			t.Fatalf("failed to fetch image: %v", err)
+		}
+
+		want := &heos.Image{ContentType: "image/jpeg", Data: []byte("jpeg")}
+		if diff := cmp.Diff(want, img); diff != "" {
+			t.Fatalf("unexpected image (-want +got):\n%s", diff)
+		}
+	}
+
+	// The second fetch must be served from the cache.
+	if diff := cmp.Diff(int32(1), atomic.LoadInt32(&hits)); diff != "" {
+		t.Fatalf("unexpected number of HTTP requests (-want +got):\n%s", diff)
+	}
+
+	if _, err := f.Fetch(ctx, srv.URL+"/missing.jpg"); err == nil {
+		t.Fatal("expected an error for missing image, but none occurred")
+	}
+}
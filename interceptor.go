@@ -0,0 +1,37 @@
+package heos
+
+import "context"
+
+// A Querier issues HEOS queries. *Client and *Pool implement Querier.
+type Querier interface {
+	Query(ctx context.Context, query string, out interface{}) (*Command, error)
+}
+
+// QuerierFunc adapts a function to the Querier interface.
+type QuerierFunc func(ctx context.Context, query string, out interface{}) (*Command, error)
+
+// Query implements Querier.
+func (fn QuerierFunc) Query(ctx context.Context, query string, out interface{}) (*Command, error) {
+	return fn(ctx, query, out)
+}
+
+// An Interceptor wraps the Querier used by a Client to issue queries, so
+// that cross-cutting concerns such as logging, metrics, retries, or command
+// rewriting can be composed without modifying the Client. An Interceptor
+// returns a Querier which typically invokes next, possibly with a modified
+// query, and may inspect or replace the result.
+//
+// The out argument may be a value internal to this package, such as when a
+// payload is streamed by Browse.BrowseEach, so Interceptors must pass out to
+// next unmodified.
+type Interceptor func(next Querier) Querier
+
+// chain wraps q with interceptors, such that the first interceptor is the
+// outermost.
+func chain(q Querier, interceptors []Interceptor) Querier {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		q = interceptors[i](q)
+	}
+
+	return q
+}
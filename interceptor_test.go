@@ -0,0 +1,82 @@
+package heos_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+var (
+	_ heos.Querier = &heos.Client{}
+	_ heos.Querier = &heos.Pool{}
+)
+
+func TestClientInterceptors(t *testing.T) {
+	var calls []string
+	record := func(name string) heos.Interceptor {
+		return func(next heos.Querier) heos.Querier {
+			return heos.QuerierFunc(func(ctx context.Context, query string, out interface{}) (*heos.Command, error) {
+				calls = append(calls, name+": "+query)
+				return next.Query(ctx, query, out)
+			})
+		}
+	}
+
+	// Redirect all commands for player 1 to player 2.
+	rewrite := func(next heos.Querier) heos.Querier {
+		return heos.QuerierFunc(func(ctx context.Context, query string, out interface{}) (*heos.Command, error) {
+			return next.Query(ctx, strings.Replace(query, "pid=1", "pid=2", 1), out)
+		})
+	}
+
+	errBlocked := errors.New("blocked")
+	block := func(next heos.Querier) heos.Querier {
+		return heos.QuerierFunc(func(ctx context.Context, query string, out interface{}) (*heos.Command, error) {
+			if strings.HasPrefix(query, "system/reboot") {
+				return nil, errBlocked
+			}
+
+			return next.Query(ctx, query, out)
+		})
+	}
+
+	cfg := &heos.Config{
+		Interceptors: []heos.Interceptor{record("outer"), rewrite, block, record("inner")},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		if diff := cmp.Diff("heos://player/get_volume?pid=2\r\n", req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		return response("player/get_volume", "pid=2&level=20", nil)
+	})
+	defer done()
+
+	level, err := c.Players.GetVolume(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+	if diff := cmp.Diff(20, level); diff != "" {
+		t.Fatalf("unexpected volume (-want +got):\n%s", diff)
+	}
+
+	if err := c.System.Reboot(ctx); !errors.Is(err, errBlocked) {
+		t.Fatalf("expected blocked error, but got: %v", err)
+	}
+
+	// The handshake is not intercepted, and the blocked command never
+	// reaches the innermost interceptor.
+	want := []string{
+		"outer: player/get_volume?pid=1",
+		"inner: player/get_volume?pid=2",
+		"outer: system/reboot",
+	}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Fatalf("unexpected interceptor calls (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,261 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// A Navigator resolves human-readable browse paths such as
+// "TuneIn/Favorites/Jazz FM" or "Playlists/Dinner" by walking the browse
+// hierarchy of a device, so that programs need not hard-code container IDs,
+// which may change between sessions. The first element of a path names a
+// music source, and each following element names an item within the
+// previous container. Names are matched ignoring case. Names which contain
+// '/' cannot be resolved.
+//
+// A Navigator caches the container IDs it resolves, so that resolving paths
+// which share a prefix does not browse the prefix again. If a device rejects
+// a cached container, the cache is cleared and the path is resolved again. A
+// Navigator is safe for concurrent use.
+type Navigator struct {
+	c *Client
+
+	mu    sync.Mutex
+	cache map[string]location
+}
+
+// A location identifies a container by its source and container IDs.
+type location struct {
+	sid SourceID
+	cid string
+}
+
+// A PathItem is an item located by a Navigator.
+type PathItem struct {
+	// SID and CID identify the container in which the item was found, as
+	// used by Browse.PlayStream. For a path which names only a music source,
+	// CID is empty.
+	SID SourceID
+	CID string
+
+	// Item is the item found. For a path which names only a music source,
+	// Item describes the source.
+	Item MediaItem
+}
+
+// NewNavigator creates a Navigator which uses c to browse.
+func NewNavigator(c *Client) *Navigator {
+	return &Navigator{
+		c:     c,
+		cache: make(map[string]location),
+	}
+}
+
+// Resolve locates the item specified by path.
+func (n *Navigator) Resolve(ctx context.Context, path string) (*PathItem, error) {
+	names, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 1 {
+		ms, err := n.source(ctx, names[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return &PathItem{
+			SID: ms.SID,
+			Item: MediaItem{
+				Container: true,
+				Type:      ms.Type,
+				Name:      ms.Name,
+				ImageURL:  ms.ImageURL,
+				SID:       ms.SID,
+			},
+		}, nil
+	}
+
+	var pi *PathItem
+	err = n.retry(func() error {
+		loc, err := n.container(ctx, names[:len(names)-1])
+		if err != nil {
+			return err
+		}
+
+		mi, err := n.find(ctx, loc, names[len(names)-1])
+		if err != nil {
+			return err
+		}
+
+		pi = &PathItem{SID: loc.sid, CID: loc.cid, Item: *mi}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pi, nil
+}
+
+// Browse returns the items in the container specified by path.
+func (n *Navigator) Browse(ctx context.Context, path string) ([]MediaItem, error) {
+	names, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var mis []MediaItem
+	err = n.retry(func() error {
+		loc, err := n.container(ctx, names)
+		if err != nil {
+			return err
+		}
+
+		mis, err = n.c.Browse.BrowseAll(ctx, loc.sid, loc.cid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mis, nil
+}
+
+// Reset clears the Navigator's cache of container IDs.
+func (n *Navigator) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.cache = make(map[string]location)
+}
+
+// retry invokes fn, and invokes it again with an empty cache if fn fails
+// because the device rejected a cached container.
+func (n *Navigator) retry(fn func() error) error {
+	err := fn()
+
+	var herr *Error
+	if !errors.As(err, &herr) {
+		return err
+	}
+
+	n.mu.Lock()
+	cached := len(n.cache) > 0
+	n.mu.Unlock()
+	if !cached {
+		return err
+	}
+
+	n.Reset()
+	return fn()
+}
+
+// container resolves the container specified by names, beginning with the
+// longest prefix of names which is cached.
+func (n *Navigator) container(ctx context.Context, names []string) (location, error) {
+	var (
+		loc location
+		i   int
+	)
+
+	n.mu.Lock()
+	for i = len(names); i > 0; i-- {
+		if l, ok := n.cache[pathKey(names[:i])]; ok {
+			loc = l
+			break
+		}
+	}
+	n.mu.Unlock()
+
+	if i == 0 {
+		ms, err := n.source(ctx, names[0])
+		if err != nil {
+			return location{}, err
+		}
+
+		loc = location{sid: ms.SID}
+		n.store(names[:1], loc)
+		i = 1
+	}
+
+	for ; i < len(names); i++ {
+		mi, err := n.find(ctx, loc, names[i])
+		if err != nil {
+			return location{}, err
+		}
+		if !mi.Container {
+			return location{}, fmt.Errorf("heos: browse path %q: %q is not a container",
+				strings.Join(names, "/"), mi.Name)
+		}
+
+		// Nested sources, such as local media servers, are browsed by their
+		// own source ID.
+		if mi.SID != 0 {
+			loc = location{sid: mi.SID}
+		} else {
+			loc = location{sid: loc.sid, cid: mi.CID}
+		}
+		n.store(names[:i+1], loc)
+	}
+
+	return loc, nil
+}
+
+// source locates the music source specified by name.
+func (n *Navigator) source(ctx context.Context, name string) (*MusicSource, error) {
+	s, err := n.c.Browse.FindSource(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := s.Info()
+	return &ms, nil
+}
+
+// find locates the item specified by name within the container at loc.
+func (n *Navigator) find(ctx context.Context, loc location, name string) (*MediaItem, error) {
+	mis, err := n.c.Browse.BrowseAll(ctx, loc.sid, loc.cid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mi := range mis {
+		if strings.EqualFold(mi.Name, name) {
+			return &mi, nil
+		}
+	}
+
+	return nil, fmt.Errorf("heos: browse item %q not found", name)
+}
+
+// store caches loc as the location of the container specified by names.
+func (n *Navigator) store(names []string, loc location) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.cache[pathKey(names)] = loc
+}
+
+// splitPath splits a browse path into its names, ignoring empty names.
+func splitPath(path string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("heos: invalid browse path %q", path)
+	}
+
+	return names, nil
+}
+
+// pathKey returns the cache key for the container specified by names.
+func pathKey(names []string) string {
+	return strings.ToLower(strings.Join(names, "/"))
+}
@@ -0,0 +1,121 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestNavigator(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		reqs  []string
+		stale bool
+	)
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, strings.TrimSpace(req))
+
+		// The Favorites container ID changes once the cache becomes stale.
+		fav := "fav"
+		if stale {
+			fav = "fav2"
+		}
+
+		switch req {
+		case "heos://browse/get_music_sources\r\n":
+			return response("browse/get_music_sources", "", []map[string]interface{}{
+				{"name": "Spotify", "type": "music_service", "sid": 4},
+				{"name": "TuneIn", "type": "music_service", "sid": 3},
+			})
+		case "heos://browse/browse?sid=3&range=0,99\r\n":
+			return response("browse/browse", "sid=3&returned=2&count=2", []map[string]string{
+				{"container": "yes", "type": "container", "name": "Favorites", "cid": fav},
+				{"container": "no", "playable": "yes", "type": "station", "name": "News", "mid": "s0"},
+			})
+		case "heos://browse/browse?sid=3&cid=" + fav + "&range=0,99\r\n":
+			return response("browse/browse", "sid=3&returned=1&count=1", []map[string]string{
+				{"container": "no", "playable": "yes", "type": "station", "name": "Jazz FM", "mid": "s1"},
+			})
+		case "heos://browse/browse?sid=3&cid=fav&range=0,99\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/browse", "result": "fail", "message": "eid=2&text=ID Not Valid"}}`)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	n := heos.NewNavigator(c)
+
+	pi, err := n.Resolve(ctx, "tunein/favorites/JAZZ FM")
+	if err != nil {
+		t.Fatalf("failed to resolve path: %v", err)
+	}
+
+	want := &heos.PathItem{
+		SID: 3,
+		CID: "fav",
+		Item: heos.MediaItem{
+			Playable: true,
+			Type:     "station",
+			Name:     "Jazz FM",
+			MID:      "s1",
+		},
+	}
+	if diff := cmp.Diff(want, pi, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected item (-want +got):\n%s", diff)
+	}
+
+	// The container IDs are cached, so only the final container is browsed.
+	if _, err := n.Browse(ctx, "TuneIn/Favorites"); err != nil {
+		t.Fatalf("failed to browse path: %v", err)
+	}
+
+	// Items which are not containers cannot be browsed.
+	if _, err := n.Browse(ctx, "TuneIn/News"); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	// Once the cached container is rejected, the path is resolved again.
+	mu.Lock()
+	stale = true
+	mu.Unlock()
+
+	pi, err = n.Resolve(ctx, "TuneIn/Favorites/Jazz FM")
+	if err != nil {
+		t.Fatalf("failed to resolve stale path: %v", err)
+	}
+	if diff := cmp.Diff("fav2", pi.CID); diff != "" {
+		t.Fatalf("unexpected container ID (-want +got):\n%s", diff)
+	}
+
+	wantReqs := []string{
+		// First Resolve.
+		"heos://browse/get_music_sources",
+		"heos://browse/browse?sid=3&range=0,99",
+		"heos://browse/browse?sid=3&cid=fav&range=0,99",
+		// Browse.
+		"heos://browse/browse?sid=3&cid=fav&range=0,99",
+		// Browse of a non-container.
+		"heos://browse/browse?sid=3&range=0,99",
+		// Stale Resolve.
+		"heos://browse/browse?sid=3&cid=fav&range=0,99",
+		"heos://browse/get_music_sources",
+		"heos://browse/browse?sid=3&range=0,99",
+		"heos://browse/browse?sid=3&cid=fav2&range=0,99",
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if diff := cmp.Diff(wantReqs, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,105 @@
+// Package netctx implements context cancelation and deadlines for I/O on
+// connections which support deadlines, such as a net.Conn.
+//
+// The standard library's connections accept deadlines but not contexts. Do
+// bridges the two by applying a context's deadline to a connection and by
+// moving the connection's deadline into the past when the context is
+// canceled, which interrupts any blocked reads or writes without closing the
+// connection.
+package netctx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// A Conn is a connection whose I/O can be interrupted by deadlines, such as a
+// net.Conn.
+type Conn interface {
+	SetDeadline(t time.Time) error
+}
+
+// deadlineNow is a time far in the past which can trigger immediate connection
+// cancelation.
+var deadlineNow = time.Unix(1, 0)
+
+// Do invokes fn, which performs I/O on c, with the cancelation and deadline of
+// ctx applied to c.
+//
+// If ctx is canceled while fn is running, blocked I/O on c returns
+// immediately and Do returns the context's error rather than the I/O error
+// observed by fn. Likewise, if I/O times out because the context's deadline
+// passed, Do returns context.DeadlineExceeded. Otherwise, Do returns fn's
+// error.
+//
+// When fn returns, c's deadline is cleared, so that neither the context's
+// deadline nor its cancelation affects later I/O on c. Because c has a single
+// deadline, calls to Do for the same Conn must not run concurrently; callers
+// which share a Conn must serialize their calls, such as with a mutex. Calls
+// for different Conns may run concurrently.
+func Do(ctx context.Context, c Conn, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Enable immediate connection cancelation via context by using the context's
+	// deadline and also setting a deadline in the past if/when the context is
+	// canceled. This pattern courtesy of @acln from #networking on Gophers Slack.
+	dl, _ := ctx.Deadline()
+	if err := c.SetDeadline(dl); err != nil {
+		return err
+	}
+
+	if ctx.Done() == nil {
+		// The context can never be canceled and has no deadline, so there is
+		// nothing to restore.
+		return fn()
+	}
+
+	// Rather than starting a goroutine per call to watch the context, arrange
+	// for cancelation to interrupt fn by moving the deadline into the past.
+	canceled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(canceled)
+		_ = c.SetDeadline(deadlineNow)
+	})
+
+	err := fn()
+	if !stop() {
+		// Wait for the cancelation deadline to be set so it cannot interfere
+		// with a later call.
+		<-canceled
+	}
+
+	// Restore the connection to having no deadline. fn may have closed c, in
+	// which case there is nothing to restore.
+	_ = c.SetDeadline(time.Time{})
+
+	if err == nil {
+		return nil
+	}
+
+	// The connection deadline may fire before the context's Done channel is
+	// closed; report the context error regardless.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if timeout(err) && !dl.IsZero() && !time.Now().Before(dl) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+// timeout reports whether err indicates an I/O timeout.
+func timeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
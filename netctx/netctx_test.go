@@ -0,0 +1,175 @@
+package netctx_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos/netctx"
+)
+
+func TestDoCanceled(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	// Nothing is written to c2, so the read blocks until canceled.
+	err := netctx.Do(ctx, c1, func() error {
+		_, err := c1.Read(make([]byte, 1))
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, but got: %v", err)
+	}
+
+	// The cancelation deadline must not affect later I/O.
+	testReadWrite(t, context.Background(), c1, c2)
+}
+
+func TestDoDeadlineExceeded(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := netctx.Do(ctx, c1, func() error {
+		_, err := c1.Read(make([]byte, 1))
+		return err
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	// The context's deadline must not affect later I/O.
+	testReadWrite(t, context.Background(), c1, c2)
+}
+
+func TestDoContextDone(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := netctx.Do(ctx, c1, func() error {
+		panic("fn must not be called with a canceled context")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, but got: %v", err)
+	}
+}
+
+func TestDoError(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Errors unrelated to the context are returned as-is.
+	errFoo := errors.New("foo")
+	err := netctx.Do(ctx, c1, func() error { return errFoo })
+	if !errors.Is(err, errFoo) {
+		t.Fatalf("expected foo error, but got: %v", err)
+	}
+
+	// Errors setting the deadline are also returned, such as when the Conn is
+	// closed.
+	_ = c1.Close()
+	err = netctx.Do(ctx, c1, func() error {
+		panic("fn must not be called when the deadline cannot be set")
+	})
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("expected closed pipe, but got: %v", err)
+	}
+}
+
+func TestDoConcurrent(t *testing.T) {
+	const (
+		conns = 8
+		calls = 50
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(conns)
+
+	for i := 0; i < conns; i++ {
+		go func() {
+			defer wg.Done()
+
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			defer c2.Close()
+
+			// Calls for a shared Conn are serialized by a mutex, while calls
+			// for different Conns proceed concurrently. Canceled calls must
+			// not disturb the calls which follow them.
+			var mu sync.Mutex
+			var cwg sync.WaitGroup
+			cwg.Add(calls)
+
+			for j := 0; j < calls; j++ {
+				go func(j int) {
+					defer cwg.Done()
+
+					mu.Lock()
+					defer mu.Unlock()
+
+					if j%2 == 0 {
+						ctx, cancel := context.WithCancel(context.Background())
+						time.AfterFunc(time.Millisecond, cancel)
+
+						err := netctx.Do(ctx, c1, func() error {
+							_, err := c1.Read(make([]byte, 1))
+							return err
+						})
+						if !errors.Is(err, context.Canceled) {
+							panic("expected context canceled, but got: " + err.Error())
+						}
+						return
+					}
+
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					testReadWrite(t, ctx, c1, c2)
+				}(j)
+			}
+
+			cwg.Wait()
+		}()
+	}
+
+	wg.Wait()
+}
+
+// testReadWrite verifies that a byte written to c2 can be read from c1 using
+// netctx.Do.
+func testReadWrite(t *testing.T, ctx context.Context, c1, c2 net.Conn) {
+	go func() { _, _ = c2.Write([]byte{'x'}) }()
+
+	b := make([]byte, 1)
+	err := netctx.Do(ctx, c1, func() error {
+		_, err := io.ReadFull(c1, b)
+		return err
+	})
+	if err != nil {
+		t.Errorf("failed to read: %v", err)
+		return
+	}
+
+	if diff := cmp.Diff("x", string(b)); diff != "" {
+		t.Errorf("unexpected data (-want +got):\n%s", diff)
+	}
+}
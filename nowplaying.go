@@ -0,0 +1,85 @@
+package heos
+
+// A MediaKind describes the kind of media playing on a player.
+type MediaKind int
+
+// Possible MediaKind values.
+const (
+	KindUnknown MediaKind = iota
+	KindSong
+	KindStation
+)
+
+// String returns the string representation of a MediaKind.
+func (k MediaKind) String() string {
+	switch k {
+	case KindSong:
+		return "song"
+	case KindStation:
+		return "station"
+	default:
+		return "unknown"
+	}
+}
+
+// NowPlaying is a normalized view of NowPlayingMedia. The fields of
+// NowPlayingMedia have different meanings depending on the type of media and
+// the music service in use; NowPlaying exposes them consistently so that
+// callers need not special-case each type.
+type NowPlaying struct {
+	// Kind is the kind of media which is playing.
+	Kind MediaKind
+
+	// Title is the title of the current song. For stations which do not
+	// report the current song, Title is the station name.
+	Title  string
+	Artist string
+	Album  string
+
+	// Station is the name of the station which is playing, or empty for
+	// songs.
+	Station string
+
+	ImageURL string
+
+	// SourceID is the ID of the music source playing the media, and MediaID
+	// is the ID of the media within that source.
+	SourceID SourceID
+	MediaID  string
+
+	// QueueID is the ID of the song in the player's queue, or zero for
+	// stations, which are not played from the queue.
+	QueueID QueueID
+}
+
+// Normalize returns a normalized view of the NowPlayingMedia.
+func (npm *NowPlayingMedia) Normalize() *NowPlaying {
+	np := &NowPlaying{
+		Title:    npm.Song,
+		Artist:   npm.Artist,
+		Album:    npm.Album,
+		ImageURL: npm.ImageURL,
+		SourceID: npm.SID,
+		MediaID:  npm.MID,
+	}
+
+	switch npm.Type {
+	case "song":
+		np.Kind = KindSong
+		np.QueueID = npm.QID
+	case "station":
+		np.Kind = KindStation
+		np.Station = npm.Station
+
+		// Some services report the station name in the album field instead.
+		if np.Station == "" {
+			np.Station = npm.Album
+			np.Album = ""
+		}
+		if np.Title == "" {
+			np.Title = np.Station
+		}
+	}
+
+	return np
+}
@@ -0,0 +1,85 @@
+package heos_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestNowPlayingMediaNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		npm  heos.NowPlayingMedia
+		want *heos.NowPlaying
+	}{
+		{
+			name: "song",
+			npm: heos.NowPlayingMedia{
+				Type:   "song",
+				Song:   "So What",
+				Album:  "Kind of Blue",
+				Artist: "Miles Davis",
+				MID:    "m1",
+				QID:    3,
+				SID:    1024,
+			},
+			want: &heos.NowPlaying{
+				Kind:     heos.KindSong,
+				Title:    "So What",
+				Artist:   "Miles Davis",
+				Album:    "Kind of Blue",
+				SourceID: 1024,
+				MediaID:  "m1",
+				QueueID:  3,
+			},
+		},
+		{
+			name: "station",
+			npm: heos.NowPlayingMedia{
+				Type:    "station",
+				Song:    "Take Five",
+				Artist:  "Dave Brubeck",
+				Station: "Jazz FM",
+				MID:     "s1",
+				QID:     1,
+				SID:     3,
+			},
+			want: &heos.NowPlaying{
+				Kind:     heos.KindStation,
+				Title:    "Take Five",
+				Artist:   "Dave Brubeck",
+				Station:  "Jazz FM",
+				SourceID: 3,
+				MediaID:  "s1",
+			},
+		},
+		{
+			name: "station in album",
+			npm: heos.NowPlayingMedia{
+				Type:  "station",
+				Album: "Classic Rock Radio",
+				SID:   1,
+			},
+			want: &heos.NowPlaying{
+				Kind:     heos.KindStation,
+				Title:    "Classic Rock Radio",
+				Station:  "Classic Rock Radio",
+				SourceID: 1,
+			},
+		},
+		{
+			name: "unknown",
+			npm:  heos.NowPlayingMedia{Type: "foo", Song: "bar"},
+			want: &heos.NowPlaying{Title: "bar"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.npm.Normalize()); diff != "" {
+				t.Fatalf("unexpected now playing (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
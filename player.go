@@ -0,0 +1,391 @@
+package heos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Players wraps HEOS Player commands.
+type Players struct {
+	c *Client
+}
+
+// PlayerInfo contains information about a HEOS player.
+type PlayerInfo struct {
+	Name    string   `json:"name"`
+	PID     PlayerID `json:"pid"`
+	GID     GroupID  `json:"gid,omitempty"`
+	Model   string   `json:"model"`
+	Version string   `json:"version"`
+	IP      string   `json:"ip"`
+
+	// Fields which are not reported by all devices.
+	Serial  string      `json:"serial,omitempty"`
+	Network NetworkType `json:"network,omitempty"`
+	LineOut LineOut     `json:"lineout,omitempty"`
+	Control Control     `json:"control,omitempty"`
+
+	// Raw is the JSON object from which the PlayerInfo was decoded, which may
+	// contain fields not otherwise supported by this package.
+	Raw json.RawMessage `json:"-"`
+}
+
+// A NetworkType is the type of network connection used by a player.
+type NetworkType string
+
+// Possible NetworkType values.
+const (
+	NetworkWired   NetworkType = "wired"
+	NetworkWiFi    NetworkType = "wifi"
+	NetworkUnknown NetworkType = "unknown"
+)
+
+// A LineOut is the line out level type of a player.
+type LineOut int
+
+// Possible LineOut values.
+const (
+	LineOutVariable LineOut = 1
+	LineOutFixed    LineOut = 2
+)
+
+// String returns the string representation of a LineOut.
+func (l LineOut) String() string {
+	switch l {
+	case LineOutVariable:
+		return "variable"
+	case LineOutFixed:
+		return "fixed"
+	default:
+		return fmt.Sprintf("LineOut(%d)", int(l))
+	}
+}
+
+// A Control is the type of control used by a player with a fixed line out
+// level.
+type Control int
+
+// Possible Control values.
+const (
+	ControlNone    Control = 1
+	ControlIR      Control = 2
+	ControlTrigger Control = 3
+	ControlNetwork Control = 4
+)
+
+// String returns the string representation of a Control.
+func (c Control) String() string {
+	switch c {
+	case ControlNone:
+		return "none"
+	case ControlIR:
+		return "IR"
+	case ControlTrigger:
+		return "trigger"
+	case ControlNetwork:
+		return "network"
+	default:
+		return fmt.Sprintf("Control(%d)", int(c))
+	}
+}
+
+// GetPlayers returns information about all of the players known to a device.
+func (p *Players) GetPlayers(ctx context.Context) ([]PlayerInfo, error) {
+	var ps []PlayerInfo
+	if _, err := p.c.Query(ctx, "player/get_players", &ps); err != nil {
+		return nil, err
+	}
+
+	for _, pi := range ps {
+		p.c.caps.learn(pi.PID, pi.Version)
+	}
+
+	return ps, nil
+}
+
+// GetPlayerInfo returns information about the player specified by pid.
+func (p *Players) GetPlayerInfo(ctx context.Context, pid PlayerID) (*PlayerInfo, error) {
+	var pi PlayerInfo
+	if _, err := p.c.Query(ctx, fmt.Sprintf("player/get_player_info?pid=%d", pid), &pi); err != nil {
+		return nil, err
+	}
+
+	p.c.caps.learn(pi.PID, pi.Version)
+	return &pi, nil
+}
+
+// GetVolume returns the volume level of the player specified by pid, in the
+// range 0-100.
+func (p *Players) GetVolume(ctx context.Context, pid PlayerID) (int, error) {
+	cmd, err := p.c.Query(ctx, fmt.Sprintf("player/get_volume?pid=%d", pid), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Attributes().Int("level")
+}
+
+// SetVolume sets the volume level of the player specified by pid, in the range
+// 0-100.
+func (p *Players) SetVolume(ctx context.Context, pid PlayerID, level int) error {
+	if err := checkVolume(level); err != nil {
+		return err
+	}
+
+	level, err := p.c.volumeLimit.apply(pid, level)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.c.Query(ctx, fmt.Sprintf("player/set_volume?pid=%d&level=%d", pid, level), nil)
+	return err
+}
+
+// VolumeUp increases the volume level of the player specified by pid by step,
+// in the range 1-10.
+func (p *Players) VolumeUp(ctx context.Context, pid PlayerID, step int) error {
+	if err := checkStep(step); err != nil {
+		return err
+	}
+
+	vl := p.c.volumeLimit
+	if max, ok := vl.limit(pid); ok {
+		level, err := vl.step(fmt.Sprintf("player %d", pid), max, step, func() (int, error) {
+			return p.GetVolume(ctx, pid)
+		})
+		if err != nil {
+			return err
+		}
+		if level != -1 {
+			return p.SetVolume(ctx, pid, level)
+		}
+	}
+
+	return p.volumeStep(ctx, "volume_up", pid, step)
+}
+
+// VolumeDown decreases the volume level of the player specified by pid by
+// step, in the range 1-10.
+func (p *Players) VolumeDown(ctx context.Context, pid PlayerID, step int) error {
+	return p.volumeStep(ctx, "volume_down", pid, step)
+}
+
+// volumeStep issues a volume step command for a player.
+func (p *Players) volumeStep(ctx context.Context, command string, pid PlayerID, step int) error {
+	if err := checkStep(step); err != nil {
+		return err
+	}
+
+	_, err := p.c.Query(ctx, fmt.Sprintf("player/%s?pid=%d&step=%d", command, pid, step), nil)
+	return err
+}
+
+// FadeVolume gradually transitions the volume level of the player specified
+// by pid from its current level to level over the duration d, using a series
+// of volume changes. FadeVolume returns when the target level is reached or
+// the context is canceled.
+func (p *Players) FadeVolume(ctx context.Context, pid PlayerID, level int, d time.Duration) error {
+	return fadeVolume(ctx, level, d,
+		func() (int, error) { return p.GetVolume(ctx, pid) },
+		func(level int) error { return p.SetVolume(ctx, pid, level) },
+	)
+}
+
+// GetMute reports whether the player specified by pid is muted.
+func (p *Players) GetMute(ctx context.Context, pid PlayerID) (bool, error) {
+	cmd, err := p.c.Query(ctx, fmt.Sprintf("player/get_mute?pid=%d", pid), nil)
+	if err != nil {
+		return false, err
+	}
+
+	return parseOnOff(cmd.Attributes()["state"])
+}
+
+// SetMute mutes or unmutes the player specified by pid.
+func (p *Players) SetMute(ctx context.Context, pid PlayerID, mute bool) error {
+	_, err := p.c.Query(ctx, fmt.Sprintf("player/set_mute?pid=%d&state=%s", pid, onOff(mute)), nil)
+	return err
+}
+
+// GetPlayState returns the play state of the player specified by pid: one of
+// StatePlay, StatePause, or StateStop.
+func (p *Players) GetPlayState(ctx context.Context, pid PlayerID) (string, error) {
+	cmd, err := p.c.Query(ctx, fmt.Sprintf("player/get_play_state?pid=%d", pid), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.Attributes()["state"], nil
+}
+
+// SetPlayState sets the play state of the player specified by pid to one of
+// StatePlay, StatePause, or StateStop.
+func (p *Players) SetPlayState(ctx context.Context, pid PlayerID, state string) error {
+	switch state {
+	case StatePlay, StatePause, StateStop:
+	default:
+		return fmt.Errorf("heos: invalid play state %q", state)
+	}
+
+	_, err := p.c.Query(ctx, fmt.Sprintf("player/set_play_state?pid=%d&state=%s", pid, state), nil)
+	return err
+}
+
+// NowPlayingMedia contains information about the media playing on a player.
+type NowPlayingMedia struct {
+	// Type is the type of media, such as "song" or "station".
+	Type     string   `json:"type"`
+	Song     string   `json:"song"`
+	Album    string   `json:"album"`
+	Artist   string   `json:"artist"`
+	Station  string   `json:"station,omitempty"`
+	ImageURL string   `json:"image_url"`
+	AlbumID  string   `json:"album_id"`
+	MID      string   `json:"mid"`
+	QID      QueueID  `json:"qid"`
+	SID      SourceID `json:"sid"`
+
+	// Raw is the JSON object from which the NowPlayingMedia was decoded, which may
+	// contain fields not otherwise supported by this package.
+	Raw json.RawMessage `json:"-"`
+}
+
+// GetNowPlayingMedia returns information about the media playing on the
+// player specified by pid.
+func (p *Players) GetNowPlayingMedia(ctx context.Context, pid PlayerID) (*NowPlayingMedia, error) {
+	var npm NowPlayingMedia
+	if _, err := p.c.Query(ctx, fmt.Sprintf("player/get_now_playing_media?pid=%d", pid), &npm); err != nil {
+		return nil, err
+	}
+
+	return &npm, nil
+}
+
+// WatchNowPlaying returns a channel which delivers the media playing on the
+// player specified by pid: first the current media, and then the new media
+// each time a PlayerNowPlayingChanged event for the player is received from
+// events. Because the event carries no information about the media,
+// WatchNowPlaying queries the device each time the event is received.
+//
+// WatchNowPlaying consumes all events from events, which typically come from
+// an EventStream. The returned channel is closed when the context is canceled
+// or events is closed. The Client must not be the Client used by an
+// EventStream, since its connection is busy receiving events.
+func (p *Players) WatchNowPlaying(ctx context.Context, pid PlayerID, events <-chan Event) (<-chan *NowPlayingMedia, error) {
+	npm, err := p.GetNowPlayingMedia(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *NowPlayingMedia, 1)
+	out <- npm
+
+	go func() {
+		defer close(out)
+
+		for {
+			var e Event
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				e = ev
+			}
+
+			if e, ok := e.(*PlayerNowPlayingChanged); !ok || e.PID != pid {
+				continue
+			}
+
+			npm, err := p.GetNowPlayingMedia(ctx, pid)
+			if err != nil {
+				// The context may have been canceled or the device may be
+				// temporarily unable to respond; try again on the next event.
+				p.c.log(ctx, slog.LevelWarn, "failed to query now playing media",
+					slog.Int("pid", int(pid)),
+					slog.Any("error", err),
+				)
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- npm:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// A QueueItem is an item in a player's queue.
+type QueueItem struct {
+	Song     string  `json:"song"`
+	Album    string  `json:"album"`
+	Artist   string  `json:"artist"`
+	ImageURL string  `json:"image_url"`
+	QID      QueueID `json:"qid"`
+	MID      string  `json:"mid"`
+	AlbumID  string  `json:"album_id"`
+
+	// Raw is the JSON object from which the QueueItem was decoded, which may
+	// contain fields not otherwise supported by this package.
+	Raw json.RawMessage `json:"-"`
+}
+
+// GetQueue returns the items in the queue of the player specified by pid
+// which are selected by r, and Counts which report whether more items remain.
+// Use GetQueueAll to retrieve an entire queue.
+func (p *Players) GetQueue(ctx context.Context, pid PlayerID, r Range) ([]QueueItem, Counts, error) {
+	if err := r.check(); err != nil {
+		return nil, Counts{}, err
+	}
+
+	var qis []QueueItem
+	cmd, err := p.c.Query(ctx, fmt.Sprintf("player/get_queue?pid=%d&range=%s", pid, r), &qis)
+	if err != nil {
+		return nil, Counts{}, err
+	}
+
+	return qis, counts(cmd, len(qis)), nil
+}
+
+// PlayQueue plays the item specified by qid in the queue of the player
+// specified by pid.
+func (p *Players) PlayQueue(ctx context.Context, pid PlayerID, qid QueueID) error {
+	_, err := p.c.Query(ctx, fmt.Sprintf("player/play_queue?pid=%d&qid=%d", pid, qid), nil)
+	return err
+}
+
+// GetQueueEach is like GetQueue, but invokes fn for each item as it is decoded
+// from the device's response rather than returning a slice. If fn returns an
+// error, no further items are passed to fn and GetQueueEach returns the error.
+func (p *Players) GetQueueEach(ctx context.Context, pid PlayerID, r Range, fn func(qi QueueItem) error) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+
+	return queryEach(ctx, p.c, fmt.Sprintf("player/get_queue?pid=%d&range=%s", pid, r), fn)
+}
+
+// GetQueueAll returns all of the items in the queue of the player specified by
+// pid, issuing as many requests as necessary.
+func (p *Players) GetQueueAll(ctx context.Context, pid PlayerID) ([]QueueItem, error) {
+	var all []QueueItem
+	err := EachPage(maxRange, func(r Range) (Counts, error) {
+		qis, c, err := p.GetQueue(ctx, pid, r)
+		all = append(all, qis...)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
@@ -0,0 +1,360 @@
+package heos_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestPlayersGetPlayerInfo(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		return response("player/get_player_info", "pid=1", json.RawMessage(`{
+			"name": "Living Room",
+			"pid": 1,
+			"model": "HEOS Drive",
+			"version": "1.520.200",
+			"ip": "192.168.1.10",
+			"network": "wired",
+			"lineout": 2,
+			"control": 2,
+			"serial": "ABC123"
+		}`))
+	})
+	defer done()
+
+	info, err := c.Players.GetPlayerInfo(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get player info: %v", err)
+	}
+
+	want := &heos.PlayerInfo{
+		Name:    "Living Room",
+		PID:     1,
+		Model:   "HEOS Drive",
+		Version: "1.520.200",
+		IP:      "192.168.1.10",
+		Serial:  "ABC123",
+		Network: heos.NetworkWired,
+		LineOut: heos.LineOutFixed,
+		Control: heos.ControlIR,
+	}
+	if diff := cmp.Diff(want, info, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected player info (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff("fixed/IR", info.LineOut.String()+"/"+info.Control.String()); diff != "" {
+		t.Fatalf("unexpected string forms (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersGetPlayerInfoStrict(t *testing.T) {
+	const info = `{"name": "Kitchen", "pid": 1, "model": "HEOS 1", "version": "1.0", "ip": "192.168.1.10", "wifi_band": "5GHz"}`
+
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict %v", strict), func(t *testing.T) {
+			cfg := &heos.Config{Strict: strict}
+			c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+				switch req {
+				case "heos://player/get_player_info?pid=1\r\n":
+					return response("player/get_player_info", "pid=1", json.RawMessage(info))
+				case "heos://system/heart_beat\r\n":
+					return ack(req)
+				default:
+					return response("player/get_queue", "pid=1", json.RawMessage(`[{"song": "a", "qid": 1, "rating": 5}, {"song": "b", "qid": 2}]`))
+				}
+			})
+			defer done()
+
+			// Unknown fields are only rejected in strict mode.
+			_, err := c.Players.GetPlayerInfo(ctx, 1)
+			if strict != (err != nil) {
+				t.Fatalf("unexpected get player info error: %v", err)
+			}
+
+			var n int
+			err = c.Players.GetQueueEach(ctx, 1, heos.Range{Start: 0, End: 9}, func(_ heos.QueueItem) error {
+				n++
+				return nil
+			})
+			if strict != (err != nil) {
+				t.Fatalf("unexpected get queue error: %v", err)
+			}
+
+			// The connection remains usable after a strict decoding failure.
+			if err := c.System.Heartbeat(ctx); err != nil {
+				t.Fatalf("failed to send heartbeat: %v", err)
+			}
+		})
+	}
+}
+
+func TestPlayersVolume(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://player/get_volume?pid=1\r\n":
+			return response("player/get_volume", "pid=1&level=20", nil)
+		case "heos://player/set_volume?pid=1&level=30\r\n":
+			return response("player/set_volume", "pid=1&level=30", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	level, err := c.Players.GetVolume(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+	if diff := cmp.Diff(20, level); diff != "" {
+		t.Fatalf("unexpected volume level (-want +got):\n%s", diff)
+	}
+
+	if err := c.Players.SetVolume(ctx, 1, 30); err != nil {
+		t.Fatalf("failed to set volume: %v", err)
+	}
+
+	if err := c.Players.SetVolume(ctx, 1, 101); err == nil {
+		t.Fatal("expected an out of range error, but none occurred")
+	}
+}
+
+func TestPlayersVolumeStep(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+		return ack(req)
+	})
+	defer done()
+
+	if err := c.Players.VolumeUp(ctx, 1, 1); err != nil {
+		t.Fatalf("failed to increase volume: %v", err)
+	}
+	if err := c.Players.VolumeDown(ctx, 1, 3); err != nil {
+		t.Fatalf("failed to decrease volume: %v", err)
+	}
+
+	want := []string{
+		"heos://player/volume_up?pid=1&step=1\r\n",
+		"heos://player/volume_down?pid=1&step=3\r\n",
+	}
+
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersFadeVolume(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sets []string
+	)
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch {
+		case req == "heos://player/get_volume?pid=1\r\n":
+			return response("player/get_volume", "pid=1&level=10", nil)
+		case strings.HasPrefix(req, "heos://player/set_volume?"):
+			mu.Lock()
+			defer mu.Unlock()
+			sets = append(sets, strings.TrimSpace(req))
+			return response("player/set_volume", "", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	// The duration only allows for 3 changes at the minimum interval, so the
+	// volume must be changed in larger steps.
+	if err := c.Players.FadeVolume(ctx, 1, 14, 300*time.Millisecond); err != nil {
+		t.Fatalf("failed to fade volume: %v", err)
+	}
+
+	want := []string{
+		"heos://player/set_volume?pid=1&level=11",
+		"heos://player/set_volume?pid=1&level=12",
+		"heos://player/set_volume?pid=1&level=14",
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, sets); diff != "" {
+		t.Fatalf("unexpected volume changes (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersWatchNowPlaying(t *testing.T) {
+	var n int
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		if diff := cmp.Diff("heos://player/get_now_playing_media?pid=1\r\n", req); diff != "" {
+			panicf("unexpected client request (-want +got):\n%s", diff)
+		}
+
+		n++
+		return response("player/get_now_playing_media", "pid=1", heos.NowPlayingMedia{
+			Type: "song",
+			Song: fmt.Sprintf("Song %d", n),
+		})
+	})
+	defer done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan heos.Event)
+	npmC, err := c.Player(1).WatchNowPlaying(ctx, events)
+	if err != nil {
+		t.Fatalf("failed to watch now playing: %v", err)
+	}
+
+	// Events for other players and other event types must be ignored.
+	events <- &heos.PlayerNowPlayingChanged{PID: 2}
+	events <- &heos.PlayerStateChanged{PID: 1, State: heos.StatePlay}
+	events <- &heos.PlayerNowPlayingChanged{PID: 1}
+
+	var songs []string
+	for i := 0; i < 2; i++ {
+		songs = append(songs, (<-npmC).Song)
+	}
+
+	if diff := cmp.Diff([]string{"Song 1", "Song 2"}, songs); diff != "" {
+		t.Fatalf("unexpected songs (-want +got):\n%s", diff)
+	}
+
+	close(events)
+	if _, ok := <-npmC; ok {
+		t.Fatal("expected now playing channel to be closed")
+	}
+}
+
+func TestPlayersVolumeLimit(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	cfg := &heos.Config{
+		VolumeLimit: &heos.VolumeLimit{
+			Max:     50,
+			Players: map[heos.PlayerID]int{2: 20},
+		},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, req)
+
+		return ack(req)
+	})
+	defer done()
+
+	// Levels above the limit are clamped.
+	for _, pid := range []heos.PlayerID{1, 2} {
+		if err := c.Players.SetVolume(ctx, pid, 80); err != nil {
+			t.Fatalf("failed to set volume: %v", err)
+		}
+	}
+
+	// Changes made by other means are corrected.
+	events := make(chan heos.Event, 3)
+	events <- &heos.PlayerVolumeChanged{PID: 1, Level: 40}
+	events <- &heos.PlayerVolumeChanged{PID: 2, Level: 30}
+	events <- &heos.PlayerStateChanged{PID: 1, State: heos.StatePlay}
+	close(events)
+
+	if err := c.Players.EnforceVolumeLimit(ctx, events); err != nil {
+		t.Fatalf("failed to enforce volume limit: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{
+		"heos://player/set_volume?pid=1&level=50\r\n",
+		"heos://player/set_volume?pid=2&level=20\r\n",
+		"heos://player/set_volume?pid=2&level=20\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersVolumeLimitStep(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	cfg := &heos.Config{
+		VolumeLimit: &heos.VolumeLimit{Players: map[heos.PlayerID]int{1: 50}},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, req)
+
+		if req == "heos://player/get_volume?pid=1\r\n" {
+			return response("player/get_volume", "pid=1&level=45", nil)
+		}
+
+		return ack(req)
+	})
+	defer done()
+
+	// Steps within the limit and steps for players without a limit are
+	// issued unchanged, but a step beyond the limit sets the limit instead.
+	for _, step := range []struct {
+		pid  heos.PlayerID
+		step int
+	}{
+		{pid: 1, step: 5},
+		{pid: 1, step: 10},
+		{pid: 2, step: 10},
+	} {
+		if err := c.Players.VolumeUp(ctx, step.pid, step.step); err != nil {
+			t.Fatalf("failed to increase volume: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{
+		"heos://player/get_volume?pid=1\r\n",
+		"heos://player/volume_up?pid=1&step=5\r\n",
+		"heos://player/get_volume?pid=1\r\n",
+		"heos://player/set_volume?pid=1&level=50\r\n",
+		"heos://player/volume_up?pid=2&step=10\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersVolumeLimitReject(t *testing.T) {
+	cfg := &heos.Config{
+		VolumeLimit: &heos.VolumeLimit{Max: 50, Reject: true},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		panicf("unexpected client request: %q", req)
+		return nil
+	})
+	defer done()
+
+	if err := c.Players.SetVolume(ctx, 1, 80); !errors.Is(err, heos.ErrVolumeLimit) {
+		t.Fatalf("expected volume limit error, but got: %v", err)
+	}
+}
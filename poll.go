@@ -0,0 +1,130 @@
+package heos
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// A playerState is the state of a player observed by Poll.
+type playerState struct {
+	state string
+	level int
+	mute  bool
+	media NowPlayingMedia
+}
+
+// Poll returns a channel which delivers events for the players specified by
+// pids, by querying each player's state every interval. Poll is intended for
+// networks on which an EventStream cannot be used, and delivers the same
+// events an EventStream would, so that code which consumes events need not
+// know their origin.
+//
+// Poll delivers PlayerStateChanged, PlayerVolumeChanged, and
+// PlayerNowPlayingChanged events when the state of a player differs from the
+// previous poll. The initial state of each player is queried before Poll
+// returns and produces no events.
+//
+// The returned channel is closed when the context is canceled. Unlike an
+// EventStream, Poll does not occupy the Client's connection, so its events
+// may be passed to helpers such as WatchNowPlaying which use the same Client.
+func (p *Players) Poll(ctx context.Context, interval time.Duration, pids ...PlayerID) (<-chan Event, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("heos: invalid poll interval %v", interval)
+	}
+
+	states := make([]playerState, len(pids))
+	for i, pid := range pids {
+		if err := p.poll(ctx, pid, &states[i], nil); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(chan Event, 16)
+	go func() {
+		defer close(out)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			for i, pid := range pids {
+				var events []Event
+				if err := p.poll(ctx, pid, &states[i], &events); err != nil {
+					// The context may have been canceled or the device may be
+					// temporarily unable to respond; try again on the next
+					// poll.
+					p.c.log(ctx, slog.LevelWarn, "failed to poll player",
+						slog.Int("pid", int(pid)),
+						slog.Any("error", err),
+					)
+				}
+
+				for _, e := range events {
+					select {
+					case <-ctx.Done():
+						return
+					case out <- e:
+					}
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// poll queries the state of the player specified by pid, updating s and
+// appending an event to events for each change since the previous poll. If
+// events is nil, s is updated without producing events.
+func (p *Players) poll(ctx context.Context, pid PlayerID, s *playerState, events *[]Event) error {
+	state, err := p.GetPlayState(ctx, pid)
+	if err != nil {
+		return err
+	}
+	level, err := p.GetVolume(ctx, pid)
+	if err != nil {
+		return err
+	}
+	mute, err := p.GetMute(ctx, pid)
+	if err != nil {
+		return err
+	}
+	media, err := p.GetNowPlayingMedia(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	// Compare only the fields decoded by this package.
+	m := *media
+	m.Raw = nil
+
+	if events != nil {
+		if state != s.state {
+			*events = append(*events, &PlayerStateChanged{PID: pid, State: state})
+		}
+		if level != s.level || mute != s.mute {
+			*events = append(*events, &PlayerVolumeChanged{PID: pid, Level: level, Mute: mute})
+		}
+		if !reflect.DeepEqual(m, s.media) {
+			*events = append(*events, &PlayerNowPlayingChanged{PID: pid})
+		}
+	}
+
+	*s = playerState{
+		state: state,
+		level: level,
+		mute:  mute,
+		media: m,
+	}
+
+	return nil
+}
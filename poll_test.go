@@ -0,0 +1,78 @@
+package heos_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestPlayersPoll(t *testing.T) {
+	var (
+		mu sync.Mutex
+		n  int
+	)
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch req {
+		case "heos://player/get_play_state?pid=1\r\n":
+			n++
+			if n == 1 {
+				return response("player/get_play_state", "pid=1&state=pause", nil)
+			}
+			return response("player/get_play_state", "pid=1&state=play", nil)
+		case "heos://player/get_volume?pid=1\r\n":
+			return response("player/get_volume", "pid=1&level=20", nil)
+		case "heos://player/get_mute?pid=1\r\n":
+			return response("player/get_mute", "pid=1&state=off", nil)
+		case "heos://player/get_now_playing_media?pid=1\r\n":
+			song := "One"
+			if n > 2 {
+				song = "Two"
+			}
+			return response("player/get_now_playing_media", "pid=1", heos.NowPlayingMedia{Song: song})
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	if _, err := c.Players.Poll(ctx, 0, 1); err == nil {
+		t.Fatal("expected an error for an invalid interval, but none occurred")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := c.Players.Poll(ctx, 10*time.Millisecond, 1)
+	if err != nil {
+		t.Fatalf("failed to poll: %v", err)
+	}
+
+	// The play state changes on the first poll after the baseline, and the
+	// media on the next.
+	var got []heos.Event
+	for len(got) < 2 {
+		got = append(got, <-events)
+	}
+
+	cancel()
+	for range events {
+	}
+
+	want := []heos.Event{
+		&heos.PlayerStateChanged{PID: 1, State: heos.StatePlay},
+		&heos.PlayerNowPlayingChanged{PID: 1},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
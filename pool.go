@@ -0,0 +1,189 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// A Pool distributes requests across several connections to a single device,
+// so that bulk operations such as walking a large library are not serialized
+// behind the round trip latency of one connection. A Pool is safe for
+// concurrent use.
+//
+// Each connection is an independent Client, so a Pool's connections must not
+// be used for an EventStream.
+type Pool struct {
+	clients []*Client
+	idle    chan *Client
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// DialPool dials size connections to the device specified by addr and
+// returns a Pool which distributes requests across them. The context is used
+// for cancelation and to set timeouts while dialing. If cfg is nil, a default
+// configuration is used.
+func DialPool(ctx context.Context, addr string, size int, cfg *Config) (*Pool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("heos: invalid pool size %d", size)
+	}
+
+	clients := make([]*Client, 0, size)
+	for i := 0; i < size; i++ {
+		c, err := Dial(ctx, addr, cfg)
+		if err != nil {
+			for _, c := range clients {
+				_ = c.Close()
+			}
+			return nil, err
+		}
+
+		clients = append(clients, c)
+	}
+
+	return NewPool(clients...), nil
+}
+
+// NewPool returns a Pool which distributes requests across clients, which
+// must be connected to the same device and are closed when the Pool is
+// closed. NewPool panics if no clients are specified.
+func NewPool(clients ...*Client) *Pool {
+	if len(clients) == 0 {
+		panic("heos: NewPool requires at least one Client")
+	}
+
+	p := &Pool{
+		clients: clients,
+		idle:    make(chan *Client, len(clients)),
+	}
+	for _, c := range clients {
+		p.idle <- c
+	}
+
+	return p
+}
+
+// Len returns the number of connections in the Pool.
+func (p *Pool) Len() int { return len(p.clients) }
+
+// Do waits for an idle connection and invokes fn with its Client, returning
+// fn's error. The Client is used exclusively by fn until fn returns, and must
+// not be retained afterward. Do returns the context's error if the context is
+// canceled while waiting.
+func (p *Pool) Do(ctx context.Context, fn func(c *Client) error) error {
+	var c *Client
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case c = <-p.idle:
+	}
+	defer func() { p.idle <- c }()
+
+	return fn(c)
+}
+
+// Query issues query using an idle connection. See Client.Query for details.
+func (p *Pool) Query(ctx context.Context, query string, out interface{}) (*Command, error) {
+	var cmd *Command
+	err := p.Do(ctx, func(c *Client) error {
+		var err error
+		cmd, err = c.Query(ctx, query, out)
+		return err
+	})
+
+	return cmd, err
+}
+
+// BrowseAll is like Browse.BrowseAll, but requests the pages of a container
+// concurrently using the Pool's connections once the device reports the
+// number of items in the container. Items are returned in order.
+func (p *Pool) BrowseAll(ctx context.Context, sid SourceID, cid string) ([]MediaItem, error) {
+	var (
+		first []MediaItem
+		c     Counts
+	)
+	err := p.Do(ctx, func(cl *Client) error {
+		var err error
+		first, c, err = cl.Browse.BrowseRange(ctx, sid, cid, Page(0, maxRange))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Count < 0 {
+		// The number of pages is unknown, so they must be requested in turn.
+		var all []MediaItem
+		err := p.Do(ctx, func(cl *Client) error {
+			var err error
+			all, err = cl.Browse.BrowseAll(ctx, sid, cid)
+			return err
+		})
+		return all, err
+	}
+
+	pages := make([][]MediaItem, (c.Count+maxRange-1)/maxRange)
+	if len(pages) == 0 {
+		return first, nil
+	}
+	pages[0] = first
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for i := 1; i < len(pages); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := p.Do(ctx, func(cl *Client) error {
+				mis, _, err := cl.Browse.BrowseRange(ctx, sid, cid, Page(i, maxRange))
+				pages[i] = mis
+				return err
+			})
+			if err != nil {
+				// Record the error before stopping the remaining requests,
+				// so that it precedes any cancelation errors they return.
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	all := make([]MediaItem, 0, c.Count)
+	for _, mis := range pages {
+		all = append(all, mis...)
+	}
+
+	return all, nil
+}
+
+// Close closes all of the Pool's connections. Close is safe to call more than
+// once; subsequent calls return the result of the first call.
+func (p *Pool) Close() error {
+	p.closeOnce.Do(func() {
+		errs := make([]error, 0, len(p.clients))
+		for _, c := range p.clients {
+			errs = append(errs, c.Close())
+		}
+		p.closeErr = errors.Join(errs...)
+	})
+
+	return p.closeErr
+}
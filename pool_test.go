@@ -0,0 +1,126 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestPoolBrowseAll(t *testing.T) {
+	// A container with 250 items, served by each connection of the Pool.
+	rangeRe := regexp.MustCompile(`range=(\d+),(\d+)`)
+
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+	fn := func(req string) interface{} {
+		mu.Lock()
+		reqs = append(reqs, strings.TrimSpace(req))
+		mu.Unlock()
+
+		m := rangeRe.FindStringSubmatch(req)
+		if m == nil {
+			panicf("unexpected client request: %q", req)
+		}
+
+		start, _ := strconv.Atoi(m[1])
+		end, _ := strconv.Atoi(m[2])
+		if end > 249 {
+			end = 249
+		}
+
+		items := make([]string, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			items = append(items, fmt.Sprintf(`{"name": "%d"}`, i))
+		}
+
+		return response("browse/browse",
+			fmt.Sprintf("sid=1&cid=c1&returned=%d&count=250", len(items)),
+			json.RawMessage("["+strings.Join(items, ",")+"]"),
+		)
+	}
+
+	c1, ctx, done1 := testClient(t, fn)
+	defer done1()
+	c2, _, done2 := testClient(t, fn)
+	defer done2()
+
+	p := heos.NewPool(c1, c2)
+	defer p.Close()
+
+	if diff := cmp.Diff(2, p.Len()); diff != "" {
+		t.Fatalf("unexpected pool size (-want +got):\n%s", diff)
+	}
+
+	mis, err := p.BrowseAll(ctx, 1, "c1")
+	if err != nil {
+		t.Fatalf("failed to browse: %v", err)
+	}
+
+	var names []string
+	for _, mi := range mis {
+		names = append(names, mi.Name)
+	}
+
+	want := make([]string, 0, 250)
+	for i := 0; i < 250; i++ {
+		want = append(want, strconv.Itoa(i))
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Fatalf("unexpected items (-want +got):\n%s", diff)
+	}
+
+	// Pages after the first may be requested in any order.
+	mu.Lock()
+	defer mu.Unlock()
+
+	got := append([]string(nil), reqs[1:]...)
+	if diff := cmp.Diff("heos://browse/browse?sid=1&cid=c1&range=0,99", reqs[0]); diff != "" {
+		t.Fatalf("unexpected first request (-want +got):\n%s", diff)
+	}
+	if len(got) == 2 && got[0] > got[1] {
+		got[0], got[1] = got[1], got[0]
+	}
+
+	wantReqs := []string{
+		"heos://browse/browse?sid=1&cid=c1&range=100,199",
+		"heos://browse/browse?sid=1&cid=c1&range=200,299",
+	}
+	if diff := cmp.Diff(wantReqs, got); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPoolQuery(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		return ack(req)
+	})
+	defer done()
+
+	p := heos.NewPool(c)
+
+	cmd, err := p.Query(ctx, "system/heart_beat", nil)
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if diff := cmp.Diff("system/heart_beat", cmd.HEOS.Command); diff != "" {
+		t.Fatalf("unexpected command (-want +got):\n%s", diff)
+	}
+
+	// The connection is closed once, regardless of how many times the Pool
+	// is closed.
+	if err := p.Close(); err != nil {
+		t.Fatalf("failed to close pool: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("failed to close pool again: %v", err)
+	}
+}
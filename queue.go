@@ -0,0 +1,156 @@
+package heos
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// A QueueCache caches the queues of HEOS players. When Run is used to process
+// change events, the cached queue of a player is automatically refreshed each
+// time its queue changes. A QueueCache is safe for concurrent use.
+type QueueCache struct {
+	c *Client
+
+	mu     sync.RWMutex
+	queues map[PlayerID][]QueueItem
+}
+
+// NewQueueCache creates a QueueCache which uses c to fetch queues. c must not
+// be the Client used by an EventStream.
+func NewQueueCache(c *Client) *QueueCache {
+	return &QueueCache{
+		c:      c,
+		queues: make(map[PlayerID][]QueueItem),
+	}
+}
+
+// Queue returns the queue of the player specified by pid, fetching it from the
+// device if it is not already cached. The returned slice must not be
+// modified.
+func (qc *QueueCache) Queue(ctx context.Context, pid PlayerID) ([]QueueItem, error) {
+	qc.mu.RLock()
+	qis, ok := qc.queues[pid]
+	qc.mu.RUnlock()
+	if ok {
+		return qis, nil
+	}
+
+	return qc.Refresh(ctx, pid)
+}
+
+// Refresh fetches the entire queue of the player specified by pid and updates
+// the cache.
+func (qc *QueueCache) Refresh(ctx context.Context, pid PlayerID) ([]QueueItem, error) {
+	qis, err := qc.c.Players.GetQueueAll(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	qc.queues[pid] = qis
+
+	return qis, nil
+}
+
+// Run processes change events from events, which typically come from an
+// EventStream, refreshing the cached queue of each player for which a
+// PlayerQueueChanged event arrives. Queues which have not yet been cached are
+// not fetched. Run consumes all events from events and blocks until the
+// context is canceled or events is closed.
+func (qc *QueueCache) Run(ctx context.Context, events <-chan Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			qe, ok := e.(*PlayerQueueChanged)
+			if !ok {
+				continue
+			}
+
+			qc.mu.RLock()
+			_, cached := qc.queues[qe.PID]
+			qc.mu.RUnlock()
+			if !cached {
+				continue
+			}
+
+			if _, err := qc.Refresh(ctx, qe.PID); err != nil {
+				// Discard the stale queue so the next call to Queue will
+				// fetch it again.
+				qc.mu.Lock()
+				delete(qc.queues, qe.PID)
+				qc.mu.Unlock()
+
+				qc.c.log(ctx, slog.LevelWarn, "failed to refresh queue",
+					slog.Int("pid", int(qe.PID)),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}
+}
+
+// A QueueIterator iterates over the items in a player's queue, fetching pages
+// of items from the device on demand so that long queues need not be loaded
+// all at once. Use Players.IterateQueue to create a QueueIterator.
+type QueueIterator struct {
+	p   *Players
+	pid PlayerID
+
+	page []QueueItem
+	r    Range
+	done bool
+	item QueueItem
+	err  error
+}
+
+// IterateQueue returns a QueueIterator over the queue of the player specified
+// by pid. No requests are issued until QueueIterator.Next is called.
+func (p *Players) IterateQueue(pid PlayerID) *QueueIterator {
+	return &QueueIterator{p: p, pid: pid, r: Page(0, maxRange)}
+}
+
+// Next advances the QueueIterator to the next item, fetching the next page of
+// the queue if necessary, which is then available through Item. Next returns
+// false when the end of the queue is reached or an error occurs; use Err to
+// check for errors.
+func (it *QueueIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		qis, c, err := it.p.GetQueue(ctx, it.pid, it.r)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = qis
+		it.done = !c.More(it.r)
+		it.r = it.r.Next()
+		if len(qis) == 0 {
+			return false
+		}
+	}
+
+	it.item, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Item returns the current item in the queue.
+func (it *QueueIterator) Item() QueueItem { return it.item }
+
+// Err returns the first error encountered while fetching the queue, if any.
+func (it *QueueIterator) Err() error { return it.err }
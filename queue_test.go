@@ -0,0 +1,157 @@
+package heos_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestQueueCache(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reqs    []string
+		version = 1
+	)
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, req)
+
+		var pid, start, end int
+		if _, err := fmt.Sscanf(req, "heos://player/get_queue?pid=%d&range=%d,%d\r\n", &pid, &start, &end); err != nil {
+			panicf("unexpected client request: %q", req)
+		}
+
+		// A queue of 150 items requires two pages.
+		var qis []heos.QueueItem
+		for i := start; i <= end && i < 150; i++ {
+			qis = append(qis, heos.QueueItem{
+				Song: fmt.Sprintf("Song %d.%d", version, i),
+				QID:  heos.QueueID(i + 1),
+			})
+		}
+
+		return response("player/get_queue", req, qis)
+	})
+	defer done()
+
+	qc := heos.NewQueueCache(c)
+
+	qis, err := qc.Queue(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get queue: %v", err)
+	}
+	if diff := cmp.Diff(150, len(qis)); diff != "" {
+		t.Fatalf("unexpected queue length (-want +got):\n%s", diff)
+	}
+
+	// A cached queue must not be fetched again.
+	if _, err := qc.Queue(ctx, 1); err != nil {
+		t.Fatalf("failed to get cached queue: %v", err)
+	}
+
+	// Change the queue and notify the cache; uncached players are ignored.
+	mu.Lock()
+	version = 2
+	mu.Unlock()
+
+	events := make(chan heos.Event, 3)
+	events <- &heos.PlayerQueueChanged{PID: 2}
+	events <- &heos.PlayerStateChanged{PID: 1, State: heos.StatePlay}
+	events <- &heos.PlayerQueueChanged{PID: 1}
+	close(events)
+	qc.Run(context.Background(), events)
+
+	qis, err = qc.Queue(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get refreshed queue: %v", err)
+	}
+	if diff := cmp.Diff("Song 2.149", qis[149].Song); diff != "" {
+		t.Fatalf("unexpected refreshed song (-want +got):\n%s", diff)
+	}
+
+	want := []string{
+		"heos://player/get_queue?pid=1&range=0,99\r\n",
+		"heos://player/get_queue?pid=1&range=100,199\r\n",
+		"heos://player/get_queue?pid=1&range=0,99\r\n",
+		"heos://player/get_queue?pid=1&range=100,199\r\n",
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersIterateQueue(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, req)
+
+		var pid, start, end int
+		if _, err := fmt.Sscanf(req, "heos://player/get_queue?pid=%d&range=%d,%d\r\n", &pid, &start, &end); err != nil {
+			panicf("unexpected client request: %q", req)
+		}
+
+		// A queue of 200 items requires two full pages, and a third request
+		// to discover the end of the queue.
+		var qis []heos.QueueItem
+		for i := start; i <= end && i < 200; i++ {
+			qis = append(qis, heos.QueueItem{QID: heos.QueueID(i + 1)})
+		}
+
+		return response("player/get_queue", req, qis)
+	})
+	defer done()
+
+	it := c.Players.IterateQueue(1)
+
+	// Only the first page is fetched to retrieve the first item.
+	if !it.Next(ctx) {
+		t.Fatalf("failed to get first queue item: %v", it.Err())
+	}
+	mu.Lock()
+	n := len(reqs)
+	mu.Unlock()
+	if diff := cmp.Diff(1, n); diff != "" {
+		t.Fatalf("unexpected number of requests (-want +got):\n%s", diff)
+	}
+
+	n = 1
+	for it.Next(ctx) {
+		n++
+		if diff := cmp.Diff(heos.QueueID(n), it.Item().QID); diff != "" {
+			t.Fatalf("unexpected queue ID (-want +got):\n%s", diff)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("failed to iterate queue: %v", err)
+	}
+
+	if diff := cmp.Diff(200, n); diff != "" {
+		t.Fatalf("unexpected number of queue items (-want +got):\n%s", diff)
+	}
+
+	want := []string{
+		"heos://player/get_queue?pid=1&range=0,99\r\n",
+		"heos://player/get_queue?pid=1&range=100,199\r\n",
+		"heos://player/get_queue?pid=1&range=200,299\r\n",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,109 @@
+package heos
+
+import "fmt"
+
+// maxRange is the maximum number of items a device will return in response to
+// a single request for a list, such as a queue or the contents of a
+// container.
+const maxRange = 100
+
+// A Range selects the items of a list returned by a device, from the
+// zero-based index Start through End, inclusive. Devices return at most 100
+// items per request.
+type Range struct {
+	Start, End int
+}
+
+// Page returns the Range for the zero-based page n of a list with size items
+// per page.
+func Page(n, size int) Range {
+	return Range{Start: n * size, End: n*size + size - 1}
+}
+
+// Len returns the number of items selected by r.
+func (r Range) Len() int { return r.End - r.Start + 1 }
+
+// Next returns the Range of the same length which immediately follows r.
+func (r Range) Next() Range {
+	n := r.Len()
+	return Range{Start: r.Start + n, End: r.End + n}
+}
+
+// String returns the Range in the form used by HEOS commands, such as "0,99".
+func (r Range) String() string {
+	return fmt.Sprintf("%d,%d", r.Start, r.End)
+}
+
+// check verifies that r is a valid Range.
+func (r Range) check() error {
+	if r.Start < 0 || r.End < r.Start {
+		return fmt.Errorf("heos: invalid range %d-%d", r.Start, r.End)
+	}
+
+	return nil
+}
+
+// Counts reports the number of items in a response to a request for a Range
+// of a list.
+type Counts struct {
+	// Returned is the number of items in the response.
+	Returned int
+
+	// Count is the total number of items in the list, or -1 if the device did
+	// not report it.
+	Count int
+}
+
+// More reports whether the list contains items after those returned in
+// response to a request for r. If the device did not report the total number
+// of items, More assumes that additional items remain only if the response
+// filled r.
+func (c Counts) More(r Range) bool {
+	if c.Count < 0 {
+		return c.Returned >= r.Len()
+	}
+
+	return r.Start+c.Returned < c.Count
+}
+
+// counts parses the Counts from the response to cmd, which contained n items.
+func counts(cmd *Command, n int) Counts {
+	attrs := cmd.Attributes()
+
+	c := Counts{Returned: n, Count: -1}
+	if v, err := attrs.Int("returned"); err == nil {
+		c.Returned = v
+	}
+	if v, err := attrs.Int("count"); err == nil {
+		c.Count = v
+	}
+
+	return c
+}
+
+// EachPage invokes fn with consecutive Ranges of size items, starting with the
+// first item of a list, until the Counts returned by fn report that no more
+// items remain or fn returns an error. EachPage can be combined with any
+// method which accepts a Range to retrieve an entire list:
+//
+//	var all []heos.MediaItem
+//	err := heos.EachPage(100, func(r heos.Range) (heos.Counts, error) {
+//		mis, c, err := cl.Browse.BrowseRange(ctx, sid, cid, r)
+//		all = append(all, mis...)
+//		return c, err
+//	})
+func EachPage(size int, fn func(r Range) (Counts, error)) error {
+	if size < 1 {
+		return fmt.Errorf("heos: invalid page size %d", size)
+	}
+
+	for r := Page(0, size); ; r = r.Next() {
+		c, err := fn(r)
+		if err != nil {
+			return err
+		}
+		if !c.More(r) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package heos_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestEachPage(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r heos.Range) heos.Counts
+		want []heos.Range
+	}{
+		{
+			name: "count",
+			fn: func(r heos.Range) heos.Counts {
+				// A list of 20 items: the second page is full, but nothing
+				// remains after it.
+				return heos.Counts{Returned: r.Len(), Count: 20}
+			},
+			want: []heos.Range{
+				{Start: 0, End: 9},
+				{Start: 10, End: 19},
+			},
+		},
+		{
+			name: "no count",
+			fn: func(r heos.Range) heos.Counts {
+				// A list of 25 items with no total reported.
+				n := r.Len()
+				if r.End >= 25 {
+					n = 25 - r.Start
+				}
+
+				return heos.Counts{Returned: n, Count: -1}
+			},
+			want: []heos.Range{
+				{Start: 0, End: 9},
+				{Start: 10, End: 19},
+				{Start: 20, End: 29},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rs []heos.Range
+			err := heos.EachPage(10, func(r heos.Range) (heos.Counts, error) {
+				rs = append(rs, r)
+				return tt.fn(r), nil
+			})
+			if err != nil {
+				t.Fatalf("failed to iterate pages: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, rs); diff != "" {
+				t.Fatalf("unexpected ranges (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	errFoo := errors.New("foo")
+	err := heos.EachPage(10, func(heos.Range) (heos.Counts, error) {
+		return heos.Counts{Returned: 10, Count: -1}, errFoo
+	})
+	if !errors.Is(err, errFoo) {
+		t.Fatalf("expected page error, but got: %v", err)
+	}
+
+	if err := heos.EachPage(0, nil); err == nil {
+		t.Fatal("expected an error for an invalid page size, but none occurred")
+	}
+}
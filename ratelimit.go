@@ -0,0 +1,123 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/heos/wire"
+)
+
+// ErrSuperseded is returned by Client methods when Config.Coalesce is enabled
+// and a command was not sent because a newer command superseded it.
+var ErrSuperseded = errors.New("heos: command superseded by a newer command")
+
+// A limiter throttles and optionally coalesces commands sent by a Client.
+type limiter struct {
+	interval time.Duration
+	coalesce bool
+
+	mu      sync.Mutex
+	next    time.Time
+	pending map[string]*pending
+}
+
+// A pending is a coalesced command waiting for its reserved slot. Each key
+// has at most one pending command, so a burst of commands for the same target
+// reserves only a single slot.
+type pending struct {
+	at         time.Time
+	superseded chan struct{}
+}
+
+// newLimiter creates a limiter which permits one command per interval.
+func newLimiter(interval time.Duration, coalesce bool) *limiter {
+	return &limiter{
+		interval: interval,
+		coalesce: coalesce,
+		pending:  make(map[string]*pending),
+	}
+}
+
+// wait blocks until the command in u may be sent, or returns ErrSuperseded if
+// a newer command for the same target arrived while waiting.
+func (l *limiter) wait(ctx context.Context, u *url.URL) error {
+	var key string
+	if l.coalesce {
+		key = coalesceKey(u)
+	}
+
+	l.mu.Lock()
+	var p *pending
+	if prev, ok := l.pending[key]; ok && key != "" {
+		// Supersede the pending command for this key and take over its slot
+		// rather than reserving a new one, so the most recent value is sent
+		// as soon as possible.
+		close(prev.superseded)
+		p = &pending{at: prev.at, superseded: make(chan struct{})}
+	} else {
+		// Reserve the next available slot.
+		now := time.Now()
+		if l.next.Before(now) {
+			l.next = now
+		}
+		p = &pending{at: l.next, superseded: make(chan struct{})}
+		l.next = l.next.Add(l.interval)
+	}
+	if key != "" {
+		l.pending[key] = p
+	}
+	l.mu.Unlock()
+
+	var err error
+	if d := time.Until(p.at); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-p.superseded:
+		case <-t.C:
+		}
+	}
+
+	if key == "" {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pending[key] != p {
+		return ErrSuperseded
+	}
+	delete(l.pending, key)
+
+	return err
+}
+
+// coalesced is the set of commands which may be coalesced. Only commands which
+// set state on a player or group identified solely by pid or gid are
+// included, because only the most recent value matters. Repeated commands
+// such as "player/volume_up" are always sent.
+var coalesced = map[string]bool{
+	"player/set_volume":     true,
+	"player/set_mute":       true,
+	"player/set_play_state": true,
+	"group/set_volume":      true,
+	"group/set_mute":        true,
+}
+
+// coalesceKey returns a key identifying the target of a command which may be
+// coalesced, or the empty string if the command must always be sent.
+func coalesceKey(u *url.URL) string {
+	if !coalesced[u.Path] {
+		return ""
+	}
+
+	attrs := wire.ParseAttributes(u.RawQuery)
+	return u.Path + "?pid=" + attrs["pid"] + "&gid=" + attrs["gid"]
+}
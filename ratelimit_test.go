@@ -0,0 +1,122 @@
+package heos_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientRateLimit(t *testing.T) {
+	const interval = 50 * time.Millisecond
+
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	cfg := &heos.Config{
+		RateLimit: interval,
+		Coalesce:  true,
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, strings.TrimSpace(req))
+
+		return ack(req)
+	})
+	defer done()
+
+	// Issue several volume changes in rapid succession, as a volume slider
+	// would. Only the final change should be sent.
+	var wg sync.WaitGroup
+	errC := make(chan error, 5)
+	start := time.Now()
+	for i := 1; i <= 5; i++ {
+		wg.Add(1)
+		go func(level int) {
+			defer wg.Done()
+			errC <- c.Players.SetVolume(ctx, 1, level)
+		}(i * 10)
+
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	wg.Wait()
+	close(errC)
+
+	// The surviving command takes over the slot of the commands it superseded,
+	// so it waits for at most the handshake's slots and its own.
+	if took := time.Since(start); took >= 4*interval {
+		t.Fatalf("coalesced command was delayed: took %v", took)
+	}
+
+	var superseded int
+	for err := range errC {
+		switch err {
+		case nil:
+		case heos.ErrSuperseded:
+			superseded++
+		default:
+			t.Fatalf("failed to set volume: %v", err)
+		}
+	}
+
+	if diff := cmp.Diff(4, superseded); diff != "" {
+		t.Fatalf("unexpected number of superseded commands (-want +got):\n%s", diff)
+	}
+
+	// Repeated commands which are not coalesced must all be sent, but
+	// throttled.
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		if err := c.Players.VolumeUp(ctx, 1, 1); err != nil {
+			t.Fatalf("failed to increase volume: %v", err)
+		}
+	}
+	if took := time.Since(start); took < 2*interval {
+		t.Fatalf("commands were not throttled: took %v", took)
+	}
+
+	// Other set commands must not be coalesced, because their targets are
+	// not identified only by pid or gid.
+	errC = make(chan error, 2)
+	for _, mid := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(mid string) {
+			defer wg.Done()
+			_, err := c.Query(ctx, "browse/set_service_option?sid=1&option=1&mid="+mid, nil)
+			errC <- err
+		}(mid)
+
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	wg.Wait()
+	close(errC)
+	for err := range errC {
+		if err != nil {
+			t.Fatalf("failed to set service option: %v", err)
+		}
+	}
+
+	want := []string{
+		"heos://player/set_volume?pid=1&level=50",
+		"heos://player/volume_up?pid=1&step=1",
+		"heos://player/volume_up?pid=1&step=1",
+		"heos://player/volume_up?pid=1&step=1",
+		"heos://browse/set_service_option?sid=1&option=1&mid=a",
+		"heos://browse/set_service_option?sid=1&option=1&mid=b",
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,53 @@
+package heos
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// A rawSetter is a payload type which retains the raw JSON from which it was
+// decoded, so that callers can access fields not otherwise supported by this
+// package.
+type rawSetter interface {
+	setRaw(b json.RawMessage)
+}
+
+var rawSetterType = reflect.TypeOf((*rawSetter)(nil)).Elem()
+
+func (ms *MusicSource) setRaw(b json.RawMessage)      { ms.Raw = b }
+func (mi *MediaItem) setRaw(b json.RawMessage)        { mi.Raw = b }
+func (gi *GroupInfo) setRaw(b json.RawMessage)        { gi.Raw = b }
+func (pi *PlayerInfo) setRaw(b json.RawMessage)       { pi.Raw = b }
+func (npm *NowPlayingMedia) setRaw(b json.RawMessage) { npm.Raw = b }
+func (qi *QueueItem) setRaw(b json.RawMessage)        { qi.Raw = b }
+
+// setRaw stores a copy of b, the JSON from which out was decoded, in out. If
+// out is a pointer to a slice, each element of the JSON array in b is stored
+// in the corresponding element of the slice.
+func setRaw(b []byte, out interface{}) error {
+	if rs, ok := out.(rawSetter); ok {
+		rs.setRaw(append(json.RawMessage(nil), b...))
+		return nil
+	}
+
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Slice {
+		return nil
+	}
+	s := v.Elem()
+	if !reflect.PointerTo(s.Type().Elem()).Implements(rawSetterType) {
+		return nil
+	}
+
+	// Unmarshaling copies each element.
+	var raws []json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return err
+	}
+
+	for i := 0; i < s.Len() && i < len(raws); i++ {
+		s.Index(i).Addr().Interface().(rawSetter).setRaw(raws[i])
+	}
+
+	return nil
+}
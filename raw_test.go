@@ -0,0 +1,98 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientRawPayload(t *testing.T) {
+	const (
+		players = `[{"name":"Kitchen","pid":1,"wifi_band":"5GHz"},{"name":"Den","pid":2}]`
+		items   = `[{"container":"no","mid":"s1","name":"Jazz FM","bitrate":128}]`
+	)
+
+	for _, strict := range []bool{false, true} {
+		c, ctx, done := testClientConfig(t, &heos.Config{Strict: strict}, func(req string) interface{} {
+			switch req {
+			case "heos://player/get_players\r\n":
+				return response("player/get_players", "", json.RawMessage(players))
+			case "heos://player/get_player_info?pid=2\r\n":
+				return response("player/get_player_info", "pid=2", json.RawMessage(`{"name":"Den","pid":2}`))
+			case "heos://browse/browse?sid=1\r\n":
+				return response("browse/browse", "sid=1", json.RawMessage(items))
+			default:
+				panicf("unexpected client request: %q", req)
+				return nil
+			}
+		})
+
+		// Fields not modeled by the package remain accessible through the
+		// raw JSON of each item, unless strict decoding rejects them.
+		ps, err := c.Players.GetPlayers(ctx)
+		if strict {
+			if err == nil {
+				t.Fatal("expected a strict decoding error, but none occurred")
+			}
+		} else {
+			if err != nil {
+				t.Fatalf("failed to get players: %v", err)
+			}
+
+			var v struct {
+				WiFiBand string `json:"wifi_band"`
+			}
+			if err := json.Unmarshal(ps[0].Raw, &v); err != nil {
+				t.Fatalf("failed to unmarshal raw player: %v", err)
+			}
+			if diff := cmp.Diff("5GHz", v.WiFiBand); diff != "" {
+				t.Fatalf("unexpected Wi-Fi band (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(`{"name":"Den","pid":2}`, string(ps[1].Raw)); diff != "" {
+				t.Fatalf("unexpected raw player (-want +got):\n%s", diff)
+			}
+		}
+
+		pi, err := c.Players.GetPlayerInfo(ctx, 2)
+		if err != nil {
+			t.Fatalf("failed to get player info: %v", err)
+		}
+		if diff := cmp.Diff(`{"name":"Den","pid":2}`, string(pi.Raw)); diff != "" {
+			t.Fatalf("unexpected raw player info (-want +got):\n%s", diff)
+		}
+
+		// Streamed items also retain their raw JSON. MediaItem is decoded
+		// leniently regardless of Config.Strict.
+		var raws []string
+		err = c.Browse.BrowseEach(ctx, 1, "", func(mi heos.MediaItem) error {
+			raws = append(raws, string(mi.Raw))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+		if diff := cmp.Diff([]string{`{"container":"no","mid":"s1","name":"Jazz FM","bitrate":128}`}, raws); diff != "" {
+			t.Fatalf("unexpected raw items (-want +got):\n%s", diff)
+		}
+
+		done()
+	}
+}
+
+func TestClientQueryPayload(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		return response("player/get_players", "", json.RawMessage(`[{"name":"Kitchen","pid":1}]`))
+	})
+	defer done()
+
+	cmd, err := c.Query(ctx, "player/get_players", nil)
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+
+	if diff := cmp.Diff(`[{"name":"Kitchen","pid":1}]`, string(cmd.Payload)); diff != "" {
+		t.Fatalf("unexpected payload (-want +got):\n%s", diff)
+	}
+}
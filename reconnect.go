@@ -0,0 +1,96 @@
+package heos
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// A ReconnectPolicy configures how an EventStream created by DialEvents
+// re-establishes its connection after the connection fails, such as when a
+// device reboots or loses its network connection.
+//
+// After reconnecting, the EventStream registers for change events again and
+// delivers a Reconnected event, since events which occurred while it was
+// disconnected were lost.
+type ReconnectPolicy struct {
+	// Attempts is the maximum number of consecutive attempts to reconnect
+	// after a failure. If zero, the EventStream reconnects until it succeeds
+	// or is closed.
+	Attempts int
+
+	// Backoff is the delay before the first attempt, which doubles with each
+	// subsequent attempt up to MaxBackoff. If zero, defaults of 100
+	// milliseconds and 2 seconds are used, respectively.
+	Backoff, MaxBackoff time.Duration
+}
+
+// backoff returns the delay before attempt number n, starting at 0.
+func (rp *ReconnectPolicy) backoff(n int) time.Duration {
+	return backoff(rp.Backoff, rp.MaxBackoff, n)
+}
+
+// Reconnected is delivered by an EventStream after its connection failed and
+// was re-established according to Config.Reconnect. Events may have been lost
+// while the EventStream was disconnected, so consumers should query any state
+// they track. Reconnected events are not subject to Config.EventFilter.
+type Reconnected struct {
+	// Err is the error which caused the connection to fail.
+	Err error `json:"-"`
+}
+
+func (*Reconnected) isEvent() {}
+
+// reconnect replaces the EventStream's failed connection with a new one which
+// is registered for change events, retrying according to the EventStream's
+// ReconnectPolicy until it succeeds, ctx is canceled, or the policy's attempts
+// are exhausted.
+func (es *EventStream) reconnect(ctx context.Context) error {
+	var span Span
+	if t := es.c.tracer; t != nil {
+		ctx, span = t.StartSpan(ctx, "heos reconnect")
+	}
+
+	n, err := es.redialAttempts(ctx)
+	if span != nil {
+		span.SetAttributes(slog.Int("heos.attempts", n))
+		span.End(err)
+	}
+
+	return err
+}
+
+// redialAttempts performs the work for reconnect, returning the number of
+// attempts made.
+func (es *EventStream) redialAttempts(ctx context.Context) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	for ; es.policy.Attempts < 1 || n < es.policy.Attempts; n++ {
+		if err := sleep(ctx, es.policy.backoff(n)); err != nil {
+			return n, err
+		}
+
+		var c *Client
+		c, err = es.redial(ctx)
+		if m := es.c.metrics; m != nil {
+			m.ObserveReconnect(n+1, err)
+		}
+		if err == nil {
+			// Keep the failed connection until it is replaced, so that Close
+			// always has a single connection to close.
+			_ = es.c.Close()
+			es.c = c
+			return n + 1, nil
+		}
+
+		es.c.log(ctx, slog.LevelWarn, "failed to reconnect event stream",
+			slog.Int("attempt", n+1),
+			slog.Any("error", err),
+		)
+	}
+
+	return n, fmt.Errorf("heos: failed to reconnect event stream: %w", err)
+}
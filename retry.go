@@ -0,0 +1,115 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Default values for RetryPolicy fields.
+const (
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+)
+
+// A RetryPolicy configures automatic retries of commands which fail due to
+// transient conditions. Retries never extend beyond the deadline or
+// cancelation of the caller's context.
+//
+// Any command is retried when a device reports that it is busy processing a
+// previous command or that a resource is temporarily unavailable, since the
+// device did not act on the command. These are the only conditions in which a
+// retry is always safe.
+//
+// Read-only commands, such as "player/get_volume", are also retried when a
+// single attempt exceeds Config.Timeout. Other commands are not, because the
+// device may have acted on a command whose response was merely slow, and
+// sending a command such as "player/volume_up" again would apply it twice.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts for each command, including
+	// the first. Values less than 2 disable retries.
+	Attempts int
+
+	// Backoff is the delay before the first retry, which doubles with each
+	// subsequent retry up to MaxBackoff. If zero, defaults of 100 milliseconds
+	// and 2 seconds are used, respectively.
+	Backoff, MaxBackoff time.Duration
+}
+
+// attempts returns the number of attempts permitted by the policy.
+func (rp *RetryPolicy) attempts() int {
+	if rp == nil || rp.Attempts < 1 {
+		return 1
+	}
+
+	return rp.Attempts
+}
+
+// backoff returns the delay before retry number n, starting at 0.
+func (rp *RetryPolicy) backoff(n int) time.Duration {
+	return backoff(rp.Backoff, rp.MaxBackoff, n)
+}
+
+// backoff returns the delay before attempt number n, starting at 0, for an
+// exponential backoff with the initial delay d and maximum delay max.
+func backoff(d, max time.Duration, n int) time.Duration {
+	if d <= 0 {
+		d = defaultBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	return d
+}
+
+// transient reports whether err, returned by a single attempt of command
+// issued with ctx, indicates a transient failure which may succeed if retried.
+func transient(ctx context.Context, command string, err error) bool {
+	if ctx.Err() != nil {
+		// The caller's context is done, so no retry is possible.
+		return false
+	}
+
+	// The caller's context is still alive, so a deadline error came from the
+	// per-attempt timeout. Only commands which do not change device state are
+	// safe to send again.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return readOnly(command)
+	}
+
+	return errors.Is(err, ErrBusy)
+}
+
+// readOnly reports whether command, such as "player/get_volume", only reads
+// device state.
+func readOnly(command string) bool {
+	switch command {
+	case "system/heart_beat", "system/check_account", "browse/browse", "browse/search":
+		return true
+	}
+
+	i := strings.LastIndexByte(command, '/')
+	return strings.HasPrefix(command[i+1:], "get_")
+}
+
+// sleep sleeps for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+package heos_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientRetryBusy(t *testing.T) {
+	var (
+		mu sync.Mutex
+		n  int
+	)
+
+	cfg := &heos.Config{
+		Retry: &heos.RetryPolicy{
+			Attempts: 3,
+			Backoff:  time.Millisecond,
+		},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+
+		switch req {
+		case "heos://player/get_volume?pid=1\r\n":
+			// Busy twice, then success.
+			if n < 3 {
+				return json.RawMessage(`{"heos": {"command": "player/get_volume", "result": "fail", "message": "eid=13&text=Processing previous command"}}`)
+			}
+			return response("player/get_volume", "pid=1&level=20", nil)
+		case "heos://player/get_volume?pid=2\r\n":
+			return json.RawMessage(`{"heos": {"command": "player/get_volume", "result": "fail", "message": "eid=2&text=ID Not Valid"}}`)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	level, err := c.Players.GetVolume(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+	if diff := cmp.Diff(20, level); diff != "" {
+		t.Fatalf("unexpected volume level (-want +got):\n%s", diff)
+	}
+
+	// Permanent errors must not be retried.
+	_, err = c.Players.GetVolume(ctx, 2)
+
+	var herr *heos.Error
+	if !errors.As(err, &herr) || herr.EID != 2 {
+		t.Fatalf("expected invalid ID error, but got: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(4, n); diff != "" {
+		t.Fatalf("unexpected number of requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientRetryTimeout(t *testing.T) {
+	var n int
+	cfg := &heos.Config{
+		Timeout: 100 * time.Millisecond,
+		Retry: &heos.RetryPolicy{
+			Attempts: 2,
+			Backoff:  time.Millisecond,
+		},
+	}
+
+	c, _, done := testClientConfig(t, cfg, func(req string) interface{} {
+		switch req {
+		case "heos://player/get_volume?pid=1\r\n":
+			// The first attempt is answered after it times out, but before
+			// the second attempt times out.
+			n++
+			if n == 1 {
+				time.Sleep(150 * time.Millisecond)
+			}
+			return response("player/get_volume", "pid=1&level=20", nil)
+		case "heos://system/heart_beat\r\n":
+			return response("system/heart_beat", "", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	ctx := context.Background()
+	if _, err := c.Players.GetVolume(ctx, 1); err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+
+	// The retry discarded the late response to the first attempt and
+	// accepted its own, so no response is pending for the heartbeat.
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+}
+
+func TestClientRetryTimeoutNotReadOnly(t *testing.T) {
+	var (
+		mu sync.Mutex
+		n  int
+	)
+
+	cfg := &heos.Config{
+		Timeout: 50 * time.Millisecond,
+		Retry: &heos.RetryPolicy{
+			Attempts: 3,
+			Backoff:  time.Millisecond,
+		},
+	}
+
+	c, _, done := testClientConfig(t, cfg, func(req string) interface{} {
+		switch req {
+		case "heos://player/volume_up?pid=1&step=1\r\n":
+			mu.Lock()
+			n++
+			mu.Unlock()
+
+			// The device acts on the command, but responds too late.
+			time.Sleep(100 * time.Millisecond)
+			return ack(req)
+		case "heos://system/heart_beat\r\n":
+			return response("system/heart_beat", "", nil)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	ctx := context.Background()
+	if err := c.Players.VolumeUp(ctx, 1, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+
+	// The late response must be discarded. Use a longer deadline than
+	// Config.Timeout since the device is still busy with the first command.
+	hctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if err := c.System.Heartbeat(hctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(1, n); diff != "" {
+		t.Fatalf("unexpected number of requests (-want +got):\n%s", diff)
+	}
+}
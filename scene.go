@@ -0,0 +1,103 @@
+package heos
+
+import (
+	"context"
+	"errors"
+)
+
+// A Scene is a named configuration of players, such as "Dinner" or "Party",
+// which stores grouping, volume levels, and the media to play. Scenes are
+// typically captured from the current state of a system using CaptureScene,
+// stored as JSON, and later recalled using Apply.
+type Scene struct {
+	Name string `json:"name"`
+
+	// Groups are the groups of players in the Scene. Players which are not a
+	// member of any group play independently.
+	Groups []SceneGroup `json:"groups,omitempty"`
+
+	// Players are the playback states of the players in the Scene. For group
+	// members, only volume and mute are stored, since their playback follows
+	// the group's leader.
+	Players []Snapshot `json:"players"`
+}
+
+// A SceneGroup is a group of players in a Scene.
+type SceneGroup struct {
+	Leader  PlayerID   `json:"leader"`
+	Members []PlayerID `json:"members"`
+}
+
+// CaptureScene captures the current grouping and playback state of all players
+// known to the device as a Scene with the specified name.
+func CaptureScene(ctx context.Context, c *Client, name string) (*Scene, error) {
+	gs, err := c.Groups.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scene{Name: name}
+	members := make(map[PlayerID]bool)
+	for _, g := range gs {
+		ps := sortLeader(g.Players)
+		if len(ps) == 0 || ps[0].Role != RoleLeader {
+			continue
+		}
+
+		sg := SceneGroup{Leader: ps[0].PID}
+		for _, p := range ps[1:] {
+			sg.Members = append(sg.Members, p.PID)
+			members[p.PID] = true
+		}
+
+		s.Groups = append(s.Groups, sg)
+	}
+
+	ps, err := c.Players.GetPlayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range ps {
+		var snap *Snapshot
+		if members[p.PID] {
+			snap, err = c.Players.volumeSnapshot(ctx, p.PID)
+		} else {
+			snap, err = c.Players.Snapshot(ctx, p.PID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.Players = append(s.Players, *snap)
+	}
+
+	return s, nil
+}
+
+// Apply recalls the Scene using c: all existing groups are dissolved, the
+// Scene's groups are created, and each player's volume, mute, media, and play
+// state are restored.
+func (s *Scene) Apply(ctx context.Context, c *Client) error {
+	if len(s.Players) == 0 && len(s.Groups) == 0 {
+		return errors.New("heos: scene has no players or groups")
+	}
+
+	if err := c.Groups.UngroupAll(ctx); err != nil {
+		return err
+	}
+
+	for _, g := range s.Groups {
+		if err := c.Groups.SetGroup(ctx, g.Leader, g.Members...); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.Players {
+		if err := c.Players.Restore(ctx, &s.Players[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestSceneCaptureApply(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		var pid int
+		switch {
+		case req == "heos://group/get_groups\r\n":
+			return response("group/get_groups", "", []heos.GroupInfo{{
+				GID: 1,
+				Players: []heos.GroupPlayer{
+					{PID: 1, Role: heos.RoleLeader},
+					{PID: 2, Role: heos.RoleMember},
+				},
+			}})
+		case req == "heos://player/get_players\r\n":
+			return response("player/get_players", "", []heos.PlayerInfo{{PID: 1}, {PID: 2}})
+		case scan(req, "heos://player/get_volume?pid=%d\r\n", &pid):
+			return response("player/get_volume", fmt.Sprintf("pid=%d&level=%d", pid, pid*10), nil)
+		case scan(req, "heos://player/get_mute?pid=%d\r\n", &pid):
+			return response("player/get_mute", fmt.Sprintf("pid=%d&state=off", pid), nil)
+		case req == "heos://player/get_play_state?pid=1\r\n":
+			return response("player/get_play_state", "pid=1&state=play", nil)
+		case req == "heos://player/get_now_playing_media?pid=1\r\n":
+			return response("player/get_now_playing_media", "pid=1", heos.NowPlayingMedia{
+				Type: "station",
+				MID:  "s1",
+				SID:  3,
+			})
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	s, err := heos.CaptureScene(ctx, c, "Dinner")
+	if err != nil {
+		t.Fatalf("failed to capture scene: %v", err)
+	}
+
+	// Scenes survive a round trip through JSON.
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal scene: %v", err)
+	}
+	var got heos.Scene
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to unmarshal scene: %v", err)
+	}
+
+	want := heos.Scene{
+		Name:   "Dinner",
+		Groups: []heos.SceneGroup{{Leader: 1, Members: []heos.PlayerID{2}}},
+		Players: []heos.Snapshot{
+			{
+				PID:    1,
+				Volume: 10,
+				State:  heos.StatePlay,
+				Media:  &heos.NowPlayingMedia{Type: "station", MID: "s1", SID: 3},
+			},
+			{PID: 2, Volume: 20},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected scene (-want +got):\n%s", diff)
+	}
+
+	reqs = nil
+	if err := got.Apply(ctx, c); err != nil {
+		t.Fatalf("failed to apply scene: %v", err)
+	}
+
+	wantReqs := []string{
+		"heos://group/get_groups\r\n",
+		"heos://group/set_group?pid=1\r\n",
+		"heos://group/set_group?pid=1,2\r\n",
+		"heos://player/set_mute?pid=1&state=off\r\n",
+		"heos://player/set_volume?pid=1&level=10\r\n",
+		"heos://browse/play_stream?pid=1&sid=3&mid=s1\r\n",
+		"heos://player/set_play_state?pid=1&state=play\r\n",
+		"heos://player/set_mute?pid=2&state=off\r\n",
+		"heos://player/set_volume?pid=2&level=20\r\n",
+	}
+	if diff := cmp.Diff(wantReqs, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+// scan reports whether s matches format, storing the scanned values in args.
+func scan(s, format string, args ...interface{}) bool {
+	_, err := fmt.Sscanf(s, format, args...)
+	return err == nil && strings.HasSuffix(s, "\r\n")
+}
@@ -0,0 +1,213 @@
+package heos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mdlayher/heos/wire"
+)
+
+// A SearchCriteria is a way of searching a music source, such as by artist or
+// by track.
+type SearchCriteria struct {
+	// Name is the name of the criteria, such as "Artist" or "Track".
+	Name string
+
+	// SCID is the search criteria ID passed to Browse.Search.
+	SCID int
+
+	// Wildcard reports whether the source supports wildcards ('*') in
+	// searches using the criteria.
+	Wildcard bool
+
+	// Playable reports whether the results of the criteria can be played
+	// directly, and CID is the container ID used to do so, if any.
+	Playable bool
+	CID      string
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sc *SearchCriteria) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Name     string `json:"name"`
+		SCID     int    `json:"scid"`
+		Wildcard string `json:"wildcard"`
+		Playable string `json:"playable"`
+		CID      string `json:"cid"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*sc = SearchCriteria{
+		Name:     v.Name,
+		SCID:     v.SCID,
+		Wildcard: v.Wildcard == "yes",
+		Playable: v.Playable == "yes",
+		CID:      v.CID,
+	}
+
+	return nil
+}
+
+// GetSearchCriteria returns the ways in which the music source specified by
+// sid can be searched. Sources which cannot be searched report an error.
+func (b *Browse) GetSearchCriteria(ctx context.Context, sid SourceID) ([]SearchCriteria, error) {
+	var scs []SearchCriteria
+	if _, err := b.c.Query(ctx, fmt.Sprintf("browse/get_search_criteria?sid=%d", sid), &scs); err != nil {
+		return nil, err
+	}
+
+	return scs, nil
+}
+
+// Search searches the music source specified by sid for query, using the
+// search criteria specified by scid, such as the SCID of a SearchCriteria
+// returned by GetSearchCriteria. Devices return a limited number of results.
+func (b *Browse) Search(ctx context.Context, sid SourceID, scid int, query string) ([]MediaItem, error) {
+	var mis []MediaItem
+	q := fmt.Sprintf("browse/search?sid=%d&search=%s&scid=%d", sid, wire.Escape(query), scid)
+	if _, err := b.c.Query(ctx, q, &mis); err != nil {
+		return nil, err
+	}
+
+	return mis, nil
+}
+
+// A SearchResult contains the results of searching one music source using
+// one of its search criteria, as returned by Browse.SearchAll.
+type SearchResult struct {
+	// Source is the music source which was searched, and Criteria is the
+	// search criteria used.
+	Source   MusicSource
+	Criteria SearchCriteria
+
+	// Items are the items found by the search.
+	Items []MediaItem
+
+	// Err is the error which occurred while searching, if any. If the
+	// source's search criteria could not be retrieved, Criteria is empty.
+	Err error
+}
+
+// SearchAll searches every available music source for query at once, and
+// returns a SearchResult for each source and search criteria, in the order
+// the device reports them. If criteria are specified, only search criteria
+// whose names match one of them, ignoring case, are used, such as "Artist"
+// or "Track". Sources which cannot be searched are skipped.
+//
+// Searching one source does not wait for another, and a failure to search
+// one source is reported in its SearchResult rather than stopping the
+// others. If the context is canceled or its deadline passes, the results
+// obtained so far are returned, and the context's error is reported by the
+// remaining SearchResults. SearchAll returns an error only if the music
+// sources cannot be listed.
+func (b *Browse) SearchAll(ctx context.Context, query string, criteria ...string) ([]SearchResult, error) {
+	return searchAll(ctx, func(fn func(c *Client) error) error {
+		return fn(b.c)
+	}, query, criteria)
+}
+
+// SearchAll is like Browse.SearchAll, but searches the music sources
+// concurrently using the Pool's connections.
+func (p *Pool) SearchAll(ctx context.Context, query string, criteria ...string) ([]SearchResult, error) {
+	return searchAll(ctx, func(fn func(c *Client) error) error {
+		return p.Do(ctx, fn)
+	}, query, criteria)
+}
+
+// searchAll implements SearchAll, using do to obtain a Client for each
+// request.
+func searchAll(ctx context.Context, do func(fn func(c *Client) error) error, query string, criteria []string) ([]SearchResult, error) {
+	var mss []MusicSource
+	err := do(func(c *Client) error {
+		var err error
+		mss, err = c.Browse.GetMusicSources(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([][]SearchResult, len(mss))
+	)
+	for i, ms := range mss {
+		if !ms.Available {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, ms MusicSource) {
+			defer wg.Done()
+			results[i] = searchSource(ctx, do, ms, query, criteria)
+		}(i, ms)
+	}
+	wg.Wait()
+
+	var all []SearchResult
+	for _, srs := range results {
+		all = append(all, srs...)
+	}
+
+	return all, nil
+}
+
+// searchSource searches ms for query using each of its search criteria
+// which match criteria.
+func searchSource(ctx context.Context, do func(fn func(c *Client) error) error, ms MusicSource, query string, criteria []string) []SearchResult {
+	var scs []SearchCriteria
+	err := do(func(c *Client) error {
+		var err error
+		scs, err = c.Browse.GetSearchCriteria(ctx, ms.SID)
+		return err
+	})
+	if err != nil {
+		var herr *Error
+		if errors.As(err, &herr) {
+			// The device reports that the source cannot be searched.
+			return nil
+		}
+
+		return []SearchResult{{Source: ms, Err: err}}
+	}
+
+	var srs []SearchResult
+	for _, sc := range scs {
+		if !matchCriteria(sc, criteria) {
+			continue
+		}
+
+		sr := SearchResult{Source: ms, Criteria: sc}
+		sr.Err = do(func(c *Client) error {
+			var err error
+			sr.Items, err = c.Browse.Search(ctx, ms.SID, sc.SCID, query)
+			return err
+		})
+
+		srs = append(srs, sr)
+	}
+
+	return srs
+}
+
+// matchCriteria reports whether sc matches one of the criteria names, or
+// whether criteria is empty.
+func matchCriteria(sc SearchCriteria, criteria []string) bool {
+	if len(criteria) == 0 {
+		return true
+	}
+
+	for _, name := range criteria {
+		if strings.EqualFold(sc.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientBrowseSearch(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://browse/get_search_criteria?sid=4\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/get_search_criteria", "result": "success", "message": "sid=4"}, "payload": [
+				{"name": "Artist", "scid": 1, "wildcard": "no"},
+				{"name": "Track", "scid": 3, "wildcard": "yes", "playable": "yes", "cid": "SEARCHED_TRACKS-"}
+			]}`)
+		case "heos://browse/search?sid=4&search=AC%26DC&scid=1\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/search", "result": "success", "message": "sid=4&search=AC%26DC&scid=1&returned=1&count=1"}, "payload": [
+				{"container": "yes", "playable": "no", "type": "artist", "name": "AC/DC", "cid": "artist-1"}
+			]}`)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	scs, err := c.Browse.GetSearchCriteria(ctx, 4)
+	if err != nil {
+		t.Fatalf("failed to get search criteria: %v", err)
+	}
+
+	wantSCs := []heos.SearchCriteria{
+		{Name: "Artist", SCID: 1},
+		{Name: "Track", SCID: 3, Wildcard: true, Playable: true, CID: "SEARCHED_TRACKS-"},
+	}
+	if diff := cmp.Diff(wantSCs, scs); diff != "" {
+		t.Fatalf("unexpected search criteria (-want +got):\n%s", diff)
+	}
+
+	mis, err := c.Browse.Search(ctx, 4, scs[0].SCID, "AC&DC")
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+
+	wantMIs := []heos.MediaItem{{
+		Container: true,
+		Type:      "artist",
+		Name:      "AC/DC",
+		CID:       "artist-1",
+	}}
+	if diff := cmp.Diff(wantMIs, mis, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected items (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientBrowseSearchAll(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://browse/get_music_sources\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/get_music_sources", "result": "success", "message": ""}, "payload": [
+				{"name": "Pandora", "type": "music_service", "sid": 1, "available": "false"},
+				{"name": "TuneIn", "type": "music_service", "sid": 3, "available": "true"},
+				{"name": "Spotify", "type": "music_service", "sid": 4, "available": "true"},
+				{"name": "Favorites", "type": "heos_service", "sid": 1028, "available": "true"}
+			]}`)
+		case "heos://browse/get_search_criteria?sid=3\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/get_search_criteria", "result": "success", "message": "sid=3"}, "payload": [
+				{"name": "Station", "scid": 1}
+			]}`)
+		case "heos://browse/get_search_criteria?sid=4\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/get_search_criteria", "result": "success", "message": "sid=4"}, "payload": [
+				{"name": "Artist", "scid": 1},
+				{"name": "Track", "scid": 3}
+			]}`)
+		case "heos://browse/get_search_criteria?sid=1028\r\n":
+			// Favorites cannot be searched.
+			return json.RawMessage(`{"heos": {"command": "browse/get_search_criteria", "result": "fail", "message": "eid=1&text=Unrecognized Command"}}`)
+		case "heos://browse/search?sid=3&search=jazz&scid=1\r\n":
+			return response("browse/search", "sid=3&search=jazz&scid=1", []map[string]string{
+				{"container": "no", "playable": "yes", "type": "station", "name": "Jazz FM", "mid": "s1"},
+			})
+		case "heos://browse/search?sid=4&search=jazz&scid=1\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/search", "result": "fail", "message": "eid=5&text=Resource Not Available"}}`)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	// Only the Station and Artist criteria are used.
+	srs, err := c.Browse.SearchAll(ctx, "jazz", "station", "artist")
+	if err != nil {
+		t.Fatalf("failed to search all sources: %v", err)
+	}
+
+	if len(srs) != 2 {
+		t.Fatalf("unexpected number of search results: %d", len(srs))
+	}
+	if !errors.Is(srs[1].Err, heos.ErrBusy) {
+		t.Fatalf("expected heos.ErrBusy for Spotify, but got: %v", srs[1].Err)
+	}
+	srs[1].Err = nil
+
+	want := []heos.SearchResult{
+		{
+			Source:   heos.MusicSource{Name: "TuneIn", Type: "music_service", SID: 3, Available: true},
+			Criteria: heos.SearchCriteria{Name: "Station", SCID: 1},
+			Items: []heos.MediaItem{{
+				Playable: true,
+				Type:     "station",
+				Name:     "Jazz FM",
+				MID:      "s1",
+			}},
+		},
+		{
+			Source:   heos.MusicSource{Name: "Spotify", Type: "music_service", SID: 4, Available: true},
+			Criteria: heos.SearchCriteria{Name: "Artist", SCID: 1},
+		},
+	}
+	if diff := cmp.Diff(want, srs, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected search results (-want +got):\n%s", diff)
+	}
+}
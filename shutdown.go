@@ -0,0 +1,147 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// ErrClientClosed is returned by Client methods which are called after the
+// Client is closed or while it is shutting down, and by commands which are
+// interrupted because the Client was closed. ErrClientClosed also matches
+// net.ErrClosed using errors.Is.
+var ErrClientClosed = fmt.Errorf("heos: client closed: %w", net.ErrClosed)
+
+// Shutdown gracefully closes the Client: it stops accepting new commands,
+// which fail with ErrClientClosed, waits for commands already in progress to
+// complete, and then closes the Client's connection. If the context is
+// canceled before the commands complete, the connection is closed
+// immediately, interrupting them, and Shutdown returns the context's error.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.state.mu.Lock()
+	c.state.closing = true
+	c.state.mu.Unlock()
+
+	werr := c.state.wait(ctx)
+
+	c.state.mu.Lock()
+	c.state.closed = true
+	c.state.mu.Unlock()
+
+	if err := c.c.Close(); err != nil && werr == nil {
+		return err
+	}
+
+	return werr
+}
+
+// Shutdown gracefully closes all of the Pool's connections, as with
+// Client.Shutdown.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	errs := make([]error, len(p.clients))
+
+	var wg sync.WaitGroup
+	for i, c := range p.clients {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			errs[i] = c.Shutdown(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Shutdown gracefully closes the EventStream: it stops receiving events,
+// waits for the delivery of an event which was already received to
+// complete, and then closes the EventStream's connection. Events which were
+// delivered remain buffered in the Events channel, which is then closed. If
+// the context is canceled first, the delivery is abandoned, and Shutdown
+// returns the context's error.
+func (es *EventStream) Shutdown(ctx context.Context) error {
+	es.stopRead()
+
+	var werr error
+	select {
+	case <-es.done:
+	case <-ctx.Done():
+		werr = ctx.Err()
+	}
+
+	if err := es.Close(); err != nil && werr == nil {
+		return err
+	}
+
+	return werr
+}
+
+// clientState tracks the commands in progress on a Client so that it can be
+// shut down gracefully. The zero value is ready to use.
+type clientState struct {
+	mu       sync.Mutex
+	closing  bool
+	closed   bool
+	inflight int
+	idle     chan struct{}
+}
+
+// begin registers the start of a command, returning ErrClientClosed if the
+// Client is closing. If begin succeeds, end must be called when the command
+// completes.
+func (cs *clientState) begin() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closing {
+		return ErrClientClosed
+	}
+
+	cs.inflight++
+	return nil
+}
+
+// end registers the completion of a command, translating err to
+// ErrClientClosed if the command was interrupted because the Client's
+// connection was closed. Other errors, such as those which occur while a
+// Shutdown waits for the command to complete, are returned unchanged.
+func (cs *clientState) end(err error) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.inflight--
+	if cs.inflight == 0 && cs.idle != nil {
+		close(cs.idle)
+		cs.idle = nil
+	}
+
+	if cs.closed && (errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe)) {
+		return ErrClientClosed
+	}
+
+	return err
+}
+
+// wait waits until no commands are in progress or ctx is canceled.
+func (cs *clientState) wait(ctx context.Context) error {
+	cs.mu.Lock()
+	if cs.inflight == 0 {
+		cs.mu.Unlock()
+		return nil
+	}
+	if cs.idle == nil {
+		cs.idle = make(chan struct{})
+	}
+	idle := cs.idle
+	cs.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,116 @@
+package heos_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/heos"
+)
+
+func TestClientShutdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		queryTimeout time.Duration
+		shutdownErr  error
+		queryErr     error
+	}{
+		{
+			name:    "graceful",
+			timeout: 5 * time.Second,
+		},
+		{
+			name:        "deadline exceeded",
+			timeout:     10 * time.Millisecond,
+			shutdownErr: context.DeadlineExceeded,
+			queryErr:    heos.ErrClientClosed,
+		},
+		{
+			// The in-flight query fails on its own while Shutdown waits, so
+			// its error must not be reported as heos.ErrClientClosed.
+			name:         "query error",
+			timeout:      5 * time.Second,
+			queryTimeout: 10 * time.Millisecond,
+			queryErr:     context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			// The server responds to a command slowly, after signaling that
+			// the command was received.
+			var (
+				received = make(chan struct{})
+				respond  = make(chan struct{})
+			)
+			client, server := net.Pipe()
+			go func() {
+				defer server.Close()
+
+				enc := json.NewEncoder(server)
+				r := bufio.NewReader(server)
+				for {
+					req, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					if req == "heos://player/get_volume?pid=1\r\n" {
+						close(received)
+						<-respond
+					}
+
+					if err := enc.Encode(ack(req)); err != nil {
+						return
+					}
+				}
+			}()
+
+			c, err := heos.New(ctx, client, nil)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			errC := make(chan error, 1)
+			go func() {
+				qctx := ctx
+				if tt.queryTimeout != 0 {
+					var qcancel context.CancelFunc
+					qctx, qcancel = context.WithTimeout(ctx, tt.queryTimeout)
+					defer qcancel()
+				}
+
+				_, err := c.Query(qctx, "player/get_volume?pid=1", nil)
+				errC <- err
+			}()
+			<-received
+
+			time.AfterFunc(50*time.Millisecond, func() { close(respond) })
+
+			sctx, scancel := context.WithTimeout(ctx, tt.timeout)
+			defer scancel()
+
+			serr := c.Shutdown(sctx)
+			err = <-errC
+			if !errors.Is(serr, tt.shutdownErr) {
+				t.Fatalf("unexpected Shutdown error: %v", serr)
+			}
+			if !errors.Is(err, tt.queryErr) {
+				t.Fatalf("unexpected in-flight query error: %v", err)
+			}
+
+			// No new commands are accepted.
+			if err := c.System.Heartbeat(ctx); !errors.Is(err, heos.ErrClientClosed) {
+				t.Fatalf("expected heos.ErrClientClosed, but got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package heos
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep is a sleep timer: it stops playback on the player specified by pid
+// after the duration d. If fade is non-zero, the volume is first faded to zero
+// over the final fade of d, and the original volume is restored after
+// playback stops so that the player is not silent the next time it is used.
+//
+// Sleep blocks until playback is stopped, and can be canceled using the
+// context. If the context is canceled during a fade, the volume is left at its
+// current level.
+func (p *Players) Sleep(ctx context.Context, pid PlayerID, d, fade time.Duration) error {
+	return sleepTimer(ctx, d, fade,
+		func() (int, error) { return p.GetVolume(ctx, pid) },
+		func(level int) error { return p.SetVolume(ctx, pid, level) },
+		func(level int) error { return p.FadeVolume(ctx, pid, level, fade) },
+		func() error { return p.SetPlayState(ctx, pid, StateStop) },
+	)
+}
+
+// Sleep is like Players.Sleep, but fades the volume of the group specified by
+// gid and stops playback on the group.
+func (g *Groups) Sleep(ctx context.Context, gid GroupID, d, fade time.Duration) error {
+	return sleepTimer(ctx, d, fade,
+		func() (int, error) { return g.GetVolume(ctx, gid) },
+		func(level int) error { return g.SetVolume(ctx, gid, level) },
+		func(level int) error { return g.FadeVolume(ctx, gid, level, fade) },
+		// A group's ID is the player ID of its leader, which controls
+		// playback for the group.
+		func() error { return g.c.Players.SetPlayState(ctx, PlayerID(gid), StateStop) },
+	)
+}
+
+// sleepTimer implements sleep timers for players and groups.
+func sleepTimer(ctx context.Context, d, fade time.Duration, get func() (int, error), set, fadeTo func(level int) error, stop func() error) error {
+	if fade > d {
+		fade = d
+	}
+
+	if err := sleep(ctx, d-fade); err != nil {
+		return err
+	}
+
+	if fade == 0 {
+		return stop()
+	}
+
+	level, err := get()
+	if err != nil {
+		return err
+	}
+	if err := fadeTo(0); err != nil {
+		return err
+	}
+	if err := stop(); err != nil {
+		return err
+	}
+
+	return set(level)
+}
@@ -0,0 +1,69 @@
+package heos_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlayersSleep(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []string
+	)
+
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, req)
+
+		if req == "heos://player/get_volume?pid=1\r\n" {
+			return response("player/get_volume", "pid=1&level=2", nil)
+		}
+
+		return ack(req)
+	})
+	defer done()
+
+	start := time.Now()
+	if err := c.Players.Sleep(ctx, 1, 300*time.Millisecond, 200*time.Millisecond); err != nil {
+		t.Fatalf("failed to sleep: %v", err)
+	}
+	if took := time.Since(start); took < 300*time.Millisecond {
+		t.Fatalf("sleep timer finished early: %v", took)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Fade out, stop, and then restore the original volume.
+	want := []string{
+		"heos://player/get_volume?pid=1\r\n",
+		"heos://player/get_volume?pid=1\r\n",
+		"heos://player/set_volume?pid=1&level=1\r\n",
+		"heos://player/set_volume?pid=1&level=0\r\n",
+		"heos://player/set_play_state?pid=1&state=stop\r\n",
+		"heos://player/set_volume?pid=1&level=2\r\n",
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlayersSleepCanceled(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		panicf("unexpected client request: %q", req)
+		return nil
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.Players.Sleep(ctx, 1, time.Hour, 0); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+}
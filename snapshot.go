@@ -0,0 +1,144 @@
+package heos
+
+import "context"
+
+// A Snapshot is the captured playback state of a player or group, which can
+// be restored later, such as after interrupting playback for an announcement.
+// Snapshots can be encoded as JSON.
+type Snapshot struct {
+	// PID is the player whose playback was captured. For a group, PID is the
+	// group's leader.
+	PID PlayerID `json:"pid"`
+
+	// GID is the group whose volume was captured, or zero for a player.
+	GID GroupID `json:"gid,omitempty"`
+
+	// Volume and Mute are the volume level and mute state of the player, or
+	// of the group if GID is set.
+	Volume int  `json:"volume"`
+	Mute   bool `json:"mute"`
+
+	// State is one of StatePlay, StatePause, or StateStop. If empty, only
+	// volume and mute are restored, such as for a group member whose playback
+	// is controlled by its leader.
+	State string `json:"state,omitempty"`
+
+	// Media is the media which was playing, if any.
+	Media *NowPlayingMedia `json:"media,omitempty"`
+}
+
+// Snapshot captures the volume, mute, play state, and media of the player
+// specified by pid, so that they can be restored using Restore.
+func (p *Players) Snapshot(ctx context.Context, pid PlayerID) (*Snapshot, error) {
+	s, err := p.volumeSnapshot(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.snapshot(ctx, s)
+}
+
+// volumeSnapshot captures only the volume and mute state of the player
+// specified by pid.
+func (p *Players) volumeSnapshot(ctx context.Context, pid PlayerID) (*Snapshot, error) {
+	volume, err := p.GetVolume(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	mute, err := p.GetMute(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		PID:    pid,
+		Volume: volume,
+		Mute:   mute,
+	}, nil
+}
+
+// Snapshot captures the volume and mute state of the group specified by gid,
+// and the play state and media of its leader, so that they can be restored
+// using Players.Restore.
+func (g *Groups) Snapshot(ctx context.Context, gid GroupID) (*Snapshot, error) {
+	volume, err := g.GetVolume(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	mute, err := g.GetMute(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	// A group's ID is the player ID of its leader.
+	return g.c.Players.snapshot(ctx, &Snapshot{
+		PID:    PlayerID(gid),
+		GID:    gid,
+		Volume: volume,
+		Mute:   mute,
+	})
+}
+
+// snapshot captures the playback state of s.PID into s.
+func (p *Players) snapshot(ctx context.Context, s *Snapshot) (*Snapshot, error) {
+	state, err := p.GetPlayState(ctx, s.PID)
+	if err != nil {
+		return nil, err
+	}
+	s.State = state
+
+	npm, err := p.GetNowPlayingMedia(ctx, s.PID)
+	if err != nil {
+		return nil, err
+	}
+	if npm.Type != "" {
+		s.Media = npm
+	}
+
+	return s, nil
+}
+
+// Restore restores the playback state captured by a Snapshot. Media is
+// resumed from the player's queue when possible, or by playing the captured
+// station again. The playback position within a song cannot be restored,
+// since HEOS devices do not support seeking.
+func (p *Players) Restore(ctx context.Context, s *Snapshot) error {
+	if s.GID != 0 {
+		if err := p.c.Groups.SetMute(ctx, s.GID, s.Mute); err != nil {
+			return err
+		}
+		if err := p.c.Groups.SetVolume(ctx, s.GID, s.Volume); err != nil {
+			return err
+		}
+	} else {
+		if err := p.SetMute(ctx, s.PID, s.Mute); err != nil {
+			return err
+		}
+		if err := p.SetVolume(ctx, s.PID, s.Volume); err != nil {
+			return err
+		}
+	}
+
+	if s.State == "" {
+		return nil
+	}
+
+	if m := s.Media; m != nil {
+		var err error
+		switch {
+		case m.QID > 0:
+			err = p.PlayQueue(ctx, s.PID, m.QID)
+		case m.Type == "station" && m.MID != "":
+			// The station's container is not reported with the media.
+			err = p.c.Browse.PlayStream(ctx, s.PID, m.SID, "", m.MID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Resuming media starts playback, so set the captured state last.
+	return p.SetPlayState(ctx, s.PID, s.State)
+}
@@ -0,0 +1,80 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestPlayersSnapshotRestore(t *testing.T) {
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+
+		switch req {
+		case "heos://player/get_volume?pid=1\r\n":
+			return response("player/get_volume", "pid=1&level=25", nil)
+		case "heos://player/get_mute?pid=1\r\n":
+			return response("player/get_mute", "pid=1&state=off", nil)
+		case "heos://player/get_play_state?pid=1\r\n":
+			return response("player/get_play_state", "pid=1&state=pause", nil)
+		case "heos://player/get_now_playing_media?pid=1\r\n":
+			return response("player/get_now_playing_media", "pid=1", heos.NowPlayingMedia{
+				Type: "song",
+				Song: "Song",
+				QID:  3,
+				SID:  1024,
+			})
+		default:
+			return ack(req)
+		}
+	})
+	defer done()
+
+	s, err := c.Players.Snapshot(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+
+	// Snapshots survive a round trip through JSON.
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal snapshot: %v", err)
+	}
+	var got heos.Snapshot
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to unmarshal snapshot: %v", err)
+	}
+
+	want := heos.Snapshot{
+		PID:    1,
+		Volume: 25,
+		State:  heos.StatePause,
+		Media: &heos.NowPlayingMedia{
+			Type: "song",
+			Song: "Song",
+			QID:  3,
+			SID:  1024,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected snapshot (-want +got):\n%s", diff)
+	}
+
+	reqs = nil
+	if err := c.Players.Restore(ctx, &got); err != nil {
+		t.Fatalf("failed to restore snapshot: %v", err)
+	}
+
+	wantReqs := []string{
+		"heos://player/set_mute?pid=1&state=off\r\n",
+		"heos://player/set_volume?pid=1&level=25\r\n",
+		"heos://player/play_queue?pid=1&qid=3\r\n",
+		"heos://player/set_play_state?pid=1&state=pause\r\n",
+	}
+	if diff := cmp.Diff(wantReqs, reqs); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
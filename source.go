@@ -0,0 +1,131 @@
+package heos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A Source is a handle to a music source, bound to its source ID. Sources are
+// typically located using Browse helpers such as Browse.TuneIn, so that
+// callers need not hard-code source IDs.
+type Source struct {
+	c    *Client
+	info MusicSource
+}
+
+// Info returns information about the Source.
+func (s *Source) Info() MusicSource { return s.info }
+
+// SID returns the source ID the Source is bound to.
+func (s *Source) SID() SourceID { return s.info.SID }
+
+// Browse returns the items at the root of the Source, or in the container
+// specified by cid if cid is not empty.
+func (s *Source) Browse(ctx context.Context, cid string) ([]MediaItem, error) {
+	return s.c.Browse.Browse(ctx, s.info.SID, cid)
+}
+
+// A wellKnownSource describes a music source which can be located by name or
+// its typical source ID.
+type wellKnownSource struct {
+	sid   SourceID
+	names []string
+}
+
+// Well-known music sources. Names are matched first, because source IDs for
+// music services may differ between devices and accounts.
+var (
+	sourceTuneIn      = wellKnownSource{sid: 3, names: []string{"TuneIn"}}
+	sourceSpotify     = wellKnownSource{sid: 4, names: []string{"Spotify"}}
+	sourceTidal       = wellKnownSource{sid: 10, names: []string{"Tidal"}}
+	sourceAmazonMusic = wellKnownSource{sid: 13, names: []string{"Amazon Music", "Amazon"}}
+	sourceLocalMedia  = wellKnownSource{sid: SourceLocalMedia, names: []string{"Local Music", "Local Media"}}
+)
+
+// TuneIn locates the TuneIn music source.
+func (b *Browse) TuneIn(ctx context.Context) (*Source, error) {
+	return b.wellKnown(ctx, sourceTuneIn)
+}
+
+// Spotify locates the Spotify music source.
+func (b *Browse) Spotify(ctx context.Context) (*Source, error) {
+	return b.wellKnown(ctx, sourceSpotify)
+}
+
+// Tidal locates the Tidal music source.
+func (b *Browse) Tidal(ctx context.Context) (*Source, error) {
+	return b.wellKnown(ctx, sourceTidal)
+}
+
+// AmazonMusic locates the Amazon Music music source.
+func (b *Browse) AmazonMusic(ctx context.Context) (*Source, error) {
+	return b.wellKnown(ctx, sourceAmazonMusic)
+}
+
+// LocalMedia locates the source containing local media servers, such as DLNA
+// servers and USB drives.
+func (b *Browse) LocalMedia(ctx context.Context) (*Source, error) {
+	return b.wellKnown(ctx, sourceLocalMedia)
+}
+
+// LocalMediaServers returns Source handles for each of the local media servers,
+// such as DLNA servers and USB drives, within the local media source. Unlike
+// other sources, each server is itself a source, and its contents are browsed
+// using its own source ID.
+func (b *Browse) LocalMediaServers(ctx context.Context) ([]*Source, error) {
+	mis, err := b.Browse(ctx, SourceLocalMedia, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]*Source, 0, len(mis))
+	for _, mi := range mis {
+		if mi.SID == 0 {
+			continue
+		}
+
+		ss = append(ss, &Source{
+			c: b.c,
+			info: MusicSource{
+				Name:     mi.Name,
+				ImageURL: mi.ImageURL,
+				Type:     mi.Type,
+				SID:      mi.SID,
+			},
+		})
+	}
+
+	return ss, nil
+}
+
+// FindSource locates the music source whose name matches name, ignoring case.
+func (b *Browse) FindSource(ctx context.Context, name string) (*Source, error) {
+	return b.wellKnown(ctx, wellKnownSource{names: []string{name}})
+}
+
+// wellKnown locates the music source described by wk.
+func (b *Browse) wellKnown(ctx context.Context, wk wellKnownSource) (*Source, error) {
+	mss, err := b.GetMusicSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range wk.names {
+		for _, ms := range mss {
+			if strings.EqualFold(ms.Name, name) {
+				return &Source{c: b.c, info: ms}, nil
+			}
+		}
+	}
+
+	if wk.sid != 0 {
+		for _, ms := range mss {
+			if ms.SID == wk.sid {
+				return &Source{c: b.c, info: ms}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("heos: music source %q not found", wk.names[0])
+}
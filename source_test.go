@@ -0,0 +1,152 @@
+package heos_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestBrowseWellKnownSources(t *testing.T) {
+	sources := []heos.MusicSource{
+		// Source IDs may differ from their typical values.
+		{Name: "tunein", Type: "music_service", SID: 30},
+		{Name: "Amazon", Type: "music_service", SID: 13},
+		{Name: "Local Music", Type: "heos_server", SID: 1024},
+	}
+
+	var reqs []string
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		reqs = append(reqs, req)
+		switch req {
+		case "heos://browse/get_music_sources\r\n":
+			return response("browse/get_music_sources", "", sources)
+		case "heos://browse/browse?sid=30\r\n":
+			return response("browse/browse", "sid=30", []heos.MediaItem{})
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	s, err := c.Browse.TuneIn(ctx)
+	if err != nil {
+		t.Fatalf("failed to find TuneIn: %v", err)
+	}
+	if diff := cmp.Diff(heos.SourceID(30), s.SID()); diff != "" {
+		t.Fatalf("unexpected TuneIn source ID (-want +got):\n%s", diff)
+	}
+	if _, err := s.Browse(ctx, ""); err != nil {
+		t.Fatalf("failed to browse TuneIn: %v", err)
+	}
+
+	s, err = c.Browse.AmazonMusic(ctx)
+	if err != nil {
+		t.Fatalf("failed to find Amazon Music: %v", err)
+	}
+	if diff := cmp.Diff(sources[1], s.Info(), ignoreRaw); diff != "" {
+		t.Fatalf("unexpected Amazon Music source (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.Browse.Spotify(ctx); err == nil {
+		t.Fatal("expected an error for missing Spotify source, but none occurred")
+	}
+}
+
+func TestBrowseLocalMediaServers(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://browse/browse?sid=1024\r\n":
+			return response("browse/browse", "sid=1024", json.RawMessage(`[
+				{"name": "NAS", "image_url": "", "type": "heos_server", "sid": -1234},
+				{"name": "USB", "image_url": "", "type": "heos_server", "sid": 5678}
+			]`))
+		case "heos://browse/browse?sid=-1234\r\n":
+			return response("browse/browse", "sid=-1234", json.RawMessage(`[
+				{"container": "yes", "type": "container", "cid": "music", "playable": "no", "name": "Music", "image_url": ""}
+			]`))
+		case "heos://browse/browse?sid=-1234&cid=music\r\n":
+			return response("browse/browse", "sid=-1234&cid=music", json.RawMessage(`[
+				{"container": "no", "type": "song", "mid": "track1", "playable": "yes", "name": "Track 1", "artist": "Artist", "album": "Album", "image_url": ""}
+			]`))
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	// The root of the local media source contains servers as nested sources.
+	mis, err := c.Browse.Browse(ctx, heos.SourceLocalMedia, "")
+	if err != nil {
+		t.Fatalf("failed to browse local media: %v", err)
+	}
+	if !mis[0].Container || mis[0].SID != -1234 {
+		t.Fatalf("expected nested source container, but got: %+v", mis[0])
+	}
+
+	ss, err := c.Browse.LocalMediaServers(ctx)
+	if err != nil {
+		t.Fatalf("failed to get local media servers: %v", err)
+	}
+	if diff := cmp.Diff(2, len(ss)); diff != "" {
+		t.Fatalf("unexpected number of servers (-want +got):\n%s", diff)
+	}
+
+	root, err := ss[0].Browse(ctx, "")
+	if err != nil {
+		t.Fatalf("failed to browse server: %v", err)
+	}
+
+	songs, err := ss[0].Browse(ctx, root[0].CID)
+	if err != nil {
+		t.Fatalf("failed to browse server container: %v", err)
+	}
+
+	want := []heos.MediaItem{{
+		Playable: true,
+		Type:     "song",
+		Name:     "Track 1",
+		Artist:   "Artist",
+		Album:    "Album",
+		MID:      "track1",
+	}}
+	if diff := cmp.Diff(want, songs, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected songs (-want +got):\n%s", diff)
+	}
+}
+
+func TestBrowseGetMusicSourcesAvailability(t *testing.T) {
+	c, ctx, done := testClient(t, func(req string) interface{} {
+		switch req {
+		case "heos://browse/get_music_sources\r\n":
+			return json.RawMessage(`{"heos": {"command": "browse/get_music_sources", "result": "success", "message": ""}, "payload": [
+				{"name": "Pandora", "type": "music_service", "sid": 1, "available": "false"},
+				{"name": "Spotify", "type": "music_service", "sid": 4, "available": "true", "service_username": "user@example.com"},
+				{"name": "Local Music", "type": "heos_server", "sid": 1024}
+			]}`)
+		default:
+			panicf("unexpected client request: %q", req)
+			return nil
+		}
+	})
+	defer done()
+
+	mss, err := c.Browse.GetMusicSources(ctx)
+	if err != nil {
+		t.Fatalf("failed to get music sources: %v", err)
+	}
+
+	want := []heos.MusicSource{
+		{Name: "Pandora", Type: "music_service", SID: 1},
+		{Name: "Spotify", Type: "music_service", SID: 4, Available: true, ServiceUsername: "user@example.com"},
+		// Availability is not reported.
+		{Name: "Local Music", Type: "heos_server", SID: 1024, Available: true},
+	}
+
+	if diff := cmp.Diff(want, mss, ignoreRaw); diff != "" {
+		t.Fatalf("unexpected music sources (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,190 @@
+package heos
+
+import (
+	"context"
+	"time"
+)
+
+// A Speaker is a handle to a HEOS player which may belong to a group. Volume
+// commands issued to a Speaker apply to its group while the player is
+// grouped, and to the player alone otherwise. Playback commands always apply
+// to the group leader, which controls playback for the entire group.
+//
+// A Speaker determines whether its player is grouped each time a command is
+// issued, at the cost of an additional query, so it remains correct as
+// groups change. Use Player or Group to control a player or group directly.
+// Create Speakers using Client.Speaker.
+type Speaker struct {
+	c   *Client
+	pid PlayerID
+}
+
+// Speaker returns a Speaker handle for the player specified by pid.
+func (c *Client) Speaker(pid PlayerID) *Speaker {
+	return &Speaker{c: c, pid: pid}
+}
+
+// PID returns the player ID the Speaker is bound to.
+func (s *Speaker) PID() PlayerID { return s.pid }
+
+// Group returns a Group handle for the group the Speaker's player belongs to,
+// or nil if the player is not grouped.
+func (s *Speaker) Group(ctx context.Context) (*Group, error) {
+	gid, ok, err := s.group(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return s.c.Group(gid), nil
+}
+
+// group returns the group ID of the Speaker's player and true if the player is
+// grouped.
+func (s *Speaker) group(ctx context.Context) (GroupID, bool, error) {
+	pi, err := s.c.Players.GetPlayerInfo(ctx, s.pid)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Ungrouped players report no group ID.
+	return pi.GID, pi.GID != 0, nil
+}
+
+// leader returns the player ID which controls playback for the Speaker.
+func (s *Speaker) leader(ctx context.Context) (PlayerID, error) {
+	gid, ok, err := s.group(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return s.pid, nil
+	}
+
+	// A group's ID is the player ID of its leader.
+	return PlayerID(gid), nil
+}
+
+// GetVolume returns the volume level of the Speaker, in the range 0-100.
+func (s *Speaker) GetVolume(ctx context.Context) (int, error) {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return 0, err
+	case ok:
+		return s.c.Groups.GetVolume(ctx, gid)
+	default:
+		return s.c.Players.GetVolume(ctx, s.pid)
+	}
+}
+
+// SetVolume sets the volume level of the Speaker, in the range 0-100.
+func (s *Speaker) SetVolume(ctx context.Context, level int) error {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return s.c.Groups.SetVolume(ctx, gid, level)
+	default:
+		return s.c.Players.SetVolume(ctx, s.pid, level)
+	}
+}
+
+// VolumeUp increases the volume level of the Speaker by step, in the range
+// 1-10.
+func (s *Speaker) VolumeUp(ctx context.Context, step int) error {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return s.c.Groups.VolumeUp(ctx, gid, step)
+	default:
+		return s.c.Players.VolumeUp(ctx, s.pid, step)
+	}
+}
+
+// VolumeDown decreases the volume level of the Speaker by step, in the range
+// 1-10.
+func (s *Speaker) VolumeDown(ctx context.Context, step int) error {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return s.c.Groups.VolumeDown(ctx, gid, step)
+	default:
+		return s.c.Players.VolumeDown(ctx, s.pid, step)
+	}
+}
+
+// FadeVolume gradually transitions the volume level of the Speaker to level
+// over the duration d. See Players.FadeVolume for details.
+func (s *Speaker) FadeVolume(ctx context.Context, level int, d time.Duration) error {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return s.c.Groups.FadeVolume(ctx, gid, level, d)
+	default:
+		return s.c.Players.FadeVolume(ctx, s.pid, level, d)
+	}
+}
+
+// GetMute reports whether the Speaker is muted.
+func (s *Speaker) GetMute(ctx context.Context) (bool, error) {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return false, err
+	case ok:
+		return s.c.Groups.GetMute(ctx, gid)
+	default:
+		return s.c.Players.GetMute(ctx, s.pid)
+	}
+}
+
+// SetMute mutes or unmutes the Speaker.
+func (s *Speaker) SetMute(ctx context.Context, mute bool) error {
+	gid, ok, err := s.group(ctx)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return s.c.Groups.SetMute(ctx, gid, mute)
+	default:
+		return s.c.Players.SetMute(ctx, s.pid, mute)
+	}
+}
+
+// GetPlayState returns the play state of the Speaker.
+func (s *Speaker) GetPlayState(ctx context.Context) (string, error) {
+	pid, err := s.leader(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return s.c.Players.GetPlayState(ctx, pid)
+}
+
+// SetPlayState sets the play state of the Speaker.
+func (s *Speaker) SetPlayState(ctx context.Context, state string) error {
+	pid, err := s.leader(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.c.Players.SetPlayState(ctx, pid, state)
+}
+
+// GetNowPlayingMedia returns information about the media playing on the
+// Speaker.
+func (s *Speaker) GetNowPlayingMedia(ctx context.Context) (*NowPlayingMedia, error) {
+	pid, err := s.leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.c.Players.GetNowPlayingMedia(ctx, pid)
+}
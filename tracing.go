@@ -0,0 +1,85 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// A Tracer creates spans which trace the commands issued by a Client and the
+// reconnects performed by an EventStream, so that HEOS calls appear in the
+// distributed traces of a larger service. A Tracer can be implemented using
+// OpenTelemetry or a similar library, such as:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (ot otelTracer) StartSpan(ctx context.Context, name string) (context.Context, heos.Span) {
+//		ctx, span := ot.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+// Command spans are named after the command, such as "heos
+// player/get_volume", and carry the attributes "heos.command_group" (such as
+// "player"), "heos.command" (such as "get_volume"), and if applicable,
+// "heos.pid", "heos.result", and "heos.eid". Reconnect spans are named "heos
+// reconnect" and carry the attribute "heos.attempts".
+type Tracer interface {
+	// StartSpan starts a span with the specified name as a child of any span
+	// in ctx, and returns a context containing the new span.
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// A Span is an operation traced by a Tracer.
+type Span interface {
+	// SetAttributes sets attributes which describe the operation.
+	SetAttributes(attrs ...slog.Attr)
+
+	// End completes the span, with the error which caused the operation to
+	// fail, if any.
+	End(err error)
+}
+
+// startCommandSpan starts a span for the command in u if the Client has a
+// Tracer, returning a nil Span otherwise.
+func (c *Client) startCommandSpan(ctx context.Context, u *url.URL) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := c.tracer.StartSpan(ctx, "heos "+u.Path)
+
+	group, command, _ := strings.Cut(u.Path, "/")
+	attrs := []slog.Attr{
+		slog.String("heos.command_group", group),
+		slog.String("heos.command", command),
+	}
+	if pid, err := strconv.Atoi(u.Query().Get("pid")); err == nil {
+		attrs = append(attrs, slog.Int("heos.pid", pid))
+	}
+	span.SetAttributes(attrs...)
+
+	return ctx, span
+}
+
+// endCommandSpan ends span, if not nil, with the result of a command.
+func endCommandSpan(span Span, cmd *Command, err error) {
+	if span == nil {
+		return
+	}
+
+	var herr *Error
+	switch {
+	case errors.As(err, &herr):
+		span.SetAttributes(
+			slog.String("heos.result", string(ResultFail)),
+			slog.Int("heos.eid", herr.EID),
+		)
+	case err == nil && cmd != nil:
+		span.SetAttributes(slog.String("heos.result", string(cmd.HEOS.Result)))
+	}
+
+	span.End(err)
+}
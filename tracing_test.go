@@ -0,0 +1,137 @@
+package heos_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestClientTracer(t *testing.T) {
+	tr := &testTracer{}
+	c, ctx, done := testClientConfig(t, &heos.Config{Tracer: tr}, func(req string) interface{} {
+		if req == "heos://player/get_volume?pid=1\r\n" {
+			return response("player/get_volume", "pid=1&level=20", nil)
+		}
+
+		return json.RawMessage(`{"heos": {"command": "player/set_volume", "result": "fail", "message": "eid=9&text=Parameter out of range&pid=1"}}`)
+	})
+	defer done()
+
+	// Spans are children of the caller's span.
+	ctx = context.WithValue(ctx, spanKey{}, "parent")
+
+	if _, err := c.Players.GetVolume(ctx, 1); err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+	if err := c.Players.SetVolume(ctx, 1, 50); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	want := []testSpan{
+		{
+			Name: "heos system/heart_beat",
+			Attrs: map[string]string{
+				"heos.command_group": "system",
+				"heos.command":       "heart_beat",
+				"heos.result":        "success",
+			},
+		},
+		{
+			Name: "heos system/prettify_json_response",
+			Attrs: map[string]string{
+				"heos.command_group": "system",
+				"heos.command":       "prettify_json_response",
+				"heos.result":        "success",
+			},
+		},
+		{
+			Name:   "heos player/get_volume",
+			Parent: "parent",
+			Attrs: map[string]string{
+				"heos.command_group": "player",
+				"heos.command":       "get_volume",
+				"heos.pid":           "1",
+				"heos.result":        "success",
+			},
+		},
+		{
+			Name:   "heos player/set_volume",
+			Parent: "parent",
+			Attrs: map[string]string{
+				"heos.command_group": "player",
+				"heos.command":       "set_volume",
+				"heos.pid":           "1",
+				"heos.result":        "fail",
+				"heos.eid":           "9",
+			},
+			Failed: true,
+		},
+	}
+
+	if diff := cmp.Diff(want, tr.ended()); diff != "" {
+		t.Fatalf("unexpected spans (-want +got):\n%s", diff)
+	}
+}
+
+var _ heos.Tracer = &testTracer{}
+
+// A testTracer is a heos.Tracer which records ended spans.
+type testTracer struct {
+	mu    sync.Mutex
+	spans []testSpan
+}
+
+// A spanKey is the context key for the name of a testTracer's current span.
+type spanKey struct{}
+
+func (tr *testTracer) StartSpan(ctx context.Context, name string) (context.Context, heos.Span) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	return context.WithValue(ctx, spanKey{}, name), &testSpanRecorder{
+		tr: tr,
+		s: testSpan{
+			Name:   name,
+			Parent: parent,
+			Attrs:  make(map[string]string),
+		},
+	}
+}
+
+// ended returns the spans which have ended.
+func (tr *testTracer) ended() []testSpan {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	return append([]testSpan(nil), tr.spans...)
+}
+
+// A testSpan is a span recorded by a testTracer.
+type testSpan struct {
+	Name, Parent string
+	Attrs        map[string]string
+	Failed       bool
+}
+
+// A testSpanRecorder is a heos.Span which reports to a testTracer when ended.
+type testSpanRecorder struct {
+	tr *testTracer
+	s  testSpan
+}
+
+func (r *testSpanRecorder) SetAttributes(attrs ...slog.Attr) {
+	for _, a := range attrs {
+		r.s.Attrs[a.Key] = a.Value.String()
+	}
+}
+
+func (r *testSpanRecorder) End(err error) {
+	r.s.Failed = err != nil
+
+	r.tr.mu.Lock()
+	defer r.tr.mu.Unlock()
+	r.tr.spans = append(r.tr.spans, r.s)
+}
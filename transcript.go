@@ -0,0 +1,189 @@
+package heos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// A Direction is the direction of data on a connection to a HEOS device.
+type Direction string
+
+// Possible Direction values.
+const (
+	DirectionSend    Direction = "send"
+	DirectionReceive Direction = "receive"
+)
+
+// A Tap receives copies of all raw data sent and received by a Client, such as
+// for capturing protocol traces. b must not be retained or modified after the
+// Tap returns. A Tap is called while the Client is reading from or writing to
+// its connection, so it should return quickly.
+type Tap func(d Direction, t time.Time, b []byte)
+
+// A record is a single entry in a protocol transcript. Data holds the exact
+// bytes sent or received, and is base64 encoded in JSON so that malformed
+// device output is preserved byte for byte.
+type record struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Data      []byte    `json:"data"`
+}
+
+// Record wraps conn so that all bytes written to and read from the connection
+// are appended to w as a transcript of newline-delimited JSON records. The
+// transcript can be served back to a Client using Replay, which is useful for
+// capturing reproducible bug reports from real hardware:
+//
+//	f, _ := os.Create("transcript.json")
+//	conn, _ := net.Dial("tcp", "192.168.1.10:1255")
+//	c, _ := heos.New(ctx, heos.Record(conn, f), nil)
+func Record(conn net.Conn, w io.Writer) net.Conn {
+	var (
+		mu  sync.Mutex
+		enc = json.NewEncoder(w)
+	)
+
+	return &tapConn{
+		Conn: conn,
+		tap: func(d Direction, t time.Time, b []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			// Errors are ignored, as they are for any Tap.
+			_ = enc.Encode(record{
+				Time:      t,
+				Direction: d,
+				Data:      b,
+			})
+		},
+	}
+}
+
+var _ net.Conn = &tapConn{}
+
+// A tapConn is a net.Conn which passes its traffic to a Tap.
+type tapConn struct {
+	net.Conn
+	tap Tap
+}
+
+// Read implements io.Reader.
+func (c *tapConn) Read(b []byte) (int, error) { return tapRead(c.Conn, c.tap, b) }
+
+// Write implements io.Writer.
+func (c *tapConn) Write(b []byte) (int, error) { return tapWrite(c.Conn, c.tap, b) }
+
+var _ Transport = &tapTransport{}
+
+// A tapTransport is a Transport which passes its traffic to a Tap.
+type tapTransport struct {
+	Transport
+	tap Tap
+}
+
+// Read implements io.Reader.
+func (t *tapTransport) Read(b []byte) (int, error) { return tapRead(t.Transport, t.tap, b) }
+
+// Write implements io.Writer.
+func (t *tapTransport) Write(b []byte) (int, error) { return tapWrite(t.Transport, t.tap, b) }
+
+// tapRead reads from r into b, passing the data read to tap.
+func tapRead(r io.Reader, tap Tap, b []byte) (int, error) {
+	n, err := r.Read(b)
+	if n > 0 {
+		tap(DirectionReceive, time.Now(), b[:n])
+	}
+
+	return n, err
+}
+
+// tapWrite writes b to w, passing the data written to tap.
+func tapWrite(w io.Writer, tap Tap, b []byte) (int, error) {
+	n, err := w.Write(b)
+	if n > 0 {
+		tap(DirectionSend, time.Now(), b[:n])
+	}
+
+	return n, err
+}
+
+// Replay reads a transcript produced by Record from r, and returns a net.Conn
+// which serves the recorded responses to a Client.
+//
+// Each request written to the connection must match the next request in the
+// transcript, and is answered with the data that was received after that
+// request was originally sent. If a request does not match the transcript or
+// the transcript is exhausted, the connection is closed.
+func Replay(r io.Reader) (net.Conn, error) {
+	// Coalesce the transcript into alternating runs of sent and received data,
+	// since the original reads and writes may not have lined up with the
+	// boundaries of each message.
+	var exchanges []exchange
+	dec := json.NewDecoder(r)
+	for {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, fmt.Errorf("heos: failed to decode transcript: %v", err)
+		}
+
+		switch rec.Direction {
+		case DirectionSend:
+			if len(exchanges) == 0 || len(exchanges[len(exchanges)-1].receive) > 0 {
+				exchanges = append(exchanges, exchange{})
+			}
+			exchanges[len(exchanges)-1].send = append(exchanges[len(exchanges)-1].send, rec.Data...)
+		case DirectionReceive:
+			if len(exchanges) == 0 {
+				// Data received before any request was sent.
+				exchanges = append(exchanges, exchange{})
+			}
+			exchanges[len(exchanges)-1].receive = append(exchanges[len(exchanges)-1].receive, rec.Data...)
+		default:
+			return nil, fmt.Errorf("heos: unknown transcript record direction: %q", rec.Direction)
+		}
+	}
+
+	client, server := net.Pipe()
+	go replay(server, exchanges)
+
+	return client, nil
+}
+
+// An exchange is a request and the response data which followed it.
+type exchange struct {
+	send, receive []byte
+}
+
+// replay serves exchanges over conn until the transcript is exhausted or the
+// client sends a request which does not match.
+func replay(conn net.Conn, exchanges []exchange) {
+	defer conn.Close()
+
+	b := make([]byte, 256)
+	for _, ex := range exchanges {
+		// Consume the request, verifying that it matches the transcript as
+		// each chunk arrives.
+		want := ex.send
+		for len(want) > 0 {
+			n, err := conn.Read(b)
+			if err != nil || n > len(want) || !bytes.Equal(b[:n], want[:n]) {
+				return
+			}
+
+			want = want[n:]
+		}
+
+		if _, err := conn.Write(ex.receive); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package heos_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+
+		// Answer the handshake and a heartbeat, then wait for the client to
+		// hang up.
+		enc := json.NewEncoder(server)
+		b := make([]byte, 128)
+		for i := 0; i < 3; i++ {
+			n, err := server.Read(b)
+			if err != nil {
+				panicf("failed to read request: %v", err)
+			}
+
+			if err := enc.Encode(ack(string(b[:n]))); err != nil {
+				panicf("failed to write response: %v", err)
+			}
+		}
+
+		_, _ = server.Read(b)
+	}()
+
+	// Record a handshake and one explicit heartbeat.
+	var buf bytes.Buffer
+	c, err := heos.New(ctx, heos.Record(client, &buf), nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+	_ = c.Close()
+
+	if n := strings.Count(buf.String(), "\n"); n != 6 {
+		t.Fatalf("expected 6 transcript records, but got %d:\n%s", n, buf.String())
+	}
+
+	// Now replay the same session with no device present.
+	conn, err := heos.Replay(&buf)
+	if err != nil {
+		t.Fatalf("failed to replay transcript: %v", err)
+	}
+
+	c, err = heos.New(ctx, conn, nil)
+	if err != nil {
+		t.Fatalf("failed to create replay client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send replayed heartbeat: %v", err)
+	}
+
+	// The transcript is exhausted, so further queries must fail.
+	if err := c.System.Heartbeat(ctx); err == nil {
+		t.Fatal("expected an error after transcript was exhausted, but none occurred")
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Data is base64 encoded: "heos://player/get_players\r\n" and "{}\r\n".
+	const transcript = `{"time":"2019-10-01T00:00:00Z","direction":"send","data":"aGVvczovL3BsYXllci9nZXRfcGxheWVycw0K"}
+{"time":"2019-10-01T00:00:00Z","direction":"receive","data":"e30NCg=="}
+`
+
+	conn, err := heos.Replay(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatalf("failed to replay transcript: %v", err)
+	}
+
+	// The handshake does not match the transcript.
+	if _, err := heos.New(ctx, conn, nil); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestRecordReplayInvalidUTF8(t *testing.T) {
+	// Malformed device output must be replayed exactly as it was received.
+	want := []byte("\xff\xfe{\"heos\": {}}\r\n")
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		_, _ = server.Write(want)
+	}()
+
+	var buf bytes.Buffer
+	conn := heos.Record(client, &buf)
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("failed to read recorded data: %v", err)
+	}
+	_ = conn.Close()
+
+	rconn, err := heos.Replay(&buf)
+	if err != nil {
+		t.Fatalf("failed to replay transcript: %v", err)
+	}
+	defer rconn.Close()
+
+	got = make([]byte, len(want))
+	if _, err := io.ReadFull(rconn, got); err != nil {
+		t.Fatalf("failed to read replayed data: %v", err)
+	}
+
+	if !bytes.Equal(want, got) {
+		t.Fatalf("unexpected replayed data:\nwant: %q\n got: %q", want, got)
+	}
+}
+
+func TestClientTap(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		sent  bytes.Buffer
+		recvd bytes.Buffer
+		last  time.Time
+	)
+
+	cfg := &heos.Config{
+		Tap: func(d heos.Direction, now time.Time, b []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if now.Before(last) {
+				panicf("tap timestamps went backwards: %v < %v", now, last)
+			}
+			last = now
+
+			switch d {
+			case heos.DirectionSend:
+				sent.Write(b)
+			case heos.DirectionReceive:
+				recvd.Write(b)
+			default:
+				panicf("unexpected direction: %q", d)
+			}
+		},
+	}
+
+	c, ctx, done := testClientConfig(t, cfg, func(req string) interface{} {
+		return ack(req)
+	})
+	defer done()
+
+	if err := c.System.Heartbeat(ctx); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The handshake and the explicit heartbeat must all be captured.
+	want := "heos://system/heart_beat\r\n" +
+		"heos://system/prettify_json_response?enable=off\r\n" +
+		"heos://system/heart_beat\r\n"
+	if diff := cmp.Diff(want, sent.String()); diff != "" {
+		t.Fatalf("unexpected sent data (-want +got):\n%s", diff)
+	}
+
+	if n := strings.Count(recvd.String(), `"command"`); n != 3 {
+		t.Fatalf("expected 3 responses, but got %d:\n%s", n, recvd.String())
+	}
+}
@@ -0,0 +1,20 @@
+package heos
+
+import (
+	"io"
+	"time"
+)
+
+// A Transport is a bidirectional stream to a HEOS device, used by a Client to
+// send requests and receive responses and events. A net.Conn is a Transport,
+// so a Client can use a TCP connection, one end of a net.Pipe for in-memory
+// testing, or any other connection.
+//
+// A Transport must support deadlines, which the Client uses for timeouts and
+// context cancelation: a pending Read or Write must return promptly when the
+// deadline passes, with an error whose Timeout method reports true, such as
+// os.ErrDeadlineExceeded. A zero deadline disables the deadline.
+type Transport interface {
+	io.ReadWriteCloser
+	SetDeadline(t time.Time) error
+}
@@ -0,0 +1,212 @@
+package heos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrVolumeLimit is returned when VolumeLimit.Reject is set and a player's
+// volume level would exceed its limit.
+var ErrVolumeLimit = errors.New("heos: volume level exceeds limit")
+
+// A VolumeLimit enforces maximum volume levels for players on the client side,
+// such as in a child's bedroom. A group's volume is limited to the lowest
+// limit of the players in the group. Volume steps which would exceed a limit
+// are replaced by setting the volume to the limit.
+type VolumeLimit struct {
+	// Max is the maximum volume level for all players. If zero, players have
+	// no limit unless one is set in Players.
+	Max int
+
+	// Players optionally sets maximum volume levels for the players specified
+	// by pid, overriding Max.
+	Players map[PlayerID]int
+
+	// Reject, if true, causes requests to set a player's volume level above
+	// its limit to fail with ErrVolumeLimit. Otherwise, the level is clamped
+	// to the limit.
+	Reject bool
+}
+
+// limit returns the maximum volume level for the player specified by pid, and
+// whether the player has a limit.
+func (vl *VolumeLimit) limit(pid PlayerID) (int, bool) {
+	if vl == nil {
+		return 0, false
+	}
+	if max, ok := vl.Players[pid]; ok {
+		return max, true
+	}
+
+	return vl.Max, vl.Max > 0
+}
+
+// groupLimit returns the lowest maximum volume level of the players in gi,
+// and whether any of the players has a limit.
+func (vl *VolumeLimit) groupLimit(gi *GroupInfo) (int, bool) {
+	var (
+		min   int
+		found bool
+	)
+	for _, p := range gi.Players {
+		max, ok := vl.limit(p.PID)
+		if ok && (!found || max < min) {
+			min, found = max, true
+		}
+	}
+
+	return min, found
+}
+
+// apply applies the limit for the player specified by pid to level.
+func (vl *VolumeLimit) apply(pid PlayerID, level int) (int, error) {
+	max, ok := vl.limit(pid)
+	if !ok {
+		return level, nil
+	}
+
+	return vl.clamp(fmt.Sprintf("player %d", pid), level, max)
+}
+
+// clamp applies the limit max to level for the player or group described by
+// target.
+func (vl *VolumeLimit) clamp(target string, level, max int) (int, error) {
+	if level <= max {
+		return level, nil
+	}
+	if vl.Reject {
+		return 0, fmt.Errorf("%w: %s level %d, limit %d", ErrVolumeLimit, target, level, max)
+	}
+
+	return max, nil
+}
+
+// step determines whether increasing the current volume level, returned by
+// get, by step would exceed the limit max for the player or group described
+// by target. If so, step returns the limited level which should be set
+// instead. Otherwise, step returns -1 and the volume step may be issued.
+func (vl *VolumeLimit) step(target string, max, step int, get func() (int, error)) (int, error) {
+	level, err := get()
+	if err != nil {
+		return 0, err
+	}
+
+	level += step
+	if level > 100 {
+		level = 100
+	}
+	if level <= max {
+		return -1, nil
+	}
+
+	return vl.clamp(target, level, max)
+}
+
+// minFadeInterval is the minimum time between volume changes during a fade,
+// so that a device is not flooded with commands.
+const minFadeInterval = 100 * time.Millisecond
+
+// checkVolume verifies that level is a valid HEOS volume level.
+func checkVolume(level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("heos: volume level %d out of range 0-100", level)
+	}
+
+	return nil
+}
+
+// checkStep verifies that step is a valid HEOS volume step.
+func checkStep(step int) error {
+	if step < 1 || step > 10 {
+		return fmt.Errorf("heos: volume step %d out of range 1-10", step)
+	}
+
+	return nil
+}
+
+// EnforceVolumeLimit lowers the volume of any player whose volume is changed
+// above its Config.VolumeLimit by other means, such as a physical knob or
+// another HEOS app, as reported by PlayerVolumeChanged events received from
+// events. EnforceVolumeLimit consumes all events from events, which typically
+// come from an EventStream, and blocks until the context is canceled or events
+// is closed. The Client must not be the Client used by an EventStream.
+func (p *Players) EnforceVolumeLimit(ctx context.Context, events <-chan Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			e, ok := ev.(*PlayerVolumeChanged)
+			if !ok {
+				continue
+			}
+
+			if max, ok := p.c.volumeLimit.limit(e.PID); ok && e.Level > max {
+				p.c.log(ctx, slog.LevelInfo, "lowering volume to limit",
+					slog.Int("pid", int(e.PID)),
+					slog.Int("level", e.Level),
+					slog.Int("limit", max),
+				)
+
+				if err := p.SetVolume(ctx, e.PID, max); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// fadeVolume implements volume fades for players and groups using the get
+// and set functions to retrieve and change the current volume level.
+func fadeVolume(ctx context.Context, level int, d time.Duration, get func() (int, error), set func(level int) error) error {
+	if err := checkVolume(level); err != nil {
+		return err
+	}
+
+	start, err := get()
+	if err != nil {
+		return err
+	}
+
+	delta := level - start
+	if delta == 0 {
+		return nil
+	}
+
+	// Make one change per volume level if possible, but use larger changes if
+	// the duration is too short for that many commands.
+	steps := delta
+	if steps < 0 {
+		steps = -steps
+	}
+	if max := int(d / minFadeInterval); steps > max {
+		steps = max
+	}
+	if steps < 1 {
+		return set(level)
+	}
+
+	t := time.NewTicker(d / time.Duration(steps))
+	defer t.Stop()
+
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		if err := set(start + delta*i/steps); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,335 @@
+// Package wire implements encoding and decoding of the Denon HEOS protocol's
+// wire format.
+//
+// Requests are URIs of the form "heos://group/command?attr1=value1" terminated
+// by "\r\n", and responses and events are JSON objects with a "heos" header,
+// and optional "payload" and "options" fields.
+package wire
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scheme is the URI scheme prefix for all HEOS requests.
+const scheme = "heos://"
+
+// errInvalidRequest is returned when a request contains characters which would
+// corrupt the wire format.
+var errInvalidRequest = errors.New("wire: request must not contain CR or LF")
+
+// ErrTooLarge is returned by a Decoder when a Frame exceeds the Decoder's
+// maximum size.
+var ErrTooLarge = errors.New("wire: frame exceeds maximum size")
+
+// A Header is the "heos" object present in every HEOS response and event.
+type Header struct {
+	Command string `json:"command"`
+	Result  string `json:"result,omitempty"`
+	Message string `json:"message"`
+}
+
+// Attributes parses the Header's message into a set of attributes.
+func (h Header) Attributes() Attributes {
+	return ParseAttributes(h.Message)
+}
+
+// A Frame is a single HEOS protocol message sent by a device: either a
+// response to a command or an unsolicited event.
+type Frame struct {
+	HEOS    Header          `json:"heos"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Options json.RawMessage `json:"options,omitempty"`
+
+	// Raw is the raw JSON encoding of the entire Frame, as read by a Decoder.
+	Raw json.RawMessage `json:"-"`
+}
+
+// IsEvent reports whether the Frame is an unsolicited event, rather than a
+// response to a command.
+func (f *Frame) IsEvent() bool {
+	return strings.HasPrefix(f.HEOS.Command, "event/")
+}
+
+// AppendRequest appends the wire encoding of command to b. command is of the
+// form "system/heart_beat" or "player/get_volume?pid=1", and may optionally
+// include the "heos://" scheme prefix.
+func AppendRequest(b []byte, command string) ([]byte, error) {
+	if strings.ContainsAny(command, "\r\n") {
+		return nil, errInvalidRequest
+	}
+
+	if !strings.HasPrefix(command, scheme) {
+		b = append(b, scheme...)
+	}
+
+	b = append(b, command...)
+	return append(b, "\r\n"...), nil
+}
+
+// A Decoder reads Frames from an input stream. Frames may span multiple lines,
+// such as when a device has prettified JSON responses enabled.
+type Decoder struct {
+	d   *json.Decoder
+	r   *limitReader
+	max int64
+}
+
+// NewDecoder returns a Decoder which reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	lr := &limitReader{r: bufio.NewReader(r), limit: -1}
+	return &Decoder{d: json.NewDecoder(lr), r: lr}
+}
+
+// SetMaxSize sets the maximum size in bytes of each Frame read by the
+// Decoder. Decoding a larger Frame fails with ErrTooLarge once the limit is
+// reached, rather than buffering the entire Frame. The input stream cannot be
+// resynchronized after ErrTooLarge, so a new Decoder must be used for any
+// further reads. A size of zero or less disables the limit.
+func (d *Decoder) SetMaxSize(n int64) {
+	d.max = n
+}
+
+// start prepares the Decoder to read the next Frame.
+func (d *Decoder) start() {
+	if d.max <= 0 {
+		d.r.limit = -1
+		return
+	}
+
+	// The limit is relative to the end of the previous Frame. Bytes already
+	// buffered by the json.Decoder count toward the limit, so reads beyond it
+	// are only necessary for a Frame which is too large.
+	d.r.limit = d.d.InputOffset() + d.max
+}
+
+// Decode reads the next Frame from the input stream.
+func (d *Decoder) Decode() (*Frame, error) {
+	d.start()
+
+	var raw json.RawMessage
+	if err := d.d.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	f := Frame{Raw: raw}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// DecodeFunc reads the next Frame from the input stream like Decode, but
+// invokes fn to decode the Frame's payload directly from the stream rather
+// than buffering it, which reduces memory use for large payloads. fn is passed
+// the Header decoded so far, which devices send before the payload, and dec
+// positioned at the start of the payload value. fn must consume exactly one
+// JSON value from dec, such as by using ForEach or dec.Decode.
+//
+// The returned Frame's Payload and Raw fields are empty.
+func (d *Decoder) DecodeFunc(fn func(h Header, dec *json.Decoder) error) (*Frame, error) {
+	d.start()
+
+	if err := d.delim('{'); err != nil {
+		return nil, err
+	}
+
+	var f Frame
+	for d.d.More() {
+		tok, err := d.d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok {
+		case "heos":
+			err = d.d.Decode(&f.HEOS)
+		case "payload":
+			err = fn(f.HEOS, d.d)
+		case "options":
+			err = d.d.Decode(&f.Options)
+		default:
+			// Skip unknown fields.
+			var skip json.RawMessage
+			err = d.d.Decode(&skip)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.delim('}'); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// delim reads the next token from the input stream and verifies that it is
+// the delimiter want.
+func (d *Decoder) delim(want json.Delim) error {
+	tok, err := d.d.Token()
+	if err != nil {
+		return err
+	}
+
+	if got, ok := tok.(json.Delim); !ok || got != want {
+		return fmt.Errorf("wire: expected %q, but got %v", want, tok)
+	}
+
+	return nil
+}
+
+// A limitReader is an io.Reader which fails with ErrTooLarge once limit bytes
+// have been read in total. A negative limit disables the limit.
+type limitReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+// Read implements io.Reader.
+func (lr *limitReader) Read(b []byte) (int, error) {
+	if lr.limit >= 0 {
+		rem := lr.limit - lr.n
+		if rem <= 0 {
+			return 0, ErrTooLarge
+		}
+		if int64(len(b)) > rem {
+			b = b[:rem]
+		}
+	}
+
+	n, err := lr.r.Read(b)
+	lr.n += int64(n)
+	return n, err
+}
+
+// ForEach invokes fn for each element of the JSON array at the current
+// position of dec. fn must consume exactly one JSON value from dec, such as by
+// calling dec.Decode. A JSON null is treated as an empty array.
+func ForEach(dec *json.Decoder, fn func() error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("wire: expected JSON array, but got %v", tok)
+	}
+
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing bracket.
+	_, err = dec.Token()
+	return err
+}
+
+// Attributes are the key/value pairs carried in the message of a HEOS
+// response or event, such as "pid=1&level=20". Attributes which appear
+// without a value, such as "signed_out", have an empty value.
+type Attributes map[string]string
+
+// ParseAttributes parses a HEOS message into Attributes. Parsing is lenient:
+// malformed percent-encoding is left as-is rather than returning an error, so
+// that as much information as possible is preserved from device output.
+func ParseAttributes(s string) Attributes {
+	attrs := make(Attributes)
+	for _, kv := range strings.Split(s, "&") {
+		if kv == "" {
+			continue
+		}
+
+		k, v := kv, ""
+		if i := strings.IndexByte(kv, '='); i != -1 {
+			k, v = kv[:i], kv[i+1:]
+		}
+
+		if k == "" {
+			continue
+		}
+
+		attrs[unescape(k)] = unescape(v)
+	}
+
+	return attrs
+}
+
+// Int parses the value of key as an integer.
+func (a Attributes) Int(key string) (int, error) {
+	v, ok := a[key]
+	if !ok {
+		return 0, errors.New("wire: attribute " + strconv.Quote(key) + " not found")
+	}
+
+	return strconv.Atoi(v)
+}
+
+// Encode encodes Attributes in the form "k1=v1&k2=v2", escaping values as
+// necessary and sorting by key.
+func (a Attributes) Encode() string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(Escape(k))
+		if v := a[k]; v != "" {
+			sb.WriteByte('=')
+			sb.WriteString(Escape(v))
+		}
+	}
+
+	return sb.String()
+}
+
+// escaper escapes the characters which the HEOS protocol reserves in
+// attribute values.
+var escaper = strings.NewReplacer(
+	"%", "%25",
+	"&", "%26",
+	"=", "%3D",
+)
+
+// Escape escapes the characters reserved by the HEOS protocol ('%', '&', and
+// '=') in an attribute key or value.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// unescape reverses Escape, returning s unmodified if it contains malformed
+// percent-encoding.
+func unescape(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	// PathUnescape is used rather than QueryUnescape because HEOS does not
+	// treat '+' as a space.
+	u, err := url.PathUnescape(s)
+	if err != nil {
+		return s
+	}
+
+	return u
+}
@@ -0,0 +1,335 @@
+package wire_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/heos/wire"
+)
+
+func TestAppendRequest(t *testing.T) {
+	tests := []struct {
+		name, command, want string
+		ok                  bool
+	}{
+		{
+			name:    "no scheme",
+			command: "system/heart_beat",
+			want:    "heos://system/heart_beat\r\n",
+			ok:      true,
+		},
+		{
+			name:    "scheme",
+			command: "heos://player/get_volume?pid=1",
+			want:    "heos://player/get_volume?pid=1\r\n",
+			ok:      true,
+		},
+		{
+			name:    "newline",
+			command: "system/heart_beat\r\nsystem/reboot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := wire.AppendRequest(nil, tt.command)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to append request: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, string(b)); diff != "" {
+				t.Fatalf("unexpected request (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	// A compact response, a prettified response, and an event on one stream.
+	const in = `{"heos": {"command": "player/get_volume", "result": "success", "message": "pid=1&level=20"}}` + "\r\n" +
+		`{
+	"heos": {
+		"command": "player/get_players",
+		"result": "success",
+		"message": ""
+	},
+	"payload": [{"pid": 1}]
+}` + "\r\n" +
+		`{"heos": {"command": "event/player_volume_changed", "message": "pid=1&level=25&mute=off"}}` + "\r\n"
+
+	want := []*wire.Frame{
+		{
+			HEOS: wire.Header{
+				Command: "player/get_volume",
+				Result:  "success",
+				Message: "pid=1&level=20",
+			},
+		},
+		{
+			HEOS: wire.Header{
+				Command: "player/get_players",
+				Result:  "success",
+			},
+			Payload: json.RawMessage(`[{"pid": 1}]`),
+		},
+		{
+			HEOS: wire.Header{
+				Command: "event/player_volume_changed",
+				Message: "pid=1&level=25&mute=off",
+			},
+		},
+	}
+
+	d := wire.NewDecoder(strings.NewReader(in))
+
+	var got []*wire.Frame
+	for range want {
+		f, err := d.Decode()
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+
+		got = append(got, f)
+	}
+
+	// Raw data is verified separately to avoid duplicating the input.
+	for i, f := range got {
+		if !json.Valid(f.Raw) || !strings.Contains(string(f.Raw), f.HEOS.Command) {
+			t.Fatalf("unexpected raw data for frame %d: %s", i, f.Raw)
+		}
+		f.Raw = nil
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected frames (-want +got):\n%s", diff)
+	}
+
+	if !got[2].IsEvent() || got[0].IsEvent() {
+		t.Fatal("unexpected event classification")
+	}
+}
+
+func TestDecoderMaxSize(t *testing.T) {
+	// Frames within the limit are decoded normally, even when buffered
+	// together, until a frame exceeds it.
+	small := `{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}` + "\r\n"
+	large := `{"heos": {"command": "browse/browse", "result": "success", "message": ""}, "payload": "` + strings.Repeat("x", 4096) + `"}` + "\r\n"
+
+	d := wire.NewDecoder(strings.NewReader(small + small + large))
+	d.SetMaxSize(int64(len(small)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.Decode(); err != nil {
+			t.Fatalf("failed to decode frame %d: %v", i, err)
+		}
+	}
+
+	if _, err := d.Decode(); !errors.Is(err, wire.ErrTooLarge) {
+		t.Fatalf("expected wire.ErrTooLarge, but got: %v", err)
+	}
+
+	// Streamed payloads are also subject to the limit.
+	d = wire.NewDecoder(strings.NewReader(large))
+	d.SetMaxSize(1024)
+
+	_, err := d.DecodeFunc(func(_ wire.Header, dec *json.Decoder) error {
+		var skip json.RawMessage
+		return dec.Decode(&skip)
+	})
+	if !errors.Is(err, wire.ErrTooLarge) {
+		t.Fatalf("expected wire.ErrTooLarge, but got: %v", err)
+	}
+}
+
+func TestDecoderDecodeFunc(t *testing.T) {
+	// A streamed payload followed by a frame decoded normally, to verify that
+	// the stream remains aligned.
+	const in = `{"heos": {"command": "browse/browse", "result": "success", "message": "sid=1"}, "payload": [{"name": "a"}, {"name": "b"}], "options": [], "extra": {"x": 1}}` + "\r\n" +
+		`{"heos": {"command": "player/get_players", "result": "success", "message": ""}, "payload": null}` + "\r\n" +
+		`{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}` + "\r\n"
+
+	d := wire.NewDecoder(strings.NewReader(in))
+
+	var (
+		headers []wire.Header
+		names   []string
+	)
+	decode := func(h wire.Header, dec *json.Decoder) error {
+		headers = append(headers, h)
+		return wire.ForEach(dec, func() error {
+			var v struct {
+				Name string `json:"name"`
+			}
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+
+			names = append(names, v.Name)
+			return nil
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.DecodeFunc(decode); err != nil {
+			t.Fatalf("failed to decode frame %d: %v", i, err)
+		}
+	}
+
+	f, err := d.Decode()
+	if err != nil {
+		t.Fatalf("failed to decode final frame: %v", err)
+	}
+
+	if diff := cmp.Diff("system/heart_beat", f.HEOS.Command); diff != "" {
+		t.Fatalf("unexpected final command (-want +got):\n%s", diff)
+	}
+
+	wantHeaders := []wire.Header{
+		{Command: "browse/browse", Result: "success", Message: "sid=1"},
+		{Command: "player/get_players", Result: "success"},
+	}
+	if diff := cmp.Diff(wantHeaders, headers); diff != "" {
+		t.Fatalf("unexpected headers (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"a", "b"}, names); diff != "" {
+		t.Fatalf("unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	tests := []struct {
+		name, in string
+		want     wire.Attributes
+	}{
+		{
+			name: "empty",
+			want: wire.Attributes{},
+		},
+		{
+			name: "error",
+			in:   "eid=2&text=ID Not Valid&pid=1",
+			want: wire.Attributes{
+				"eid":  "2",
+				"text": "ID Not Valid",
+				"pid":  "1",
+			},
+		},
+		{
+			name: "flags",
+			in:   "command under process&sid=1",
+			want: wire.Attributes{
+				"command under process": "",
+				"sid":                   "1",
+			},
+		},
+		{
+			name: "escaped",
+			in:   "name=Rock %26 Roll+Radio&bad=100%",
+			want: wire.Attributes{
+				"name": "Rock & Roll+Radio",
+				"bad":  "100%",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, wire.ParseAttributes(tt.in)); diff != "" {
+				t.Fatalf("unexpected attributes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAttributesEncode(t *testing.T) {
+	attrs := wire.Attributes{
+		"pid":       "1",
+		"search":    "AC=DC & friends",
+		"signed_in": "",
+	}
+
+	const want = "pid=1&search=AC%3DDC %26 friends&signed_in"
+	got := attrs.Encode()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected encoding (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(attrs, wire.ParseAttributes(got)); diff != "" {
+		t.Fatalf("unexpected round trip attributes (-want +got):\n%s", diff)
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	const frame = `{"heos": {"command": "event/player_now_playing_progress", "message": "pid=1&cur_pos=61500&duration=240000"}}` + "\r\n"
+	in := strings.NewReader(strings.Repeat(frame, b.N))
+	d := wire.NewDecoder(in)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := d.Decode(); err != nil {
+			b.Fatalf("failed to decode: %v", err)
+		}
+	}
+}
+
+func BenchmarkAppendRequest(b *testing.B) {
+	var buf []byte
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		buf, err = wire.AppendRequest(buf[:0], "player/get_volume?pid=1")
+		if err != nil {
+			b.Fatalf("failed to append request: %v", err)
+		}
+	}
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Add([]byte(`{"heos": {"command": "system/heart_beat", "result": "success", "message": ""}}` + "\r\n"))
+	f.Add([]byte(`{"heos": {"command": "event/player_state_changed", "message": "pid=1&state=play"}}`))
+	f.Add([]byte(`{"heos": {"command": "browse/browse"}, "payload": [{"sid": 1}], "options": []}`))
+	f.Add([]byte(`{"heos": `))
+	f.Add([]byte(`[1, 2, 3]`))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		d := wire.NewDecoder(strings.NewReader(string(b)))
+		for {
+			fr, err := d.Decode()
+			if err != nil {
+				return
+			}
+
+			_ = fr.IsEvent()
+			_ = fr.HEOS.Attributes()
+		}
+	})
+}
+
+func FuzzAttributes(f *testing.F) {
+	f.Add("eid=2&text=ID Not Valid&pid=1")
+	f.Add("command under process&sid=1")
+	f.Add("a=%zz&=&&b==c%")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		attrs := wire.ParseAttributes(s)
+
+		// Encoding parsed attributes must always round trip.
+		if diff := cmp.Diff(attrs, wire.ParseAttributes(attrs.Encode())); diff != "" {
+			t.Fatalf("unexpected round trip attributes (-want +got):\n%s", diff)
+		}
+	})
+}